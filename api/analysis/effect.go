@@ -0,0 +1,146 @@
+package analysis
+
+import "math"
+
+// Effect compares mood valence between entries with and without some
+// exposure (a food, a tag, ...)
+type Effect struct {
+	WithCount      int     `json:"withCount"`
+	WithoutCount   int     `json:"withoutCount"`
+	WithMean       float64 `json:"withMean"`
+	WithoutMean    float64 `json:"withoutMean"`
+	Difference     float64 `json:"difference"`
+	EffectSize     float64 `json:"effectSize"`
+	ConfidenceLow  float64 `json:"confidenceLow"`
+	ConfidenceHigh float64 `json:"confidenceHigh"`
+	Confidence     string  `json:"confidence"`
+}
+
+// Correlation is a Pearson correlation with a 95% confidence interval
+type Correlation struct {
+	Count          int     `json:"count"`
+	R              float64 `json:"r"`
+	ConfidenceLow  float64 `json:"confidenceLow"`
+	ConfidenceHigh float64 `json:"confidenceHigh"`
+	Confidence     string  `json:"confidence"`
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func variance(values []float64, m float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += (v - m) * (v - m)
+	}
+	return sum / float64(len(values)-1)
+}
+
+// confidenceLabel turns a z/t statistic into a coarse label clients can show,
+// given its two-sided critical values at 95% and 99%
+func confidenceLabel(stat, critical95, critical99 float64) string {
+	switch stat = math.Abs(stat); {
+	case stat >= critical99:
+		return "high"
+	case stat >= critical95:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+// Compare reports the difference in means between two groups with Cohen's d
+// as effect size and a Welch 95% confidence interval for the difference. The
+// interval uses the t distribution with Welch's degrees of freedom, so small
+// groups get wide intervals. It returns false when either group has fewer
+// than minSamples values.
+func Compare(with, without []float64, minSamples int) (Effect, bool) {
+	if minSamples < 2 {
+		minSamples = 2
+	}
+	if len(with) < minSamples || len(without) < minSamples {
+		return Effect{}, false
+	}
+
+	withMean, withoutMean := mean(with), mean(without)
+	withVar, withoutVar := variance(with, withMean), variance(without, withoutMean)
+	n1, n0 := float64(len(with)), float64(len(without))
+
+	effect := Effect{
+		WithCount:    len(with),
+		WithoutCount: len(without),
+		WithMean:     withMean,
+		WithoutMean:  withoutMean,
+		Difference:   withMean - withoutMean,
+	}
+
+	pooled := math.Sqrt(((n1-1)*withVar + (n0-1)*withoutVar) / (n1 + n0 - 2))
+	if pooled > 0 {
+		effect.EffectSize = effect.Difference / pooled
+	}
+
+	withSE, withoutSE := withVar/n1, withoutVar/n0
+	standardError := math.Sqrt(withSE + withoutSE)
+	if standardError == 0 {
+		effect.ConfidenceLow, effect.ConfidenceHigh = effect.Difference, effect.Difference
+		effect.Confidence = "low"
+		return effect, true
+	}
+
+	// Welch-Satterthwaite degrees of freedom
+	df := (withSE + withoutSE) * (withSE + withoutSE) /
+		(withSE*withSE/(n1-1) + withoutSE*withoutSE/(n0-1))
+	critical95, critical99 := studentQuantile(0.975, df), studentQuantile(0.995, df)
+
+	effect.ConfidenceLow = effect.Difference - critical95*standardError
+	effect.ConfidenceHigh = effect.Difference + critical95*standardError
+	effect.Confidence = confidenceLabel(effect.Difference/standardError, critical95, critical99)
+
+	return effect, true
+}
+
+// Pearson correlates xs with ys. It returns false when there are fewer than
+// minSamples pairs or either series is constant.
+func Pearson(xs, ys []float64, minSamples int) (Correlation, bool) {
+	if minSamples < 4 {
+		minSamples = 4
+	}
+	if len(xs) != len(ys) || len(xs) < minSamples {
+		return Correlation{}, false
+	}
+
+	mx, my := mean(xs), mean(ys)
+	var sxy, sxx, syy float64
+	for i := range xs {
+		dx, dy := xs[i]-mx, ys[i]-my
+		sxy += dx * dy
+		sxx += dx * dx
+		syy += dy * dy
+	}
+	if sxx == 0 || syy == 0 {
+		return Correlation{}, false
+	}
+
+	r := sxy / math.Sqrt(sxx*syy)
+	r = math.Max(-0.999999, math.Min(0.999999, r))
+
+	// Fisher z-transformation for the confidence interval
+	z := math.Atanh(r)
+	standardError := 1 / math.Sqrt(float64(len(xs)-3))
+
+	return Correlation{
+		Count:          len(xs),
+		R:              r,
+		ConfidenceLow:  math.Tanh(z - 1.96*standardError),
+		ConfidenceHigh: math.Tanh(z + 1.96*standardError),
+		Confidence:     confidenceLabel(z/standardError, 1.96, 2.58),
+	}, true
+}
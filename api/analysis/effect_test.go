@@ -0,0 +1,53 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+)
+
+func TestStudentQuantile(t *testing.T) {
+	tests := []struct {
+		p, df, want float64
+	}{
+		{0.975, 1, 12.706},
+		{0.975, 4, 2.776},
+		{0.975, 10, 2.228},
+		{0.975, 30, 2.042},
+		{0.995, 8, 3.355},
+		{0.975, 7.5, 2.333},
+		{0.975, math.Inf(1), 1.960},
+	}
+	for _, test := range tests {
+		if got := studentQuantile(test.p, test.df); math.Abs(got-test.want) > 0.002 {
+			t.Errorf("studentQuantile(%v, %v) = %.4f, want %.3f", test.p, test.df, got, test.want)
+		}
+	}
+}
+
+func TestCompareSmallSamplesUseT(t *testing.T) {
+	with := []float64{0.6, 0.8, 0.7, 0.9, 0.5}
+	without := []float64{0.1, 0.3, 0.2, 0.4, 0.0}
+
+	effect, ok := Compare(with, without, 5)
+	if !ok {
+		t.Fatal("expected a comparison")
+	}
+	if math.Abs(effect.Difference-0.5) > 1e-9 {
+		t.Errorf("difference %v, want 0.5", effect.Difference)
+	}
+
+	// Equal variances of 0.025 give a standard error of 0.1 and 8 degrees
+	// of freedom, so the interval is 0.5 ± 2.306 * 0.1
+	if math.Abs(effect.ConfidenceLow-0.2694) > 0.001 || math.Abs(effect.ConfidenceHigh-0.7306) > 0.001 {
+		t.Errorf("interval [%.4f, %.4f], want [0.2694, 0.7306]", effect.ConfidenceLow, effect.ConfidenceHigh)
+	}
+	if effect.Confidence != "high" {
+		t.Errorf("confidence %q, want high", effect.Confidence)
+	}
+}
+
+func TestCompareNeedsSamples(t *testing.T) {
+	if _, ok := Compare([]float64{1, 2}, []float64{1, 2, 3}, 3); ok {
+		t.Error("expected too few samples to be rejected")
+	}
+}
@@ -0,0 +1,91 @@
+package analysis
+
+import "math"
+
+// studentQuantile returns the p quantile (0.5 < p < 1) of Student's t
+// distribution with df degrees of freedom. df need not be whole, as with
+// Welch's approximation.
+func studentQuantile(p, df float64) float64 {
+	if math.IsInf(df, 1) || df > 1e7 {
+		df = 1e7
+	}
+	// The CDF is increasing, so bisect between 0 and a bound above the
+	// quantile
+	low, high := 0.0, 1.0
+	for studentCDF(high, df) < p {
+		low, high = high, high*2
+	}
+	for i := 0; i < 100 && high-low > 1e-9; i++ {
+		mid := (low + high) / 2
+		if studentCDF(mid, df) < p {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return (low + high) / 2
+}
+
+// studentCDF is P(T <= t) for Student's t distribution with df degrees of
+// freedom
+func studentCDF(t, df float64) float64 {
+	tail := 0.5 * regularizedBeta(df/(df+t*t), df/2, 0.5)
+	if t > 0 {
+		return 1 - tail
+	}
+	return tail
+}
+
+// regularizedBeta is the regularized incomplete beta function I_x(a, b),
+// evaluated with its continued fraction
+func regularizedBeta(x, a, b float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	lbetaA, _ := math.Lgamma(a)
+	lbetaB, _ := math.Lgamma(b)
+	lbetaAB, _ := math.Lgamma(a + b)
+	front := math.Exp(lbetaAB - lbetaA - lbetaB + a*math.Log(x) + b*math.Log(1-x))
+
+	// The continued fraction converges fast only below the mean
+	if x > (a+1)/(a+b+2) {
+		return 1 - front*betaFraction(1-x, b, a)/b
+	}
+	return front * betaFraction(x, a, b) / a
+}
+
+// betaFraction evaluates the continued fraction of the incomplete beta
+// function with the modified Lentz method
+func betaFraction(x, a, b float64) float64 {
+	const tiny = 1e-300
+	c, d := 1.0, 1-(a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	result := d
+	for m := 1.0; m <= 300; m++ {
+		for _, numerator := range []float64{
+			m * (b - m) * x / ((a + 2*m - 1) * (a + 2*m)),
+			-(a + m) * (a + b + m) * x / ((a + 2*m) * (a + 2*m + 1)),
+		} {
+			d = 1 + numerator*d
+			if math.Abs(d) < tiny {
+				d = tiny
+			}
+			c = 1 + numerator/c
+			if math.Abs(c) < tiny {
+				c = tiny
+			}
+			d = 1 / d
+			result *= c * d
+		}
+		if math.Abs(c*d-1) < 1e-15 {
+			break
+		}
+	}
+	return result
+}
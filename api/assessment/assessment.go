@@ -0,0 +1,124 @@
+// Package assessment defines standardized questionnaires (PHQ-9, GAD-7,
+// WHO-5) and scores answers to them
+package assessment
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	PHQ9 = "phq9"
+	GAD7 = "gad7"
+	WHO5 = "who5"
+)
+
+var (
+	ErrUnknownQuestionnaire = errors.New("unknown questionnaire")
+	ErrInvalidAnswers       = errors.New("invalid answers")
+)
+
+type Option struct {
+	Value int    `json:"value"`
+	Label string `json:"label"`
+}
+
+// Band is a severity range of the final score, both ends inclusive
+type Band struct {
+	Min      int    `json:"min"`
+	Max      int    `json:"max"`
+	Severity string `json:"severity"`
+	Label    string `json:"label"`
+}
+
+type Questionnaire struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Title        string   `json:"title"`
+	Instructions string   `json:"instructions"`
+	Questions    []string `json:"questions"`
+	// Options apply to every question
+	Options []Option `json:"options"`
+	// Multiplier turns the sum of the answers into the final score
+	Multiplier     int    `json:"-"`
+	MaxScore       int    `json:"maxScore"`
+	HigherIsBetter bool   `json:"higherIsBetter"`
+	Bands          []Band `json:"bands"`
+	// SafetyItems are questions (zero based) where any non-zero answer must
+	// be followed up
+	SafetyItems []int `json:"-"`
+}
+
+// Result is a scored set of answers
+type Result struct {
+	Score         int    `json:"score"`
+	MaxScore      int    `json:"maxScore"`
+	Severity      string `json:"severity"`
+	SeverityLabel string `json:"severityLabel"`
+	SafetyFlag    bool   `json:"safetyFlag"`
+}
+
+// Questionnaires are listed in the order they are offered
+var Questionnaires = []Questionnaire{phq9, gad7, who5}
+
+// Find returns the questionnaire with id
+func Find(id string) (Questionnaire, bool) {
+	for _, q := range Questionnaires {
+		if q.ID == id {
+			return q, true
+		}
+	}
+	return Questionnaire{}, false
+}
+
+// Score validates answers, one per question, and scores them
+func Score(id string, answers []int) (Result, error) {
+	q, ok := Find(id)
+	if !ok {
+		return Result{}, ErrUnknownQuestionnaire
+	}
+	return q.Score(answers)
+}
+
+func (q Questionnaire) Score(answers []int) (Result, error) {
+	if len(answers) != len(q.Questions) {
+		return Result{}, fmt.Errorf("%w: %s has %d questions, got %d answers", ErrInvalidAnswers, q.Name, len(q.Questions), len(answers))
+	}
+
+	var sum int
+	for i, answer := range answers {
+		if !q.validAnswer(answer) {
+			return Result{}, fmt.Errorf("%w: answer %d is not one of the options", ErrInvalidAnswers, i+1)
+		}
+		sum += answer
+	}
+
+	result := Result{Score: sum * q.Multiplier, MaxScore: q.MaxScore}
+	band := q.Band(result.Score)
+	result.Severity, result.SeverityLabel = band.Severity, band.Label
+	for _, item := range q.SafetyItems {
+		if answers[item] > 0 {
+			result.SafetyFlag = true
+		}
+	}
+	return result, nil
+}
+
+// Band returns the severity band score falls into
+func (q Questionnaire) Band(score int) Band {
+	for _, band := range q.Bands {
+		if score >= band.Min && score <= band.Max {
+			return band
+		}
+	}
+	return Band{}
+}
+
+func (q Questionnaire) validAnswer(answer int) bool {
+	for _, option := range q.Options {
+		if option.Value == answer {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package assessment
+
+import (
+	"errors"
+	"testing"
+)
+
+// answersSumming spreads sum over count answers of at most max each
+func answersSumming(count, sum, max int) []int {
+	answers := make([]int, count)
+	for i := range answers {
+		answers[i] = min(sum, max)
+		sum -= answers[i]
+	}
+	return answers
+}
+
+func TestScoreBands(t *testing.T) {
+	tests := []struct {
+		id       string
+		sum      int
+		score    int
+		severity string
+	}{
+		{PHQ9, 4, 4, "minimal"},
+		{PHQ9, 5, 5, "mild"},
+		{PHQ9, 9, 9, "mild"},
+		{PHQ9, 10, 10, "moderate"},
+		{PHQ9, 14, 14, "moderate"},
+		{PHQ9, 15, 15, "moderately_severe"},
+		{PHQ9, 19, 19, "moderately_severe"},
+		{PHQ9, 20, 20, "severe"},
+		{PHQ9, 27, 27, "severe"},
+
+		{GAD7, 0, 0, "minimal"},
+		{GAD7, 4, 4, "minimal"},
+		{GAD7, 5, 5, "mild"},
+		{GAD7, 9, 9, "mild"},
+		{GAD7, 10, 10, "moderate"},
+		{GAD7, 14, 14, "moderate"},
+		{GAD7, 15, 15, "severe"},
+		{GAD7, 21, 21, "severe"},
+
+		// WHO-5 sums are multiplied by 4
+		{WHO5, 0, 0, "low"},
+		{WHO5, 7, 28, "low"},
+		{WHO5, 8, 32, "reduced"},
+		{WHO5, 12, 48, "reduced"},
+		{WHO5, 13, 52, "good"},
+		{WHO5, 25, 100, "good"},
+	}
+	for _, test := range tests {
+		q, _ := Find(test.id)
+		maxAnswer := q.Options[0].Value
+		for _, option := range q.Options {
+			maxAnswer = max(maxAnswer, option.Value)
+		}
+
+		result, err := Score(test.id, answersSumming(len(q.Questions), test.sum, maxAnswer))
+		if err != nil {
+			t.Errorf("Score(%s, sum %d): %v", test.id, test.sum, err)
+			continue
+		}
+		if result.Score != test.score || result.Severity != test.severity {
+			t.Errorf("Score(%s, sum %d) = %d %s, want %d %s", test.id, test.sum, result.Score, result.Severity, test.score, test.severity)
+		}
+		if result.MaxScore != q.MaxScore {
+			t.Errorf("Score(%s) MaxScore = %d, want %d", test.id, result.MaxScore, q.MaxScore)
+		}
+	}
+}
+
+func TestScoreSafetyFlag(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		answers []int
+		flag    bool
+	}{
+		{"phq9 item 9 answered", PHQ9, []int{0, 0, 0, 0, 0, 0, 0, 0, 1}, true},
+		{"phq9 item 9 alone in a minimal score", PHQ9, []int{0, 0, 0, 0, 0, 0, 0, 0, 3}, true},
+		{"phq9 other items high", PHQ9, []int{3, 3, 3, 3, 3, 3, 3, 3, 0}, false},
+		{"gad7 has no safety item", GAD7, []int{3, 3, 3, 3, 3, 3, 3}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := Score(test.id, test.answers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.SafetyFlag != test.flag {
+				t.Errorf("SafetyFlag = %v, want %v", result.SafetyFlag, test.flag)
+			}
+		})
+	}
+}
+
+func TestScoreInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		answers []int
+		err     error
+	}{
+		{"unknown questionnaire", "bdi", []int{0}, ErrUnknownQuestionnaire},
+		{"too few answers", PHQ9, []int{0, 0, 0, 0, 0, 0, 0, 0}, ErrInvalidAnswers},
+		{"too many answers", GAD7, []int{0, 0, 0, 0, 0, 0, 0, 0}, ErrInvalidAnswers},
+		{"no answers", WHO5, nil, ErrInvalidAnswers},
+		{"answer above options", PHQ9, []int{0, 0, 0, 0, 4, 0, 0, 0, 0}, ErrInvalidAnswers},
+		{"negative answer", GAD7, []int{0, -1, 0, 0, 0, 0, 0}, ErrInvalidAnswers},
+		{"who5 answer above options", WHO5, []int{5, 5, 5, 5, 6}, ErrInvalidAnswers},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Score(test.id, test.answers); !errors.Is(err, test.err) {
+				t.Errorf("Score() error = %v, want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestBandsCoverScores(t *testing.T) {
+	// Every reachable score falls in exactly one band
+	for _, q := range Questionnaires {
+		for score := 0; score <= q.MaxScore; score++ {
+			matches := 0
+			for _, band := range q.Bands {
+				if score >= band.Min && score <= band.Max {
+					matches++
+				}
+			}
+			if matches != 1 {
+				t.Errorf("%s score %d is in %d bands, want 1", q.ID, score, matches)
+			}
+		}
+		if band := q.Band(q.MaxScore + 1); band.Severity != "" {
+			t.Errorf("%s Band(%d) = %s, want none", q.ID, q.MaxScore+1, band.Severity)
+		}
+	}
+}
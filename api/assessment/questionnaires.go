@@ -0,0 +1,99 @@
+package assessment
+
+// The items and answer options below are the published English wording of
+// each instrument. Scores are only comparable to the validated cut-offs if
+// the wording is kept as is.
+
+var frequencyOptions = []Option{
+	{Value: 0, Label: "Not at all"},
+	{Value: 1, Label: "Several days"},
+	{Value: 2, Label: "More than half the days"},
+	{Value: 3, Label: "Nearly every day"},
+}
+
+var phq9 = Questionnaire{
+	ID:           PHQ9,
+	Name:         "PHQ-9",
+	Title:        "Patient Health Questionnaire",
+	Instructions: "Over the last 2 weeks, how often have you been bothered by any of the following problems?",
+	Questions: []string{
+		"Little interest or pleasure in doing things",
+		"Feeling down, depressed, or hopeless",
+		"Trouble falling or staying asleep, or sleeping too much",
+		"Feeling tired or having little energy",
+		"Poor appetite or overeating",
+		"Feeling bad about yourself — or that you are a failure or have let yourself or your family down",
+		"Trouble concentrating on things, such as reading the newspaper or watching television",
+		"Moving or speaking so slowly that other people could have noticed? Or the opposite — being so fidgety or restless that you have been moving around a lot more than usual",
+		"Thoughts that you would be better off dead or of hurting yourself in some way",
+	},
+	Options:    frequencyOptions,
+	Multiplier: 1,
+	MaxScore:   27,
+	Bands: []Band{
+		{Min: 0, Max: 4, Severity: "minimal", Label: "Minimal depression"},
+		{Min: 5, Max: 9, Severity: "mild", Label: "Mild depression"},
+		{Min: 10, Max: 14, Severity: "moderate", Label: "Moderate depression"},
+		{Min: 15, Max: 19, Severity: "moderately_severe", Label: "Moderately severe depression"},
+		{Min: 20, Max: 27, Severity: "severe", Label: "Severe depression"},
+	},
+	// Any answer above "Not at all" on item 9 needs follow-up, whatever the
+	// total score
+	SafetyItems: []int{8},
+}
+
+var gad7 = Questionnaire{
+	ID:           GAD7,
+	Name:         "GAD-7",
+	Title:        "Generalized Anxiety Disorder Assessment",
+	Instructions: "Over the last 2 weeks, how often have you been bothered by the following problems?",
+	Questions: []string{
+		"Feeling nervous, anxious or on edge",
+		"Not being able to stop or control worrying",
+		"Worrying too much about different things",
+		"Trouble relaxing",
+		"Being so restless that it is hard to sit still",
+		"Becoming easily annoyed or irritable",
+		"Feeling afraid as if something awful might happen",
+	},
+	Options:    frequencyOptions,
+	Multiplier: 1,
+	MaxScore:   21,
+	Bands: []Band{
+		{Min: 0, Max: 4, Severity: "minimal", Label: "Minimal anxiety"},
+		{Min: 5, Max: 9, Severity: "mild", Label: "Mild anxiety"},
+		{Min: 10, Max: 14, Severity: "moderate", Label: "Moderate anxiety"},
+		{Min: 15, Max: 21, Severity: "severe", Label: "Severe anxiety"},
+	},
+}
+
+// who5 is scored as a percentage: the raw 0-25 sum times 4
+var who5 = Questionnaire{
+	ID:           WHO5,
+	Name:         "WHO-5",
+	Title:        "WHO-5 Well-Being Index",
+	Instructions: "Please indicate for each of the five statements which is closest to how you have been feeling over the last two weeks.",
+	Questions: []string{
+		"I have felt cheerful and in good spirits",
+		"I have felt calm and relaxed",
+		"I have felt active and vigorous",
+		"I woke up feeling fresh and rested",
+		"My daily life has been filled with things that interest me",
+	},
+	Options: []Option{
+		{Value: 5, Label: "All of the time"},
+		{Value: 4, Label: "Most of the time"},
+		{Value: 3, Label: "More than half of the time"},
+		{Value: 2, Label: "Less than half of the time"},
+		{Value: 1, Label: "Some of the time"},
+		{Value: 0, Label: "At no time"},
+	},
+	Multiplier:     4,
+	MaxScore:       100,
+	HigherIsBetter: true,
+	Bands: []Band{
+		{Min: 0, Max: 28, Severity: "low", Label: "Low well-being, screening for depression advised"},
+		{Min: 29, Max: 50, Severity: "reduced", Label: "Reduced well-being"},
+		{Min: 51, Max: 100, Severity: "good", Label: "Good well-being"},
+	},
+}
@@ -0,0 +1,394 @@
+package handler
+
+import (
+	"api/assessment"
+	"api/prisma/db"
+	"api/report"
+	"api/safety"
+	"api/storage"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AssessmentInput struct {
+	Questionnaire string `json:"questionnaire" binding:"required"`
+	Answers       []int  `json:"answers" binding:"required"`
+}
+
+// AssessmentView is a stored assessment with its answers decoded and its
+// severity band spelled out
+type AssessmentView struct {
+	ID            int       `json:"id"`
+	Questionnaire string    `json:"questionnaire"`
+	Name          string    `json:"name"`
+	Answers       []int     `json:"answers"`
+	Score         int       `json:"score"`
+	MaxScore      int       `json:"maxScore"`
+	Severity      string    `json:"severity"`
+	SeverityLabel string    `json:"severityLabel"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+type AssessmentResponse struct {
+	AssessmentView
+	Safety *SafetyPayload `json:"safety,omitempty"`
+}
+
+type AssessmentPoint struct {
+	Date     string `json:"date"`
+	Score    int    `json:"score"`
+	Severity string `json:"severity"`
+}
+
+// AssessmentSummary sums up one questionnaire over a stats range. Change is
+// the latest score minus the first; whether that is good depends on
+// HigherIsBetter.
+type AssessmentSummary struct {
+	Questionnaire  string            `json:"questionnaire"`
+	Name           string            `json:"name"`
+	MaxScore       int               `json:"maxScore"`
+	HigherIsBetter bool              `json:"higherIsBetter"`
+	Count          int               `json:"count"`
+	Average        float64           `json:"average"`
+	Latest         AssessmentPoint   `json:"latest"`
+	Change         int               `json:"change"`
+	Series         []AssessmentPoint `json:"series"`
+}
+
+type AssessmentStats struct {
+	From           string              `json:"from"`
+	To             string              `json:"to"`
+	Timezone       string              `json:"timezone"`
+	Questionnaires []AssessmentSummary `json:"questionnaires"`
+}
+
+func assessmentView(model db.AssessmentModel) AssessmentView {
+	view := AssessmentView{
+		ID:            model.ID,
+		Questionnaire: model.Questionnaire,
+		Answers:       []int{},
+		Score:         model.Score,
+		Severity:      model.Severity,
+		CreatedAt:     model.CreatedAt,
+	}
+	_ = json.Unmarshal([]byte(model.Answers), &view.Answers)
+	if q, ok := assessment.Find(model.Questionnaire); ok {
+		view.Name = q.Name
+		view.MaxScore = q.MaxScore
+		view.SeverityLabel = q.Band(model.Score).Label
+	}
+	return view
+}
+
+// queryAssessments returns the user's assessments in [from, to), oldest first
+func queryAssessments(ctx context.Context, client *db.PrismaClient, userID int, from, to time.Time) ([]db.AssessmentModel, error) {
+	return client.Assessment.FindMany(
+		db.Assessment.User.Where(
+			db.User.ID.Equals(userID),
+		),
+		db.Assessment.CreatedAt.Gte(from),
+		db.Assessment.CreatedAt.Lt(to),
+	).OrderBy(
+		db.Assessment.CreatedAt.Order(db.SortOrderAsc),
+	).Exec(ctx)
+}
+
+// reportAssessments lists the scores of the assessments taken in a report
+// period
+func reportAssessments(assessments []db.AssessmentModel, loc *time.Location) []report.AssessmentScore {
+	scores := []report.AssessmentScore{}
+	for _, model := range assessments {
+		view := assessmentView(model)
+		scores = append(scores, report.AssessmentScore{
+			Date:     model.CreatedAt.In(loc).Format("2006-01-02"),
+			Name:     view.Name,
+			Score:    view.Score,
+			MaxScore: view.MaxScore,
+			Severity: view.SeverityLabel,
+		})
+	}
+	return scores
+}
+
+// GetQuestionnaires lists the questionnaires that can be answered
+func GetQuestionnaires() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, assessment.Questionnaires)
+	}
+}
+
+func GetQuestionnaire() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		q, ok := assessment.Find(c.Param("id"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Questionnaire not found"})
+			return
+		}
+		c.JSON(http.StatusOK, q)
+	}
+}
+
+// CreateAssessment scores and stores a completed questionnaire. A PHQ-9
+// answer indicating thoughts of self-harm adds the safety payload. Stored
+// reports of the current week and month are dropped.
+func CreateAssessment(client *db.PrismaClient, store storage.BlobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input AssessmentInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+
+		result, err := assessment.Score(input.Questionnaire, input.Answers)
+		if err != nil {
+			if errors.Is(err, assessment.ErrUnknownQuestionnaire) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Questionnaire not found"})
+			} else {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid answers: " + err.Error()})
+			}
+			return
+		}
+
+		answers, err := json.Marshal(input.Answers)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode answers"})
+			return
+		}
+
+		created, err := client.Assessment.CreateOne(
+			db.Assessment.User.Link(
+				db.User.ID.Equals(userIDInt),
+			),
+			db.Assessment.Questionnaire.Set(input.Questionnaire),
+			db.Assessment.Answers.Set(string(answers)),
+			db.Assessment.Score.Set(result.Score),
+			db.Assessment.Severity.Set(result.Severity),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create assessment: " + err.Error()})
+			return
+		}
+
+		invalidateStoredReports(c.Request.Context(), client, store, userIDInt, created.CreatedAt)
+
+		response := AssessmentResponse{AssessmentView: assessmentView(*created)}
+		// The answers carry no text, so the message follows the user's country
+		if result.SafetyFlag {
+			response.Safety = safetyPayloadFor(c.Request.Context(), client, userIDInt, safety.SeverityConcern, "")
+		}
+
+		c.JSON(http.StatusCreated, response)
+	}
+}
+
+// GetAssessments lists the user's assessments newest first, only those of
+// ?questionnaire= if given
+func GetAssessments(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		filters := []db.AssessmentWhereParam{
+			db.Assessment.User.Where(
+				db.User.ID.Equals(int(userID.(uint))),
+			),
+		}
+		if questionnaire := c.Query("questionnaire"); questionnaire != "" {
+			if _, ok := assessment.Find(questionnaire); !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown questionnaire"})
+				return
+			}
+			filters = append(filters, db.Assessment.Questionnaire.Equals(questionnaire))
+		}
+
+		assessments, err := client.Assessment.FindMany(
+			filters...,
+		).OrderBy(
+			db.Assessment.CreatedAt.Order(db.SortOrderDesc),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch assessments"})
+			return
+		}
+
+		views := []AssessmentView{}
+		for _, model := range assessments {
+			views = append(views, assessmentView(model))
+		}
+
+		c.JSON(http.StatusOK, views)
+	}
+}
+
+func GetAssessment(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		assessmentID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assessment ID"})
+			return
+		}
+
+		found, err := client.Assessment.FindFirst(
+			db.Assessment.ID.Equals(assessmentID),
+			db.Assessment.User.Where(
+				db.User.ID.Equals(int(userID.(uint))),
+			),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			if err == db.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Assessment not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch assessment"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, assessmentView(*found))
+	}
+}
+
+// DeleteAssessment deletes an assessment and drops the stored reports
+// covering it
+func DeleteAssessment(client *db.PrismaClient, store storage.BlobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		assessmentID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assessment ID"})
+			return
+		}
+
+		found, err := client.Assessment.FindFirst(
+			db.Assessment.ID.Equals(assessmentID),
+			db.Assessment.User.Where(
+				db.User.ID.Equals(int(userID.(uint))),
+			),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			if err == db.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Assessment not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch assessment"})
+			}
+			return
+		}
+
+		_, err = client.Assessment.FindUnique(
+			db.Assessment.ID.Equals(assessmentID),
+		).Delete().Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete assessment"})
+			return
+		}
+
+		invalidateStoredReports(c.Request.Context(), client, store, int(userID.(uint)), found.CreatedAt)
+
+		c.JSON(http.StatusOK, gin.H{"message": "Assessment successfully deleted"})
+	}
+}
+
+// GetAssessmentStats summarizes each questionnaire answered in the stats
+// range
+func GetAssessmentStats(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+
+		loc, err := userLocation(c.Request.Context(), client, userIDInt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+			return
+		}
+
+		from, to, err := parseStatsRange(c, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid range: " + err.Error()})
+			return
+		}
+
+		assessments, err := queryAssessments(c.Request.Context(), client, userIDInt, from, to)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch assessments"})
+			return
+		}
+
+		byQuestionnaire := make(map[string][]db.AssessmentModel)
+		for _, model := range assessments {
+			byQuestionnaire[model.Questionnaire] = append(byQuestionnaire[model.Questionnaire], model)
+		}
+
+		stats := AssessmentStats{
+			From:           from.Format("2006-01-02"),
+			To:             to.AddDate(0, 0, -1).Format("2006-01-02"),
+			Timezone:       loc.String(),
+			Questionnaires: []AssessmentSummary{},
+		}
+		for _, q := range assessment.Questionnaires {
+			taken := byQuestionnaire[q.ID]
+			if len(taken) == 0 {
+				continue
+			}
+
+			summary := AssessmentSummary{
+				Questionnaire:  q.ID,
+				Name:           q.Name,
+				MaxScore:       q.MaxScore,
+				HigherIsBetter: q.HigherIsBetter,
+				Count:          len(taken),
+			}
+			var sum int
+			for _, model := range taken {
+				sum += model.Score
+				summary.Series = append(summary.Series, AssessmentPoint{
+					Date:     model.CreatedAt.In(loc).Format("2006-01-02"),
+					Score:    model.Score,
+					Severity: model.Severity,
+				})
+			}
+			summary.Average = float64(sum) / float64(len(taken))
+			summary.Latest = summary.Series[len(summary.Series)-1]
+			summary.Change = summary.Latest.Score - summary.Series[0].Score
+			stats.Questionnaires = append(stats.Questionnaires, summary)
+		}
+
+		c.JSON(http.StatusOK, stats)
+	}
+}
@@ -0,0 +1,344 @@
+package handler
+
+import (
+	"api/media"
+	"api/prisma/db"
+	"api/storage"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxAttachmentSize   = 10 << 20
+	userAttachmentQuota = 200 << 20
+)
+
+// UploadAttachment stores a photo or voice memo for a mood. Photos are
+// re-encoded to drop EXIF/GPS metadata and get a generated thumbnail.
+func UploadAttachment(client *db.PrismaClient, store storage.BlobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+
+		moodID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mood ID"})
+			return
+		}
+
+		_, err = client.Mood.FindFirst(
+			db.Mood.ID.Equals(moodID),
+			db.Mood.User.Where(
+				db.User.ID.Equals(userIDInt),
+			),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			if err == db.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Mood not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch mood"})
+			}
+			return
+		}
+
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: file is required"})
+			return
+		}
+
+		if fileHeader.Size > maxAttachmentSize {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File is larger than 10 MB"})
+			return
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+			return
+		}
+		defer file.Close()
+
+		data, err := io.ReadAll(io.LimitReader(file, maxAttachmentSize+1))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+			return
+		}
+		if len(data) > maxAttachmentSize {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File is larger than 10 MB"})
+			return
+		}
+
+		contentType, kind, err := media.Sniff(data)
+		if err != nil {
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Only JPEG/PNG photos and MP3, M4A, WAV, AIFF or OGG audio are supported"})
+			return
+		}
+
+		var thumbnail []byte
+		if kind == media.KindPhoto {
+			stripped, img, err := media.StripMetadata(data, contentType)
+			if err == media.ErrImageTooLarge {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Image is larger than 36 megapixels"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image: " + err.Error()})
+				return
+			}
+			data = stripped
+
+			thumbnail, err = media.Thumbnail(img)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate thumbnail: " + err.Error()})
+				return
+			}
+		}
+
+		existing, err := client.Attachment.FindMany(
+			db.Attachment.User.Where(
+				db.User.ID.Equals(userIDInt),
+			),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attachments"})
+			return
+		}
+
+		used := len(data) + len(thumbnail)
+		for _, attachment := range existing {
+			used += attachment.Size
+		}
+		if used > userAttachmentQuota {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Attachment storage quota exceeded"})
+			return
+		}
+
+		key, err := newAttachmentKey(userIDInt, moodID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store attachment"})
+			return
+		}
+
+		if err := store.Put(c.Request.Context(), key, data, contentType); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store attachment: " + err.Error()})
+			return
+		}
+
+		var thumbnailKey *string
+		if thumbnail != nil {
+			thumbKey := key + "-thumb"
+			if err := store.Put(c.Request.Context(), thumbKey, thumbnail, "image/jpeg"); err != nil {
+				deleteBlobs(c.Request.Context(), store, key)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store thumbnail: " + err.Error()})
+				return
+			}
+			thumbnailKey = &thumbKey
+		}
+
+		attachment, err := client.Attachment.CreateOne(
+			db.Attachment.Mood.Link(
+				db.Mood.ID.Equals(moodID),
+			),
+			db.Attachment.User.Link(
+				db.User.ID.Equals(userIDInt),
+			),
+			db.Attachment.Kind.Set(kind),
+			db.Attachment.ContentType.Set(contentType),
+			db.Attachment.Size.Set(len(data)+len(thumbnail)),
+			db.Attachment.StorageKey.Set(key),
+			db.Attachment.ThumbnailKey.SetIfPresent(thumbnailKey),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			deleteBlobs(c.Request.Context(), store, key, key+"-thumb")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create attachment: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, attachment)
+	}
+}
+
+// GetAttachments lists the attachments of a mood
+func GetAttachments(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		moodID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mood ID"})
+			return
+		}
+
+		attachments, err := client.Attachment.FindMany(
+			db.Attachment.Mood.Where(
+				db.Mood.ID.Equals(moodID),
+			),
+			db.Attachment.User.Where(
+				db.User.ID.Equals(int(userID.(uint))),
+			),
+		).OrderBy(
+			db.Attachment.CreatedAt.Order(db.ASC),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attachments"})
+			return
+		}
+
+		c.JSON(http.StatusOK, attachments)
+	}
+}
+
+// DownloadAttachment streams an attachment, or its thumbnail when
+// ?thumbnail=true is given
+func DownloadAttachment(client *db.PrismaClient, store storage.BlobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		attachment, ok := findOwnedAttachment(c, client, int(userID.(uint)))
+		if !ok {
+			return
+		}
+
+		key, contentType := attachment.StorageKey, attachment.ContentType
+		if c.Query("thumbnail") == "true" {
+			thumbnailKey, ok := attachment.ThumbnailKey()
+			if !ok {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Attachment has no thumbnail"})
+				return
+			}
+			key, contentType = thumbnailKey, "image/jpeg"
+		}
+
+		reader, err := store.Open(c.Request.Context(), key)
+		if err != nil {
+			if err == storage.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Attachment file not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read attachment"})
+			}
+			return
+		}
+		defer reader.Close()
+
+		c.DataFromReader(http.StatusOK, -1, contentType, reader, nil)
+	}
+}
+
+// DeleteAttachment removes a single attachment and its stored files
+func DeleteAttachment(client *db.PrismaClient, store storage.BlobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		attachment, ok := findOwnedAttachment(c, client, int(userID.(uint)))
+		if !ok {
+			return
+		}
+
+		_, err := client.Attachment.FindUnique(
+			db.Attachment.ID.Equals(attachment.ID),
+		).Delete().Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete attachment"})
+			return
+		}
+
+		deleteAttachmentBlobs(c.Request.Context(), store, []db.AttachmentModel{*attachment})
+
+		c.JSON(http.StatusOK, gin.H{"message": "Attachment successfully deleted"})
+	}
+}
+
+// findOwnedAttachment loads the attachment addressed by the :id and
+// :attachmentId route parameters, writing the error response itself
+func findOwnedAttachment(c *gin.Context, client *db.PrismaClient, userID int) (*db.AttachmentModel, bool) {
+	moodID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mood ID"})
+		return nil, false
+	}
+
+	attachmentID, err := strconv.Atoi(c.Param("attachmentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return nil, false
+	}
+
+	attachment, err := client.Attachment.FindFirst(
+		db.Attachment.ID.Equals(attachmentID),
+		db.Attachment.Mood.Where(
+			db.Mood.ID.Equals(moodID),
+		),
+		db.Attachment.User.Where(
+			db.User.ID.Equals(userID),
+		),
+	).Exec(c.Request.Context())
+
+	if err != nil {
+		if err == db.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attachment"})
+		}
+		return nil, false
+	}
+
+	return attachment, true
+}
+
+func newAttachmentKey(userID, moodID int) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("users/%d/moods/%d/%s", userID, moodID, hex.EncodeToString(random)), nil
+}
+
+// deleteAttachmentBlobs removes the stored files of attachments whose rows
+// are already gone. Failures are only logged so the purge itself succeeds.
+func deleteAttachmentBlobs(ctx context.Context, store storage.BlobStore, attachments []db.AttachmentModel) {
+	for _, attachment := range attachments {
+		deleteBlobs(ctx, store, attachment.StorageKey)
+		if thumbnailKey, ok := attachment.ThumbnailKey(); ok {
+			deleteBlobs(ctx, store, thumbnailKey)
+		}
+	}
+}
+
+func deleteBlobs(ctx context.Context, store storage.BlobStore, keys ...string) {
+	for _, key := range keys {
+		if err := store.Delete(ctx, key); err != nil {
+			log.Println("Stored file could not be deleted:", key, err)
+		}
+	}
+}
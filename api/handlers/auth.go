@@ -2,7 +2,9 @@ package handler
 
 import (
 	"api/prisma/db"
+	"api/storage"
 	"fmt"
+	"log"
 	"net/http"
 	"strings"
 	"time"
@@ -52,6 +54,12 @@ func Register(client *db.PrismaClient) gin.HandlerFunc {
 			return
 		}
 
+		// Kullanıcı varsayılan öğünlerle başlar; oluşturulamazsa öğünsüz
+		// devam eder ve bunları sonradan /meal-slots/defaults ile ekleyebilir
+		if err := client.Prisma.Transaction(defaultMealSlotOps(client, createdUser.ID)...).Exec(c); err != nil {
+			log.Println("Default meal slots could not be created for user", createdUser.ID, err)
+		}
+
 		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 			"user_id": createdUser.ID,
 			"exp":     time.Now().Add(time.Hour * 24).Unix(),
@@ -150,7 +158,7 @@ func GetUserInfo(client *db.PrismaClient) gin.HandlerFunc {
 	}
 }
 
-func DeleteUser(client *db.PrismaClient) gin.HandlerFunc {
+func DeleteUser(client *db.PrismaClient, store storage.BlobStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tokenString := c.GetHeader("Authorization")
 		if tokenString == "" {
@@ -181,6 +189,28 @@ func DeleteUser(client *db.PrismaClient) gin.HandlerFunc {
 			return
 		}
 
+		attachments, err := client.Attachment.FindMany(
+			db.Attachment.User.Where(
+				db.User.ID.Equals(int(userID)),
+			),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"hata": "Kullanıcı ekleri alınamadı"})
+			return
+		}
+
+		reports, err := client.Report.FindMany(
+			db.Report.User.Where(
+				db.User.ID.Equals(int(userID)),
+			),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"hata": "Kullanıcı raporları alınamadı"})
+			return
+		}
+
 		_, err = client.User.FindUnique(
 			db.User.ID.Equals(int(userID)),
 		).Delete().Exec(c.Request.Context())
@@ -194,6 +224,10 @@ func DeleteUser(client *db.PrismaClient) gin.HandlerFunc {
 			return
 		}
 
+		// Kayıtlar cascade ile silinir, ek ve rapor dosyalarını ayrıca temizliyoruz
+		deleteAttachmentBlobs(c.Request.Context(), store, attachments)
+		deleteReportBlobs(c.Request.Context(), store, reports)
+
 		c.JSON(http.StatusOK, gin.H{"mesaj": "Kullanıcı başarıyla silindi"})
 	}
 }
@@ -233,7 +267,8 @@ func UpdateUser(client *db.PrismaClient) gin.HandlerFunc {
 		c.Set("user_id", uint(userID))
 
 		var updateData struct {
-			Username string `json:"username"`
+			Username *string `json:"username"`
+			Timezone *string `json:"timezone"`
 		}
 
 		if err := c.ShouldBindJSON(&updateData); err != nil {
@@ -241,10 +276,25 @@ func UpdateUser(client *db.PrismaClient) gin.HandlerFunc {
 			return
 		}
 
+		// Yalnızca gönderilen alanlar güncellenir; kullanıcı adı boş olamaz
+		if updateData.Username != nil && strings.TrimSpace(*updateData.Username) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"hata": "Kullanıcı adı boş olamaz"})
+			return
+		}
+
+		// Saat dilimi IANA adı olmalı, örn. "Europe/Istanbul"
+		if updateData.Timezone != nil {
+			if _, err := time.LoadLocation(*updateData.Timezone); err != nil || *updateData.Timezone == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"hata": "Geçersiz saat dilimi"})
+				return
+			}
+		}
+
 		updatedUser, err := client.User.FindUnique(
 			db.User.ID.Equals(int(userID)),
 		).Update(
-			db.User.Username.SetIfPresent(&updateData.Username),
+			db.User.Username.SetIfPresent(updateData.Username),
+			db.User.Timezone.SetIfPresent(updateData.Timezone),
 		).Exec(c.Request.Context())
 
 		if err != nil {
@@ -256,6 +306,11 @@ func UpdateUser(client *db.PrismaClient) gin.HandlerFunc {
 			return
 		}
 
+		// Takvim gibi istatistikler kullanıcının saat dilimine göre hesaplanır
+		if updateData.Timezone != nil {
+			moodStatsCache.invalidate(int(userID))
+		}
+
 		c.JSON(http.StatusOK, gin.H{"mesaj": "Kullanıcı başarıyla güncellendi", "kullanici": updatedUser})
 	}
 }
@@ -0,0 +1,426 @@
+package handler
+
+import (
+	"api/nutrition"
+	"api/prisma/db"
+	"api/storage"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"github.com/steebchen/prisma-client-go/runtime/transaction"
+)
+
+// maxDiaryDays caps how many days one diary request can list
+const maxDiaryDays = 92
+
+var errInvalidTagFilter = errors.New("invalid tag ID")
+
+type DiaryUpdateInput struct {
+	Quantity *decimal.Decimal `json:"quantity"`
+	Unit     *string          `json:"unit"`
+	EatenAt  *time.Time       `json:"eatenAt"`
+	// MealID moves the entry to another meal when present, 0 takes it out
+	// of its meal
+	MealID *int `json:"mealId"`
+	// Tags replaces the entry's tags when present
+	Tags *[]int `json:"tags"`
+}
+
+// DiaryEntry is a food entry with the nutrients of the amount eaten
+type DiaryEntry struct {
+	*db.UserFoodModel
+	Nutrients nutrition.Facts `json:"nutrients"`
+}
+
+// DiaryTotals are the number of entries and the nutrients they add up to
+type DiaryTotals struct {
+	Entries int `json:"entries"`
+	nutrition.Facts
+}
+
+// DiaryDay is one local day of the food diary
+type DiaryDay struct {
+	Date    string       `json:"date"`
+	Entries []DiaryEntry `json:"entries"`
+	Totals  DiaryTotals  `json:"totals"`
+}
+
+type DiaryResponse struct {
+	From     string      `json:"from"`
+	To       string      `json:"to"`
+	Timezone string      `json:"timezone"`
+	Days     []DiaryDay  `json:"days"`
+	Totals   DiaryTotals `json:"totals"`
+}
+
+// newDiaryEntry computes the nutrients of an entry fetched with its food and
+// the food's micronutrients
+func newDiaryEntry(entry *db.UserFoodModel) DiaryEntry {
+	return DiaryEntry{UserFoodModel: entry, Nutrients: userFoodFacts(*entry)}
+}
+
+// add counts entry towards the totals
+func (t *DiaryTotals) add(entry DiaryEntry) {
+	t.Entries++
+	t.Facts = t.Facts.Add(entry.Nutrients)
+}
+
+// parseDiaryRange reads ?from= and ?to= like parseStatsRange, defaulting to
+// the user's today when neither is given
+func parseDiaryRange(c *gin.Context, loc *time.Location) (time.Time, time.Time, error) {
+	if c.Query("from") == "" && c.Query("to") == "" {
+		now := time.Now().In(loc)
+		from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+		return from, from.AddDate(0, 0, 1), nil
+	}
+
+	from, to, err := parseStatsRange(c, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if to.Sub(from) > maxDiaryDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("range must not exceed %d days", maxDiaryDays)
+	}
+	return from, to, nil
+}
+
+// diaryTagFilter reads ?tag=, matching entries with that tag or one nested
+// under it. It returns nil when no tag is given.
+func diaryTagFilter(c *gin.Context, client *db.PrismaClient, userID int) ([]db.UserFoodWhereParam, error) {
+	tag := c.Query("tag")
+	if tag == "" {
+		return nil, nil
+	}
+
+	tagID, err := strconv.Atoi(tag)
+	if err != nil {
+		return nil, errInvalidTagFilter
+	}
+
+	tagIDs, err := tagDescendants(c.Request.Context(), client, userID, tagID)
+	if err != nil {
+		return nil, err
+	}
+	return []db.UserFoodWhereParam{
+		db.UserFood.Tags.Some(
+			db.Tag.ID.In(tagIDs),
+		),
+	}, nil
+}
+
+// queryDiary lists the user's food entries in [from, to), both local
+// midnights, grouped into local days with totals. Every day of the range is
+// listed, including days without entries.
+func queryDiary(ctx context.Context, client *db.PrismaClient, userID int, loc *time.Location, from, to time.Time, filters ...db.UserFoodWhereParam) (DiaryResponse, error) {
+	filters = append([]db.UserFoodWhereParam{
+		db.UserFood.User.Where(
+			db.User.ID.Equals(userID),
+		),
+		db.UserFood.EatenAt.Gte(from),
+		db.UserFood.EatenAt.Lt(to),
+	}, filters...)
+
+	entries, err := client.UserFood.FindMany(
+		filters...,
+	).With(
+		db.UserFood.Food.Fetch().With(
+			db.Food.Category.Fetch(),
+			db.Food.Micronutrients.Fetch(),
+			db.Food.Servings.Fetch(),
+		),
+		db.UserFood.Tags.Fetch(),
+	).OrderBy(
+		db.UserFood.EatenAt.Order(db.ASC),
+	).Exec(ctx)
+
+	if err != nil {
+		return DiaryResponse{}, err
+	}
+
+	response := DiaryResponse{
+		From:     from.Format("2006-01-02"),
+		To:       to.AddDate(0, 0, -1).Format("2006-01-02"),
+		Timezone: loc.String(),
+		Days:     []DiaryDay{},
+		Totals:   DiaryTotals{Facts: nutrition.Zero()},
+	}
+
+	days := make(map[string]int)
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		days[date] = len(response.Days)
+		response.Days = append(response.Days, DiaryDay{
+			Date:    date,
+			Entries: []DiaryEntry{},
+			Totals:  DiaryTotals{Facts: nutrition.Zero()},
+		})
+	}
+
+	for i := range entries {
+		index, ok := days[entries[i].EatenAt.In(loc).Format("2006-01-02")]
+		if !ok {
+			continue
+		}
+		entry := newDiaryEntry(&entries[i])
+		day := &response.Days[index]
+		day.Entries = append(day.Entries, entry)
+		day.Totals.add(entry)
+		response.Totals.add(entry)
+	}
+
+	return response, nil
+}
+
+// findUserFood returns the user's food entry with the given ID, its tags and
+// its food's servings
+func findUserFood(ctx context.Context, client *db.PrismaClient, userID, entryID int) (*db.UserFoodModel, error) {
+	return client.UserFood.FindFirst(
+		db.UserFood.ID.Equals(entryID),
+		db.UserFood.User.Where(
+			db.User.ID.Equals(userID),
+		),
+	).With(
+		db.UserFood.Food.Fetch().With(
+			db.Food.Servings.Fetch(),
+		),
+		db.UserFood.Tags.Fetch(),
+	).Exec(ctx)
+}
+
+// GetDiary lists the food diary between ?from= and ?to= (YYYY-MM-DD, both
+// inclusive, in the user's timezone), today by default. ?tag=<tag ID> keeps
+// only entries with that tag or one nested under it.
+func GetDiary(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+
+		loc, err := userLocation(c.Request.Context(), client, userIDInt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+			return
+		}
+
+		from, to, err := parseDiaryRange(c, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid range: " + err.Error()})
+			return
+		}
+
+		filters, err := diaryTagFilter(c, client, userIDInt)
+		if err != nil {
+			if err == errInvalidTagFilter {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+			}
+			return
+		}
+
+		response, err := queryDiary(c.Request.Context(), client, userIDInt, loc, from, to, filters...)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch diary: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// UpdateUserFood changes the amount, time, meal or tags of a food entry.
+// Stored reports covering its old and new time are dropped.
+func UpdateUserFood(client *db.PrismaClient, store storage.BlobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input DiaryUpdateInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+
+		entryID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entry ID"})
+			return
+		}
+
+		existing, err := findUserFood(c.Request.Context(), client, userIDInt, entryID)
+		if err != nil {
+			if err == db.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch entry"})
+			}
+			return
+		}
+
+		params := []db.UserFoodSetParam{
+			db.UserFood.EatenAt.SetIfPresent(input.EatenAt),
+		}
+		if input.Quantity != nil || input.Unit != nil {
+			// The amount is checked as a whole, keeping what was not given
+			quantity, unit := existing.Quantity, existing.Unit
+			if input.Quantity != nil {
+				quantity = *input.Quantity
+			}
+			if input.Unit != nil {
+				unit = *input.Unit
+			}
+			amount, msg := userFoodAmount(existing.Food(), quantity, unit)
+			if msg != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+				return
+			}
+			params = append(params, amount...)
+		}
+		// An entry in a meal stays on the meal's date, whether it is moved
+		// to the meal or only its time changes
+		eatenAt := existing.EatenAt
+		if input.EatenAt != nil {
+			eatenAt = *input.EatenAt
+		}
+		mealID, inMeal := existing.MealID()
+		if input.MealID != nil {
+			mealID, inMeal = *input.MealID, *input.MealID != 0
+		}
+		if input.MealID != nil && !inMeal {
+			params = append(params, db.UserFood.Meal.Unlink())
+		}
+		if inMeal && (input.MealID != nil || input.EatenAt != nil) {
+			msg, err := validateMealDate(c.Request.Context(), client, userIDInt, mealID, eatenAt)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch meal"})
+				return
+			}
+			if msg != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+				return
+			}
+		}
+		if input.MealID != nil && inMeal {
+			params = append(params, db.UserFood.Meal.Link(
+				db.Meal.ID.Equals(mealID),
+			))
+		}
+
+		ops := []transaction.Param{
+			client.UserFood.FindUnique(
+				db.UserFood.ID.Equals(entryID),
+			).Update(
+				params...,
+			).Tx(),
+		}
+		if input.Tags != nil {
+			tags, msg, err := userFoodTags(c.Request.Context(), client, userIDInt, *input.Tags)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+				return
+			}
+			if msg != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+				return
+			}
+
+			for _, tag := range existing.Tags() {
+				ops = append(ops, client.UserFood.FindUnique(
+					db.UserFood.ID.Equals(entryID),
+				).Update(
+					db.UserFood.Tags.Unlink(
+						db.Tag.ID.Equals(tag.ID),
+					),
+				).Tx())
+			}
+			if len(tags) > 0 {
+				ops = append(ops, client.UserFood.FindUnique(
+					db.UserFood.ID.Equals(entryID),
+				).Update(
+					db.UserFood.Tags.Link(tags...),
+				).Tx())
+			}
+		}
+
+		if err := client.Prisma.Transaction(ops...).Exec(c.Request.Context()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update entry: " + err.Error()})
+			return
+		}
+
+		invalidateStoredReports(c.Request.Context(), client, store, userIDInt, existing.EatenAt, eatenAt)
+
+		entry, err := client.UserFood.FindUnique(
+			db.UserFood.ID.Equals(entryID),
+		).With(
+			db.UserFood.Food.Fetch().With(
+				db.Food.Category.Fetch(),
+				db.Food.Micronutrients.Fetch(),
+				db.Food.Servings.Fetch(),
+			),
+			db.UserFood.Tags.Fetch(),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch entry"})
+			return
+		}
+
+		c.JSON(http.StatusOK, newDiaryEntry(entry))
+	}
+}
+
+// DeleteUserFood deletes a food entry and drops the stored reports covering
+// it
+func DeleteUserFood(client *db.PrismaClient, store storage.BlobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		entryID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entry ID"})
+			return
+		}
+
+		entry, err := findUserFood(c.Request.Context(), client, int(userID.(uint)), entryID)
+		if err != nil {
+			if err == db.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch entry"})
+			}
+			return
+		}
+
+		_, err = client.UserFood.FindUnique(
+			db.UserFood.ID.Equals(entryID),
+		).Delete().Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete entry: " + err.Error()})
+			return
+		}
+
+		invalidateStoredReports(c.Request.Context(), client, store, int(userID.(uint)), entry.EatenAt)
+
+		c.JSON(http.StatusOK, gin.H{"message": "Entry successfully deleted"})
+	}
+}
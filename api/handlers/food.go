@@ -2,23 +2,98 @@ package handler
 
 import (
 	"api/prisma/db"
+	"api/storage"
+	"context"
+	"encoding/json"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"github.com/steebchen/prisma-client-go/runtime/transaction"
 )
 
 type FoodInput struct {
 	Name       string `json:"name" binding:"required"`
 	Calories   int    `json:"calories" binding:"required"`
 	CategoryID int    `json:"categoryId" binding:"required"`
+	FoodNutrientsInput
 }
 
+const (
+	// maxBulkUserFoods caps how many entries one bulk request can log
+	maxBulkUserFoods = 100
+
+	bulkAllOrNothing = "all"
+	bulkPartial      = "partial"
+
+	bulkStatusCreated = "created"
+	bulkStatusFailed  = "failed"
+	// bulkStatusSkipped marks valid entries left out because others failed
+	bulkStatusSkipped = "skipped"
+)
+
+// UserFoodInput logs quantity of a food. unit is "serving" (the default), a
+// metric or imperial unit, or one of the food's named servings. mealId puts
+// the entry in one of the user's meals.
 type UserFoodInput struct {
-	FoodID   int       `json:"foodId" binding:"required"`
-	Quantity int       `json:"quantity" binding:"required"`
-	EatenAt  time.Time `json:"eatenAt" binding:"required"`
+	FoodID   int             `json:"foodId" binding:"required"`
+	Quantity decimal.Decimal `json:"quantity"`
+	Unit     string          `json:"unit"`
+	EatenAt  time.Time       `json:"eatenAt" binding:"required"`
+	MealID   *int            `json:"mealId"`
+	Tags     []int           `json:"tags"`
+}
+
+// BulkUserFoodResult is the outcome of one entry of a bulk request
+type BulkUserFoodResult struct {
+	Index  int         `json:"index"`
+	Status string      `json:"status"`
+	Error  string      `json:"error,omitempty"`
+	Entry  *DiaryEntry `json:"entry,omitempty"`
+}
+
+type BulkUserFoodResponse struct {
+	Created int                  `json:"created"`
+	Failed  int                  `json:"failed"`
+	Results []BulkUserFoodResult `json:"results"`
+}
+
+// userFoodTags checks that tagIDs are the user's unarchived tags and returns
+// the parameters linking them to a food entry. It returns a validation
+// message, or "" if the tags are fine.
+func userFoodTags(ctx context.Context, client *db.PrismaClient, userID int, tagIDs []int) ([]db.TagWhereParam, string, error) {
+	if len(tagIDs) == 0 {
+		return nil, "", nil
+	}
+
+	tags, err := client.Tag.FindMany(
+		db.Tag.ID.In(tagIDs),
+		db.Tag.User.Where(
+			db.User.ID.Equals(userID),
+		),
+		db.Tag.ArchivedAt.IsNull(),
+	).Exec(ctx)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	found := make(map[int]bool, len(tags))
+	for _, tag := range tags {
+		found[tag.ID] = true
+	}
+
+	var params []db.TagWhereParam
+	for _, tagID := range tagIDs {
+		if !found[tagID] {
+			return nil, "Tag " + strconv.Itoa(tagID) + " not found", nil
+		}
+		params = append(params, db.Tag.ID.Equals(tagID))
+	}
+	return params, "", nil
 }
 
 // CreateFood adds a new food item to the global catalog
@@ -51,13 +126,18 @@ func CreateFood(client *db.PrismaClient) gin.HandlerFunc {
 			return
 		}
 
-		// Create food
-		food, err := client.Food.CreateOne(
+		if msg := validateFoodNutrients(input.FoodNutrientsInput, input.Calories, nil); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		createdFood, err := client.Food.CreateOne(
 			db.Food.Name.Set(input.Name),
 			db.Food.Calories.Set(input.Calories),
 			db.Food.Category.Link(
 				db.Category.ID.Equals(category.ID),
 			),
+			foodNutrientParams(input.FoodNutrientsInput)...,
 		).Exec(c.Request.Context())
 
 		if err != nil {
@@ -65,6 +145,33 @@ func CreateFood(client *db.PrismaClient) gin.HandlerFunc {
 			return
 		}
 
+		// Store the micronutrients and servings of the new food together; if
+		// that fails the food is removed again, so it is never left half made
+		if ops := foodDetailOps(client, createdFood.ID, input.FoodNutrientsInput); len(ops) > 0 {
+			if err := client.Prisma.Transaction(ops...).Exec(c.Request.Context()); err != nil {
+				if _, deleteErr := client.Food.FindUnique(
+					db.Food.ID.Equals(createdFood.ID),
+				).Delete().Exec(c.Request.Context()); deleteErr != nil {
+					log.Println("Food", createdFood.ID, "could not be removed after its details failed:", deleteErr)
+				}
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create food: " + err.Error()})
+				return
+			}
+		}
+
+		food, err := client.Food.FindUnique(
+			db.Food.ID.Equals(createdFood.ID),
+		).With(
+			db.Food.Category.Fetch(),
+			db.Food.Micronutrients.Fetch(),
+			db.Food.Servings.Fetch(),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch food"})
+			return
+		}
+
 		c.JSON(http.StatusCreated, food)
 	}
 }
@@ -89,10 +196,14 @@ func GetFoods(client *db.PrismaClient) gin.HandlerFunc {
 				),
 			).With(
 				db.Food.Category.Fetch(),
+				db.Food.Micronutrients.Fetch(),
+				db.Food.Servings.Fetch(),
 			).Exec(c.Request.Context())
 		} else {
 			foods, err = client.Food.FindMany().With(
 				db.Food.Category.Fetch(),
+				db.Food.Micronutrients.Fetch(),
+				db.Food.Servings.Fetch(),
 			).Exec(c.Request.Context())
 		}
 
@@ -130,19 +241,54 @@ func UpdateFood(client *db.PrismaClient) gin.HandlerFunc {
 			return
 		}
 
-		// Update food
-		updatedFood, err := client.Food.FindUnique(
-			db.Food.ID.Equals(existingFood.ID),
-		).Update(
+		if msg := validateFoodNutrients(input.FoodNutrientsInput, input.Calories, existingFood); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		msg, err := validateWeighedEntries(c.Request.Context(), client, existingFood, input.FoodNutrientsInput)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user food entries"})
+			return
+		}
+		if msg != "" {
+			c.JSON(http.StatusConflict, gin.H{"error": msg})
+			return
+		}
+
+		params := append([]db.FoodSetParam{
 			db.Food.Name.Set(input.Name),
 			db.Food.Calories.Set(input.Calories),
 			db.Food.Category.Link(
 				db.Category.ID.Equals(input.CategoryID),
 			),
+		}, foodNutrientParams(input.FoodNutrientsInput)...)
+
+		// Update the food; micronutrients and servings are replaced only when
+		// given
+		ops := append([]transaction.Param{
+			client.Food.FindUnique(
+				db.Food.ID.Equals(existingFood.ID),
+			).Update(
+				params...,
+			).Tx(),
+		}, foodDetailOps(client, existingFood.ID, input.FoodNutrientsInput)...)
+
+		if err := client.Prisma.Transaction(ops...).Exec(c.Request.Context()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update food: " + err.Error()})
+			return
+		}
+
+		updatedFood, err := client.Food.FindUnique(
+			db.Food.ID.Equals(existingFood.ID),
+		).With(
+			db.Food.Category.Fetch(),
+			db.Food.Micronutrients.Fetch(),
+			db.Food.Servings.Fetch(),
 		).Exec(c.Request.Context())
 
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update food: " + err.Error()})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch food"})
 			return
 		}
 
@@ -173,8 +319,9 @@ func DeleteFood(client *db.PrismaClient) gin.HandlerFunc {
 	}
 }
 
-// AddUserFood adds a new user food entry
-func AddUserFood(client *db.PrismaClient) gin.HandlerFunc {
+// AddUserFood adds a new user food entry. Stored reports covering its time
+// are dropped, since the entry may be backdated.
+func AddUserFood(client *db.PrismaClient, store storage.BlobStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var input UserFoodInput
 		if err := c.ShouldBindJSON(&input); err != nil {
@@ -191,8 +338,10 @@ func AddUserFood(client *db.PrismaClient) gin.HandlerFunc {
 		userIDInt := int(userID.(uint))
 
 		// Check if the food exists
-		_, err := client.Food.FindUnique(
+		food, err := client.Food.FindUnique(
 			db.Food.ID.Equals(input.FoodID),
+		).With(
+			db.Food.Servings.Fetch(),
 		).Exec(c.Request.Context())
 
 		if err != nil {
@@ -200,6 +349,42 @@ func AddUserFood(client *db.PrismaClient) gin.HandlerFunc {
 			return
 		}
 
+		params, msg := userFoodAmount(food, input.Quantity, input.Unit)
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		tags, msg, err := userFoodTags(c.Request.Context(), client, userIDInt, input.Tags)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+			return
+		}
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		if input.MealID != nil {
+			msg, err := validateMealDate(c.Request.Context(), client, userIDInt, *input.MealID, input.EatenAt)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch meal"})
+				return
+			}
+			if msg != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+				return
+			}
+			params = append(params, db.UserFood.Meal.Link(
+				db.Meal.ID.Equals(*input.MealID),
+			))
+		}
+
+		params = append(params, db.UserFood.EatenAt.Set(input.EatenAt))
+		if len(tags) > 0 {
+			params = append(params, db.UserFood.Tags.Link(tags...))
+		}
+
 		// Create user food entry
 		userFood, err := client.UserFood.CreateOne(
 			db.UserFood.User.Link(
@@ -208,8 +393,14 @@ func AddUserFood(client *db.PrismaClient) gin.HandlerFunc {
 			db.UserFood.Food.Link(
 				db.Food.ID.Equals(input.FoodID),
 			),
-			db.UserFood.Quantity.Set(input.Quantity),
-			db.UserFood.EatenAt.Set(input.EatenAt),
+			params...,
+		).With(
+			db.UserFood.Food.Fetch().With(
+				db.Food.Category.Fetch(),
+				db.Food.Micronutrients.Fetch(),
+				db.Food.Servings.Fetch(),
+			),
+			db.UserFood.Tags.Fetch(),
 		).Exec(c.Request.Context())
 
 		if err != nil {
@@ -217,11 +408,15 @@ func AddUserFood(client *db.PrismaClient) gin.HandlerFunc {
 			return
 		}
 
-		c.JSON(http.StatusCreated, userFood)
+		invalidateStoredReports(c.Request.Context(), client, store, userIDInt, userFood.EatenAt)
+
+		c.JSON(http.StatusCreated, newDiaryEntry(userFood))
 	}
 }
 
-// GetUserFoodsByDate fetches user food entries for a specific date
+// GetUserFoodsByDate fetches the diary for one day, YYYY-MM-DD in the user's
+// timezone. ?tag=<tag ID> keeps only entries with that tag or one nested
+// under it.
 func GetUserFoodsByDate(client *db.PrismaClient) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID, exists := c.Get("user_id")
@@ -230,46 +425,83 @@ func GetUserFoodsByDate(client *db.PrismaClient) gin.HandlerFunc {
 			return
 		}
 
+		userIDInt := int(userID.(uint))
+
+		loc, err := userLocation(c.Request.Context(), client, userIDInt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+			return
+		}
+
 		dateStr := c.Param("date")
-		date, err := time.Parse("2006-01-02", dateStr)
+		startOfDay, err := time.ParseInLocation("2006-01-02", dateStr, loc)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
 			return
 		}
+		endOfDay := startOfDay.AddDate(0, 0, 1)
 
-		startOfDay := date.Truncate(24 * time.Hour)
-		endOfDay := startOfDay.Add(24 * time.Hour)
-
-		userFoods, err := client.UserFood.FindMany(
-			db.UserFood.User.Where(
-				db.User.ID.Equals(int(userID.(uint))),
-			),
-			db.UserFood.EatenAt.Gte(startOfDay),
-			db.UserFood.EatenAt.Lt(endOfDay),
-		).With(
-			db.UserFood.Food.Fetch().With(
-				db.Food.Category.Fetch(),
-			),
-		).Exec(c.Request.Context())
+		filters, err := diaryTagFilter(c, client, userIDInt)
+		if err != nil {
+			if err == errInvalidTagFilter {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+			}
+			return
+		}
 
+		diary, err := queryDiary(c.Request.Context(), client, userIDInt, loc, startOfDay, endOfDay, filters...)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user foods"})
 			return
 		}
 
-		c.JSON(http.StatusOK, userFoods)
+		c.JSON(http.StatusOK, diary.Days[0])
 	}
 }
 
-// AddMultipleUserFoods allows adding multiple user food entries
-func AddMultipleUserFoods(client *db.PrismaClient) gin.HandlerFunc {
+// AddMultipleUserFoods logs several food entries in one transaction. Foods,
+// tags and meals are looked up once for the whole batch. With ?mode=all (the
+// default) nothing is created unless every entry is valid; with ?mode=partial
+// the valid entries are created and the rest reported. Either way the
+// response has one result per entry, in request order. Entries are decoded
+// one by one, so a malformed entry fails only itself. Stored reports covering
+// the created entries are dropped.
+func AddMultipleUserFoods(client *db.PrismaClient, store storage.BlobStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var inputs []UserFoodInput
-		if err := c.ShouldBindJSON(&inputs); err != nil {
+		var items []json.RawMessage
+		if err := c.ShouldBindJSON(&items); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
 			return
 		}
 
+		mode := c.DefaultQuery("mode", bulkAllOrNothing)
+		if mode != bulkAllOrNothing && mode != bulkPartial {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be all or partial"})
+			return
+		}
+
+		if len(items) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No user foods were given"})
+			return
+		}
+		if len(items) > maxBulkUserFoods {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "At most " + strconv.Itoa(maxBulkUserFoods) + " user foods can be logged at once"})
+			return
+		}
+
+		// json.Unmarshal does not apply the binding tags; validateBulkUserFood
+		// checks the required fields per entry instead
+		inputs := make([]UserFoodInput, len(items))
+		decodeErrors := make([]string, len(items))
+		for i, item := range items {
+			if err := json.Unmarshal(item, &inputs[i]); err != nil {
+				inputs[i] = UserFoodInput{}
+				decodeErrors[i] = "Invalid input: " + err.Error()
+			}
+		}
+
 		userID, exists := c.Get("user_id")
 		if !exists {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
@@ -277,44 +509,209 @@ func AddMultipleUserFoods(client *db.PrismaClient) gin.HandlerFunc {
 		}
 
 		userIDInt := int(userID.(uint))
-		var createdUserFoods []db.UserFoodModel
 
+		// Look up every food, tag and meal of the batch once
+		var foodIDs, tagIDs, mealIDs []int
 		for _, input := range inputs {
-			// Check if the food exists
-			_, err := client.Food.FindUnique(
-				db.Food.ID.Equals(input.FoodID),
-			).Exec(c.Request.Context())
+			foodIDs = append(foodIDs, input.FoodID)
+			tagIDs = append(tagIDs, input.Tags...)
+			if input.MealID != nil {
+				mealIDs = append(mealIDs, *input.MealID)
+			}
+		}
 
-			if err != nil {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Food not found"})
-				return
+		foods, err := client.Food.FindMany(
+			db.Food.ID.In(foodIDs),
+		).With(
+			db.Food.Servings.Fetch(),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch foods"})
+			return
+		}
+
+		tags, err := client.Tag.FindMany(
+			db.Tag.ID.In(tagIDs),
+			db.Tag.User.Where(
+				db.User.ID.Equals(userIDInt),
+			),
+			db.Tag.ArchivedAt.IsNull(),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+			return
+		}
+
+		meals, err := client.Meal.FindMany(
+			db.Meal.ID.In(mealIDs),
+			db.Meal.User.Where(
+				db.User.ID.Equals(userIDInt),
+			),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch meals"})
+			return
+		}
+
+		knownFoods := make(map[int]*db.FoodModel, len(foods))
+		for i := range foods {
+			knownFoods[foods[i].ID] = &foods[i]
+		}
+		knownTags := make(map[int]bool, len(tags))
+		for _, tag := range tags {
+			knownTags[tag.ID] = true
+		}
+		mealDates := make(map[int]string, len(meals))
+		for _, meal := range meals {
+			mealDates[meal.ID] = meal.Date
+		}
+
+		loc, err := userLocation(c.Request.Context(), client, userIDInt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+			return
+		}
+
+		results := make([]BulkUserFoodResult, len(inputs))
+		var creates []db.UserFoodUniqueTxResult
+		var created []int
+		for i, input := range inputs {
+			results[i] = BulkUserFoodResult{Index: i, Status: bulkStatusFailed}
+			if decodeErrors[i] != "" {
+				results[i].Error = decodeErrors[i]
+				continue
+			}
+			params, msg := validateBulkUserFood(input, loc, knownFoods, knownTags, mealDates)
+			if msg != "" {
+				results[i].Error = msg
+				continue
+			}
+
+			params = append(params, db.UserFood.EatenAt.Set(input.EatenAt))
+			if input.MealID != nil {
+				params = append(params, db.UserFood.Meal.Link(
+					db.Meal.ID.Equals(*input.MealID),
+				))
+			}
+			if len(input.Tags) > 0 {
+				var links []db.TagWhereParam
+				for _, tagID := range input.Tags {
+					links = append(links, db.Tag.ID.Equals(tagID))
+				}
+				params = append(params, db.UserFood.Tags.Link(links...))
 			}
 
-			// Create user food entry
-			userFood, err := client.UserFood.CreateOne(
+			creates = append(creates, client.UserFood.CreateOne(
 				db.UserFood.User.Link(
 					db.User.ID.Equals(userIDInt),
 				),
 				db.UserFood.Food.Link(
 					db.Food.ID.Equals(input.FoodID),
 				),
-				db.UserFood.Quantity.Set(input.Quantity),
-				db.UserFood.EatenAt.Set(input.EatenAt),
-			).Exec(c.Request.Context())
+				params...,
+			).Tx())
+			created = append(created, i)
+		}
 
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user food entry: " + err.Error()})
-				return
+		failed := len(inputs) - len(created)
+		if failed > 0 && (mode == bulkAllOrNothing || len(created) == 0) {
+			for _, i := range created {
+				results[i].Status = bulkStatusSkipped
 			}
+			c.JSON(http.StatusBadRequest, BulkUserFoodResponse{Failed: failed, Results: results})
+			return
+		}
 
-			createdUserFoods = append(createdUserFoods, *userFood)
+		ops := make([]transaction.Param, len(creates))
+		for i, create := range creates {
+			ops[i] = create
+		}
+		if err := client.Prisma.Transaction(ops...).Exec(c.Request.Context()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user food entries: " + err.Error()})
+			return
 		}
 
-		if len(createdUserFoods) == 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "No user foods were created"})
+		eatenAts := make([]time.Time, len(created))
+		for n, i := range created {
+			eatenAts[n] = inputs[i].EatenAt
+		}
+		invalidateStoredReports(c.Request.Context(), client, store, userIDInt, eatenAts...)
+
+		entryIDs := make([]int, len(creates))
+		for i, create := range creates {
+			entryIDs[i] = create.Result().ID
+		}
+
+		entries, err := client.UserFood.FindMany(
+			db.UserFood.ID.In(entryIDs),
+		).With(
+			db.UserFood.Food.Fetch().With(
+				db.Food.Category.Fetch(),
+				db.Food.Micronutrients.Fetch(),
+				db.Food.Servings.Fetch(),
+			),
+			db.UserFood.Tags.Fetch(),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user food entries"})
 			return
 		}
 
-		c.JSON(http.StatusCreated, createdUserFoods)
+		byID := make(map[int]DiaryEntry, len(entries))
+		for i := range entries {
+			byID[entries[i].ID] = newDiaryEntry(&entries[i])
+		}
+		for n, i := range created {
+			entry := byID[entryIDs[n]]
+			results[i].Status = bulkStatusCreated
+			results[i].Entry = &entry
+		}
+
+		status := http.StatusCreated
+		if failed > 0 {
+			status = http.StatusMultiStatus
+		}
+		c.JSON(status, BulkUserFoodResponse{Created: len(created), Failed: failed, Results: results})
+	}
+}
+
+// validateBulkUserFood checks one entry of a batch for the fields AddUserFood
+// requires and against the foods, tags and meals found for the batch, and
+// returns the parameters storing its amount. mealDates maps the meals found
+// to their date, which eatenAt must fall on in loc. It returns a validation
+// message, or "" if the entry is fine.
+func validateBulkUserFood(input UserFoodInput, loc *time.Location, knownFoods map[int]*db.FoodModel, knownTags map[int]bool, mealDates map[int]string) ([]db.UserFoodSetParam, string) {
+	if input.FoodID == 0 {
+		return nil, "foodId is required"
+	}
+	if input.EatenAt.IsZero() {
+		return nil, "eatenAt is required"
 	}
-}
\ No newline at end of file
+	food, ok := knownFoods[input.FoodID]
+	if !ok {
+		return nil, "Food not found"
+	}
+	params, msg := userFoodAmount(food, input.Quantity, input.Unit)
+	if msg != "" {
+		return nil, msg
+	}
+	for _, tagID := range input.Tags {
+		if !knownTags[tagID] {
+			return nil, "Tag " + strconv.Itoa(tagID) + " not found"
+		}
+	}
+	if input.MealID != nil {
+		date, ok := mealDates[*input.MealID]
+		if !ok {
+			return nil, "Meal not found"
+		}
+		if input.EatenAt.In(loc).Format("2006-01-02") != date {
+			return nil, "eatenAt must be on the meal's date, " + date
+		}
+	}
+	return params, ""
+}
@@ -0,0 +1,269 @@
+package handler
+
+import (
+	"api/nutrition"
+	"api/prisma/db"
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"github.com/steebchen/prisma-client-go/runtime/transaction"
+)
+
+// FoodServingInput is a named portion of a food, like a slice or a bowl
+type FoodServingInput struct {
+	Name  string          `json:"name" binding:"required"`
+	Grams decimal.Decimal `json:"grams"`
+}
+
+// FoodNutrientsInput is the optional nutrition of a food. Amounts are per
+// nutrientBasis: "serving" (the default) or "100g", which needs the weight
+// of one serving. gramsPerMl lets the food be measured by volume. Fields left
+// out are unchanged on update.
+type FoodNutrientsInput struct {
+	Protein        *decimal.Decimal           `json:"protein"`
+	Carbohydrate   *decimal.Decimal           `json:"carbohydrate"`
+	Fat            *decimal.Decimal           `json:"fat"`
+	Fiber          *decimal.Decimal           `json:"fiber"`
+	Sugar          *decimal.Decimal           `json:"sugar"`
+	Sodium         *decimal.Decimal           `json:"sodium"`
+	Micronutrients map[string]decimal.Decimal `json:"micronutrients"`
+	NutrientBasis  *string                    `json:"nutrientBasis"`
+	ServingGrams   *decimal.Decimal           `json:"servingGrams"`
+	GramsPerMl     *decimal.Decimal           `json:"gramsPerMl"`
+	Servings       []FoodServingInput         `json:"servings"`
+}
+
+// validateFoodNutrients checks input against the food it changes, nil for a
+// new food. It returns a validation message, or "" if the input is fine.
+func validateFoodNutrients(input FoodNutrientsInput, calories int, existing *db.FoodModel) string {
+	facts := nutrition.Facts{
+		Calories:       decimal.NewFromInt(int64(calories)),
+		Micronutrients: input.Micronutrients,
+	}
+	for _, amount := range []*decimal.Decimal{input.Protein, input.Carbohydrate, input.Fat, input.Fiber, input.Sugar, input.Sodium} {
+		if amount != nil && amount.IsNegative() {
+			return nutrition.ErrNegativeAmount.Error()
+		}
+	}
+	if err := facts.Validate(); err != nil {
+		return err.Error()
+	}
+
+	basis, servingGrams := nutrition.BasisServing, input.ServingGrams
+	if existing != nil {
+		basis = existing.NutrientBasis
+		if servingGrams == nil {
+			if grams, ok := existing.ServingGrams(); ok {
+				servingGrams = &grams
+			}
+		}
+	}
+	if input.NutrientBasis != nil {
+		basis = *input.NutrientBasis
+	}
+	if input.ServingGrams != nil && !input.ServingGrams.IsPositive() {
+		return "servingGrams must be positive"
+	}
+	if err := nutrition.ValidateBasis(basis, servingGrams); err != nil {
+		return err.Error()
+	}
+	if input.GramsPerMl != nil && !input.GramsPerMl.IsPositive() {
+		return "gramsPerMl must be positive"
+	}
+
+	names := make(map[string]bool, len(input.Servings))
+	for _, serving := range input.Servings {
+		if _, ok := nutrition.FindUnit(serving.Name); ok || serving.Name == nutrition.UnitServing {
+			return "Serving " + serving.Name + " has the name of a unit"
+		}
+		if names[serving.Name] {
+			return "Serving " + serving.Name + " is given twice"
+		}
+		names[serving.Name] = true
+		if !serving.Grams.IsPositive() {
+			return "Serving " + serving.Name + " must weigh more than 0 g"
+		}
+	}
+	return ""
+}
+
+// validateWeighedEntries checks that the basis and serving weight food is
+// left with after input can still turn the weighed entries logged against it
+// into nutrients. Without that, userFoodFacts would count those entries as
+// zero. It returns a validation message, or "" if the change is fine.
+func validateWeighedEntries(ctx context.Context, client *db.PrismaClient, food *db.FoodModel, input FoodNutrientsInput) (string, error) {
+	measures := nutrition.Measures{Basis: food.NutrientBasis, ServingGrams: input.ServingGrams}
+	if input.NutrientBasis != nil {
+		measures.Basis = *input.NutrientBasis
+	}
+	if measures.ServingGrams == nil {
+		if grams, ok := food.ServingGrams(); ok {
+			measures.ServingGrams = &grams
+		}
+	}
+	if _, err := measures.FactorForGrams(decimal.NewFromInt(1)); err == nil {
+		return "", nil
+	}
+
+	_, err := client.UserFood.FindFirst(
+		db.UserFood.Food.Where(
+			db.Food.ID.Equals(food.ID),
+		),
+		db.UserFood.Not(
+			db.UserFood.Grams.IsNull(),
+		),
+	).Exec(ctx)
+
+	if err == db.ErrNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return "servingGrams is needed for the entries already logged by weight", nil
+}
+
+// foodNutrientParams sets the nutrients present in input
+func foodNutrientParams(input FoodNutrientsInput) []db.FoodSetParam {
+	return []db.FoodSetParam{
+		db.Food.Protein.SetIfPresent(input.Protein),
+		db.Food.Carbohydrate.SetIfPresent(input.Carbohydrate),
+		db.Food.Fat.SetIfPresent(input.Fat),
+		db.Food.Fiber.SetIfPresent(input.Fiber),
+		db.Food.Sugar.SetIfPresent(input.Sugar),
+		db.Food.Sodium.SetIfPresent(input.Sodium),
+		db.Food.NutrientBasis.SetIfPresent(input.NutrientBasis),
+		db.Food.ServingGrams.SetIfPresent(input.ServingGrams),
+		db.Food.GramsPerMl.SetIfPresent(input.GramsPerMl),
+	}
+}
+
+// foodDetailOps returns the operations replacing the micronutrients and
+// servings of a food with those in input, to run in one transaction. Nil
+// lists are left alone.
+func foodDetailOps(client *db.PrismaClient, foodID int, input FoodNutrientsInput) []transaction.Param {
+	var ops []transaction.Param
+	if input.Micronutrients != nil {
+		ops = append(ops, client.FoodMicronutrient.FindMany(
+			db.FoodMicronutrient.Food.Where(
+				db.Food.ID.Equals(foodID),
+			),
+		).Delete().Tx())
+		for nutrient, amount := range input.Micronutrients {
+			ops = append(ops, client.FoodMicronutrient.CreateOne(
+				db.FoodMicronutrient.Food.Link(
+					db.Food.ID.Equals(foodID),
+				),
+				db.FoodMicronutrient.Nutrient.Set(nutrient),
+				db.FoodMicronutrient.Amount.Set(amount),
+			).Tx())
+		}
+	}
+	if input.Servings != nil {
+		ops = append(ops, client.FoodServing.FindMany(
+			db.FoodServing.Food.Where(
+				db.Food.ID.Equals(foodID),
+			),
+		).Delete().Tx())
+		for _, serving := range input.Servings {
+			ops = append(ops, client.FoodServing.CreateOne(
+				db.FoodServing.Food.Link(
+					db.Food.ID.Equals(foodID),
+				),
+				db.FoodServing.Name.Set(serving.Name),
+				db.FoodServing.Grams.Set(serving.Grams),
+			).Tx())
+		}
+	}
+	return ops
+}
+
+// foodMeasures are the sizes a food, fetched with its servings, can be
+// measured in
+func foodMeasures(food *db.FoodModel) nutrition.Measures {
+	measures := nutrition.Measures{
+		Basis:    food.NutrientBasis,
+		Portions: make(map[string]decimal.Decimal),
+	}
+	if grams, ok := food.ServingGrams(); ok {
+		measures.ServingGrams = &grams
+	}
+	if density, ok := food.GramsPerMl(); ok {
+		measures.GramsPerMl = &density
+	}
+	for _, serving := range food.Servings() {
+		measures.Portions[serving.Name] = serving.Grams
+	}
+	return measures
+}
+
+// userFoodAmount checks quantity in unit against a food fetched with its
+// servings and returns the parameters storing the amount on an entry. It
+// returns a validation message, or "" if the amount is fine.
+func userFoodAmount(food *db.FoodModel, quantity decimal.Decimal, unit string) ([]db.UserFoodSetParam, string) {
+	if unit == "" {
+		unit = nutrition.UnitServing
+	}
+	grams, err := foodMeasures(food).Check(quantity, unit)
+	if err != nil {
+		return nil, err.Error()
+	}
+	return []db.UserFoodSetParam{
+		db.UserFood.Quantity.Set(quantity),
+		db.UserFood.Unit.Set(unit),
+		db.UserFood.Grams.SetOptional(grams),
+	}, ""
+}
+
+// foodFacts are the nutrients of a food, fetched with its micronutrients, per
+// its nutrient basis
+func foodFacts(food *db.FoodModel) nutrition.Facts {
+	facts := nutrition.Facts{
+		Calories:       decimal.NewFromInt(int64(food.Calories)),
+		Protein:        food.Protein,
+		Carbohydrate:   food.Carbohydrate,
+		Fat:            food.Fat,
+		Fiber:          food.Fiber,
+		Sugar:          food.Sugar,
+		Sodium:         food.Sodium,
+		Micronutrients: make(map[string]decimal.Decimal),
+	}
+	for _, micronutrient := range food.Micronutrients() {
+		facts.Micronutrients[micronutrient.Nutrient] = micronutrient.Amount
+	}
+	return facts
+}
+
+// userFoodFacts are the nutrients of a food entry, fetched with its food and
+// the food's micronutrients. Weighed entries are scaled by their weight,
+// the rest by their number of servings.
+func userFoodFacts(entry db.UserFoodModel) nutrition.Facts {
+	food := entry.Food()
+	servingGrams, _ := food.ServingGrams()
+	factor := nutrition.Factor(food.NutrientBasis, servingGrams, entry.Quantity)
+	if grams, ok := entry.Grams(); ok {
+		measures := nutrition.Measures{Basis: food.NutrientBasis, ServingGrams: &servingGrams}
+		var err error
+		if factor, err = measures.FactorForGrams(grams); err != nil {
+			// The food lost the serving weight the entry was logged against
+			return nutrition.Zero()
+		}
+	}
+	return foodFacts(food).Scale(factor)
+}
+
+// GetUnits lists the units food quantities can be given in
+func GetUnits() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, nutrition.Units)
+	}
+}
+
+// GetMicronutrients lists the micronutrients foods can list
+func GetMicronutrients() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, nutrition.Micronutrients)
+	}
+}
@@ -0,0 +1,916 @@
+package handler
+
+import (
+	"api/nutrition"
+	"api/prisma/db"
+	"api/storage"
+	"context"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/steebchen/prisma-client-go/runtime/transaction"
+)
+
+type MealSlotInput struct {
+	Name string `json:"name" binding:"required"`
+	// Time is the usual local time of the meal, HH:MM
+	Time string `json:"time" binding:"required"`
+}
+
+type MealInput struct {
+	SlotID int    `json:"slotId" binding:"required"`
+	Date   string `json:"date" binding:"required"`
+	// EatenAt defaults to the slot's time on date
+	EatenAt *time.Time `json:"eatenAt"`
+}
+
+type MealUpdateInput struct {
+	EatenAt time.Time `json:"eatenAt" binding:"required"`
+}
+
+type MealCopyInput struct {
+	Date string `json:"date" binding:"required"`
+	// SlotID defaults to the slot of the copied meal
+	SlotID *int `json:"slotId"`
+}
+
+// MealView is a meal with its slot, its moods and its entries, each with
+// the nutrients of the amount eaten, and their totals
+type MealView struct {
+	*db.MealModel
+	Entries []DiaryEntry `json:"entries"`
+	Totals  DiaryTotals  `json:"totals"`
+}
+
+// defaultMealSlots are given to new users at signup, and again to users
+// without slots who ask for them
+var defaultMealSlots = []MealSlotInput{
+	{Name: "Breakfast", Time: "08:00"},
+	{Name: "Lunch", Time: "12:30"},
+	{Name: "Snacks", Time: "16:00"},
+	{Name: "Dinner", Time: "19:30"},
+}
+
+var mealTimePattern = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+func validateMealSlotInput(input MealSlotInput) string {
+	if strings.TrimSpace(input.Name) == "" {
+		return "Name cannot be empty"
+	}
+	if !mealTimePattern.MatchString(input.Time) {
+		return "Time must be HH:MM"
+	}
+	return ""
+}
+
+// mealSlotTime is the slot's time, HH:MM, on the local day of day
+func mealSlotTime(day time.Time, clock string) time.Time {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return day
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location())
+}
+
+// userMealSlots returns the user's meal slots by position
+func userMealSlots(ctx context.Context, client *db.PrismaClient, userID int) ([]db.MealSlotModel, error) {
+	return client.MealSlot.FindMany(
+		db.MealSlot.User.Where(
+			db.User.ID.Equals(userID),
+		),
+	).OrderBy(
+		db.MealSlot.Position.Order(db.SortOrderAsc),
+	).Exec(ctx)
+}
+
+// defaultMealSlotOps returns the operations creating the default meal slots
+// of a user
+func defaultMealSlotOps(client *db.PrismaClient, userID int) []transaction.Param {
+	var ops []transaction.Param
+	for position, slot := range defaultMealSlots {
+		ops = append(ops, client.MealSlot.CreateOne(
+			db.MealSlot.Name.Set(slot.Name),
+			db.MealSlot.Time.Set(slot.Time),
+			db.MealSlot.User.Link(
+				db.User.ID.Equals(userID),
+			),
+			db.MealSlot.Position.Set(position),
+		).Tx())
+	}
+	return ops
+}
+
+// findMealSlot returns the user's meal slot with the given ID
+func findMealSlot(ctx context.Context, client *db.PrismaClient, userID, slotID int) (*db.MealSlotModel, error) {
+	return client.MealSlot.FindFirst(
+		db.MealSlot.ID.Equals(slotID),
+		db.MealSlot.User.Where(
+			db.User.ID.Equals(userID),
+		),
+	).Exec(ctx)
+}
+
+// validateMeal checks that mealID is one of the user's meals
+func validateMeal(ctx context.Context, client *db.PrismaClient, userID, mealID int) (string, error) {
+	_, err := client.Meal.FindFirst(
+		db.Meal.ID.Equals(mealID),
+		db.Meal.User.Where(
+			db.User.ID.Equals(userID),
+		),
+	).Exec(ctx)
+
+	if err == db.ErrNotFound {
+		return "Meal not found", nil
+	}
+	return "", err
+}
+
+// validateMealDate checks that the meal exists for the user and that eatenAt
+// falls on its date in the user's timezone. It returns a validation message,
+// or "" if the entry can go in the meal.
+func validateMealDate(ctx context.Context, client *db.PrismaClient, userID, mealID int, eatenAt time.Time) (string, error) {
+	meal, err := client.Meal.FindFirst(
+		db.Meal.ID.Equals(mealID),
+		db.Meal.User.Where(
+			db.User.ID.Equals(userID),
+		),
+	).Exec(ctx)
+
+	if err == db.ErrNotFound {
+		return "Meal not found", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	loc, err := userLocation(ctx, client, userID)
+	if err != nil {
+		return "", err
+	}
+	if eatenAt.In(loc).Format("2006-01-02") != meal.Date {
+		return "eatenAt must be on the meal's date, " + meal.Date, nil
+	}
+	return "", nil
+}
+
+// findMeal returns the user's meal with the given ID, its slot, its moods
+// and its entries with their food and tags
+func findMeal(ctx context.Context, client *db.PrismaClient, userID, mealID int) (*db.MealModel, error) {
+	return client.Meal.FindFirst(
+		db.Meal.ID.Equals(mealID),
+		db.Meal.User.Where(
+			db.User.ID.Equals(userID),
+		),
+	).With(
+		db.Meal.Slot.Fetch(),
+		db.Meal.Moods.Fetch(),
+		db.Meal.Entries.Fetch().With(
+			db.UserFood.Food.Fetch().With(
+				db.Food.Category.Fetch(),
+				db.Food.Micronutrients.Fetch(),
+				db.Food.Servings.Fetch(),
+			),
+			db.UserFood.Tags.Fetch(),
+		),
+	).Exec(ctx)
+}
+
+// newMealView orders the entries of a meal, fetched with findMeal, by time
+// and adds up their nutrients
+func newMealView(meal *db.MealModel) MealView {
+	entries := meal.Entries()
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].EatenAt.Before(entries[j].EatenAt)
+	})
+
+	view := MealView{
+		MealModel: meal,
+		Entries:   []DiaryEntry{},
+		Totals:    DiaryTotals{Facts: nutrition.Zero()},
+	}
+	for i := range entries {
+		entry := newDiaryEntry(&entries[i])
+		view.Entries = append(view.Entries, entry)
+		view.Totals.add(entry)
+	}
+	return view
+}
+
+// clampToDay moves t into the local day starting at day, to its first or
+// last minute when it falls before or after it
+func clampToDay(t, day time.Time) time.Time {
+	if t.Before(day) {
+		return day
+	}
+	if last := day.AddDate(0, 0, 1).Add(-time.Minute); t.After(last) {
+		return last
+	}
+	return t
+}
+
+// findOrCreateMeal returns the user's meal of slot on date, a local
+// YYYY-MM-DD, creating it at eatenAt, or the slot's time on that day when
+// eatenAt is nil. The bool reports whether the meal was created.
+func findOrCreateMeal(ctx context.Context, client *db.PrismaClient, userID int, slot *db.MealSlotModel, day time.Time, eatenAt *time.Time) (*db.MealModel, bool, error) {
+	date := day.Format("2006-01-02")
+	find := func() (*db.MealModel, error) {
+		return client.Meal.FindFirst(
+			db.Meal.Slot.Where(
+				db.MealSlot.ID.Equals(slot.ID),
+			),
+			db.Meal.Date.Equals(date),
+		).Exec(ctx)
+	}
+
+	meal, err := find()
+	if err == nil {
+		return meal, false, nil
+	}
+	if err != db.ErrNotFound {
+		return nil, false, err
+	}
+
+	at := mealSlotTime(day, slot.Time)
+	if eatenAt != nil {
+		at = *eatenAt
+	}
+
+	meal, err = client.Meal.CreateOne(
+		db.Meal.User.Link(
+			db.User.ID.Equals(userID),
+		),
+		db.Meal.Slot.Link(
+			db.MealSlot.ID.Equals(slot.ID),
+		),
+		db.Meal.Date.Set(date),
+		db.Meal.EatenAt.Set(at),
+	).Exec(ctx)
+
+	// A concurrent request may have created the meal in between; the unique
+	// slot and date make this one fail, and that meal is used instead
+	if err != nil && strings.Contains(err.Error(), "Unique constraint failed") {
+		meal, err = find()
+		return meal, false, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return meal, true, nil
+}
+
+// GetMealSlots lists the user's meal slots by position
+func GetMealSlots(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		slots, err := userMealSlots(c.Request.Context(), client, int(userID.(uint)))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch meal slots"})
+			return
+		}
+
+		c.JSON(http.StatusOK, slots)
+	}
+}
+
+// AddDefaultMealSlots gives a user without meal slots breakfast, lunch,
+// snacks and dinner again, e.g. after deleting every slot
+func AddDefaultMealSlots(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+
+		existing, err := userMealSlots(c.Request.Context(), client, userIDInt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch meal slots"})
+			return
+		}
+		if len(existing) > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Default meal slots can only be added when there are no meal slots"})
+			return
+		}
+
+		// A concurrent request creating the same slots fails on the unique
+		// name instead of adding them twice
+		if err := client.Prisma.Transaction(defaultMealSlotOps(client, userIDInt)...).Exec(c.Request.Context()); err != nil {
+			if strings.Contains(err.Error(), "Unique constraint failed") {
+				c.JSON(http.StatusConflict, gin.H{"error": "Default meal slots can only be added when there are no meal slots"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create meal slots: " + err.Error()})
+			}
+			return
+		}
+
+		slots, err := userMealSlots(c.Request.Context(), client, userIDInt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch meal slots"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, slots)
+	}
+}
+
+// CreateMealSlot adds a slot after the user's other slots
+func CreateMealSlot(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input MealSlotInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		if msg := validateMealSlotInput(input); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+
+		existing, err := userMealSlots(c.Request.Context(), client, userIDInt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch meal slots"})
+			return
+		}
+
+		slot, err := client.MealSlot.CreateOne(
+			db.MealSlot.Name.Set(strings.TrimSpace(input.Name)),
+			db.MealSlot.Time.Set(input.Time),
+			db.MealSlot.User.Link(
+				db.User.ID.Equals(userIDInt),
+			),
+			db.MealSlot.Position.Set(len(existing)),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			if strings.Contains(err.Error(), "Unique constraint failed") {
+				c.JSON(http.StatusConflict, gin.H{"error": "A meal slot with this name already exists"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create meal slot: " + err.Error()})
+			}
+			return
+		}
+
+		c.JSON(http.StatusCreated, slot)
+	}
+}
+
+// UpdateMealSlot renames a slot or changes its time. Meals already logged
+// keep their own time.
+func UpdateMealSlot(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input MealSlotInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		if msg := validateMealSlotInput(input); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		slotID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid meal slot ID"})
+			return
+		}
+
+		if _, err := findMealSlot(c.Request.Context(), client, int(userID.(uint)), slotID); err != nil {
+			if err == db.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Meal slot not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch meal slot"})
+			}
+			return
+		}
+
+		slot, err := client.MealSlot.FindUnique(
+			db.MealSlot.ID.Equals(slotID),
+		).Update(
+			db.MealSlot.Name.Set(strings.TrimSpace(input.Name)),
+			db.MealSlot.Time.Set(input.Time),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			if strings.Contains(err.Error(), "Unique constraint failed") {
+				c.JSON(http.StatusConflict, gin.H{"error": "A meal slot with this name already exists"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update meal slot: " + err.Error()})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, slot)
+	}
+}
+
+// DeleteMealSlot removes a slot and its meals. Their food entries and moods
+// are kept without a meal.
+func DeleteMealSlot(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		slotID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid meal slot ID"})
+			return
+		}
+
+		if _, err := findMealSlot(c.Request.Context(), client, int(userID.(uint)), slotID); err != nil {
+			if err == db.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Meal slot not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch meal slot"})
+			}
+			return
+		}
+
+		_, err = client.MealSlot.FindUnique(
+			db.MealSlot.ID.Equals(slotID),
+		).Delete().Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete meal slot"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Meal slot successfully deleted"})
+	}
+}
+
+// ReorderMealSlots stores a new order for the user's meal slots. The request
+// must list every slot exactly once.
+func ReorderMealSlots(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input TagOrderInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		slots, err := userMealSlots(c.Request.Context(), client, int(userID.(uint)))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch meal slots"})
+			return
+		}
+
+		owned := make([]int, len(slots))
+		for i, slot := range slots {
+			owned[i] = slot.ID
+		}
+		if !sameIDs(owned, input.IDs) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Order must include every meal slot exactly once"})
+			return
+		}
+
+		var ops []transaction.Param
+		for position, id := range input.IDs {
+			ops = append(ops, client.MealSlot.FindUnique(
+				db.MealSlot.ID.Equals(id),
+			).Update(
+				db.MealSlot.Position.Set(position),
+			).Tx())
+		}
+
+		// A user may have no slots left to order
+		if len(ops) > 0 {
+			if err := client.Prisma.Transaction(ops...).Exec(c.Request.Context()); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder meal slots: " + err.Error()})
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Meal slots reordered"})
+	}
+}
+
+// GetMeals lists the user's meals between ?from= and ?to= (YYYY-MM-DD, both
+// inclusive, in the user's timezone), today by default, with their entries
+// and nutrition totals
+func GetMeals(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+
+		loc, err := userLocation(c.Request.Context(), client, userIDInt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+			return
+		}
+
+		from, to, err := parseDiaryRange(c, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid range: " + err.Error()})
+			return
+		}
+
+		// Dates are YYYY-MM-DD, so they compare like the days they name
+		meals, err := client.Meal.FindMany(
+			db.Meal.User.Where(
+				db.User.ID.Equals(userIDInt),
+			),
+			db.Meal.Date.Gte(from.Format("2006-01-02")),
+			db.Meal.Date.Lt(to.Format("2006-01-02")),
+		).With(
+			db.Meal.Slot.Fetch(),
+			db.Meal.Moods.Fetch(),
+			db.Meal.Entries.Fetch().With(
+				db.UserFood.Food.Fetch().With(
+					db.Food.Category.Fetch(),
+					db.Food.Micronutrients.Fetch(),
+					db.Food.Servings.Fetch(),
+				),
+				db.UserFood.Tags.Fetch(),
+			),
+		).OrderBy(
+			db.Meal.EatenAt.Order(db.SortOrderAsc),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch meals: " + err.Error()})
+			return
+		}
+
+		views := make([]MealView, len(meals))
+		for i := range meals {
+			views[i] = newMealView(&meals[i])
+		}
+
+		c.JSON(http.StatusOK, views)
+	}
+}
+
+// CreateMeal starts the meal of a slot on a local day, at eatenAt if given,
+// which must fall on that day. A slot has one meal per day, so an existing
+// meal is returned as is.
+func CreateMeal(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input MealInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+
+		loc, err := userLocation(c.Request.Context(), client, userIDInt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+			return
+		}
+
+		day, err := time.ParseInLocation("2006-01-02", input.Date, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+			return
+		}
+
+		if input.EatenAt != nil && input.EatenAt.In(loc).Format("2006-01-02") != input.Date {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "eatenAt must be on the meal's date, " + input.Date})
+			return
+		}
+
+		slot, err := findMealSlot(c.Request.Context(), client, userIDInt, input.SlotID)
+		if err != nil {
+			if err == db.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Meal slot not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch meal slot"})
+			}
+			return
+		}
+
+		meal, created, err := findOrCreateMeal(c.Request.Context(), client, userIDInt, slot, day, input.EatenAt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create meal: " + err.Error()})
+			return
+		}
+
+		meal, err = findMeal(c.Request.Context(), client, userIDInt, meal.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch meal"})
+			return
+		}
+
+		status := http.StatusOK
+		if created {
+			status = http.StatusCreated
+		}
+		c.JSON(status, newMealView(meal))
+	}
+}
+
+// GetMealByID returns a meal with its entries, moods and nutrition totals
+func GetMealByID(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		mealID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid meal ID"})
+			return
+		}
+
+		meal, err := findMeal(c.Request.Context(), client, int(userID.(uint)), mealID)
+		if err != nil {
+			if err == db.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Meal not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch meal"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, newMealView(meal))
+	}
+}
+
+// UpdateMeal changes the time of a meal within its day
+func UpdateMeal(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input MealUpdateInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+
+		mealID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid meal ID"})
+			return
+		}
+
+		existing, err := findMeal(c.Request.Context(), client, userIDInt, mealID)
+		if err != nil {
+			if err == db.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Meal not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch meal"})
+			}
+			return
+		}
+
+		loc, err := userLocation(c.Request.Context(), client, userIDInt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+			return
+		}
+
+		if input.EatenAt.In(loc).Format("2006-01-02") != existing.Date {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "eatenAt must be on the meal's date, " + existing.Date})
+			return
+		}
+
+		_, err = client.Meal.FindUnique(
+			db.Meal.ID.Equals(mealID),
+		).Update(
+			db.Meal.EatenAt.Set(input.EatenAt),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update meal: " + err.Error()})
+			return
+		}
+
+		meal, err := findMeal(c.Request.Context(), client, userIDInt, mealID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch meal"})
+			return
+		}
+
+		c.JSON(http.StatusOK, newMealView(meal))
+	}
+}
+
+// DeleteMeal removes a meal. Its food entries and moods are kept without a
+// meal.
+func DeleteMeal(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		mealID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid meal ID"})
+			return
+		}
+
+		msg, err := validateMeal(c.Request.Context(), client, int(userID.(uint)), mealID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch meal"})
+			return
+		}
+		if msg != "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": msg})
+			return
+		}
+
+		_, err = client.Meal.FindUnique(
+			db.Meal.ID.Equals(mealID),
+		).Delete().Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete meal: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Meal successfully deleted"})
+	}
+}
+
+// CopyMeal logs the entries of a meal again in another meal, like having the
+// same breakfast as yesterday. The target is the meal of the same slot, or of
+// slotId, on date and is created when missing. Copies keep their amount,
+// their unarchived tags and their time relative to the meal's, kept within
+// the target's day. Stored reports covering the copies are dropped.
+func CopyMeal(client *db.PrismaClient, store storage.BlobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input MealCopyInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+
+		mealID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid meal ID"})
+			return
+		}
+
+		source, err := findMeal(c.Request.Context(), client, userIDInt, mealID)
+		if err != nil {
+			if err == db.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Meal not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch meal"})
+			}
+			return
+		}
+
+		loc, err := userLocation(c.Request.Context(), client, userIDInt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+			return
+		}
+
+		day, err := time.ParseInLocation("2006-01-02", input.Date, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+			return
+		}
+
+		slot := source.Slot()
+		if input.SlotID != nil {
+			slot, err = findMealSlot(c.Request.Context(), client, userIDInt, *input.SlotID)
+			if err != nil {
+				if err == db.ErrNotFound {
+					c.JSON(http.StatusNotFound, gin.H{"error": "Meal slot not found"})
+				} else {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch meal slot"})
+				}
+				return
+			}
+		}
+		if slot.ID == source.SlotID && input.Date == source.Date {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A meal cannot be copied onto itself"})
+			return
+		}
+
+		target, _, err := findOrCreateMeal(c.Request.Context(), client, userIDInt, slot, day, nil)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create meal: " + err.Error()})
+			return
+		}
+
+		var ops []transaction.Param
+		var eatenAts []time.Time
+		for _, entry := range source.Entries() {
+			eatenAt := clampToDay(target.EatenAt.Add(entry.EatenAt.Sub(source.EatenAt)), day)
+			if eatenAt.In(loc).Format("2006-01-02") != target.Date {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "eatenAt must be on the meal's date, " + target.Date})
+				return
+			}
+			eatenAts = append(eatenAts, eatenAt)
+
+			grams, weighed := entry.Grams()
+			params := []db.UserFoodSetParam{
+				db.UserFood.Quantity.Set(entry.Quantity),
+				db.UserFood.Unit.Set(entry.Unit),
+				db.UserFood.EatenAt.Set(eatenAt),
+				db.UserFood.Meal.Link(
+					db.Meal.ID.Equals(target.ID),
+				),
+			}
+			if weighed {
+				params = append(params, db.UserFood.Grams.Set(grams))
+			}
+
+			var tags []db.TagWhereParam
+			for _, tag := range entry.Tags() {
+				if _, archived := tag.ArchivedAt(); !archived {
+					tags = append(tags, db.Tag.ID.Equals(tag.ID))
+				}
+			}
+			if len(tags) > 0 {
+				params = append(params, db.UserFood.Tags.Link(tags...))
+			}
+
+			ops = append(ops, client.UserFood.CreateOne(
+				db.UserFood.User.Link(
+					db.User.ID.Equals(userIDInt),
+				),
+				db.UserFood.Food.Link(
+					db.Food.ID.Equals(entry.FoodID),
+				),
+				params...,
+			).Tx())
+		}
+
+		if len(ops) > 0 {
+			if err := client.Prisma.Transaction(ops...).Exec(c.Request.Context()); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to copy meal: " + err.Error()})
+				return
+			}
+			invalidateStoredReports(c.Request.Context(), client, store, userIDInt, eatenAts...)
+		}
+
+		meal, err := findMeal(c.Request.Context(), client, userIDInt, target.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch meal"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, newMealView(meal))
+	}
+}
@@ -2,18 +2,74 @@ package handler
 
 import (
 	"api/prisma/db"
+	"api/sentiment"
+	"api/storage"
+	"context"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/steebchen/prisma-client-go/runtime/transaction"
 )
 
 type MoodInput struct {
-	Title       string `json:"title" binding:"required"`
-	Description string `json:"description" binding:"required"`
-	Emoji       string `json:"emoji" binding:"required"`
-	Tags        []int  `json:"tags"`
+	Title       string               `json:"title" binding:"required"`
+	Description string               `json:"description" binding:"required"`
+	Emoji       string               `json:"emoji"`
+	MoodTypeID  *int                 `json:"moodTypeId"`
+	Moods       []MoodComponentInput `json:"moods"`
+	Latitude    *float64             `json:"latitude"`
+	Longitude   *float64             `json:"longitude"`
+	PlaceID     *int                 `json:"placeId"`
+	MealID      *int                 `json:"mealId"`
+	Tags        []int                `json:"tags"`
+}
+
+// resolveMoodTags finds or creates the tags named by the given IDs
+func resolveMoodTags(ctx context.Context, client *db.PrismaClient, userID int, tags []int) ([]int, error) {
+	var tagIDs []int
+	for _, tagID := range tags {
+		tagName := strconv.Itoa(tagID)
+		tag, err := client.Tag.FindFirst(
+			db.Tag.Name.Equals(tagName),
+			db.Tag.User.Where(
+				db.User.ID.Equals(userID),
+			),
+		).Exec(ctx)
+
+		if err == db.ErrNotFound {
+			// Tag doesn't exist, create it
+			newTag, err := client.Tag.CreateOne(
+				db.Tag.Name.Set(tagName),
+				db.Tag.User.Link(
+					db.User.ID.Equals(userID),
+				),
+			).Exec(ctx)
+
+			if err != nil {
+				return nil, fmt.Errorf("create tag: %w", err)
+			}
+			tagIDs = append(tagIDs, newTag.ID)
+		} else if err != nil {
+			return nil, fmt.Errorf("check existing tag: %w", err)
+		} else {
+			tagIDs = append(tagIDs, tag.ID)
+		}
+	}
+	return tagIDs, nil
+}
+
+// moodSentiment scores the title and description of an entry, nil when the
+// text carries no sentiment the lexicon knows
+func moodSentiment(input MoodInput) *float64 {
+	result, ok := sentiment.Analyze(input.Title + ".\n" + input.Description)
+	if !ok {
+		return nil
+	}
+	return &result.Score
 }
 
 func CreateMood(client *db.PrismaClient) gin.HandlerFunc {
@@ -36,47 +92,69 @@ func CreateMood(client *db.PrismaClient) gin.HandlerFunc {
 			return
 		}
 
-		// Create or get existing tags
-		var tagIDs []int
-		for _, tagID := range moodInput.Tags {
-			tagName := strconv.Itoa(tagID)
-			tag, err := client.Tag.FindFirst(
-				db.Tag.Name.Equals(tagName),
-				db.Tag.User.Where(
-					db.User.ID.Equals(int(userID)),
-				),
-			).Exec(c.Request.Context())
+		components, err := resolveMoodComponents(c.Request.Context(), client, int(userID), moodInput, nil)
+		if err != nil {
+			c.JSON(moodComponentErrorStatus(err), gin.H{"error": "Invalid mood: " + err.Error()})
+			return
+		}
 
-			if err == db.ErrNotFound {
-				// Tag doesn't exist, create it
-				newTag, err := client.Tag.CreateOne(
-					db.Tag.Name.Set(tagName),
-					db.Tag.User.Link(
-						db.User.ID.Equals(int(userID)),
-					),
-				).Exec(c.Request.Context())
-
-				if err != nil {
-					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tag: " + err.Error()})
-					return
-				}
-				tagIDs = append(tagIDs, newTag.ID)
-			} else if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing tag: " + err.Error()})
+		// The dominant mood keeps the single emoji field filled for older
+		// clients; the weighted valence is stored with the entry so it
+		// survives later edits or archiving of a custom type
+		dominant, valence := summarizeMoodComponents(components)
+		moodParams := []db.MoodSetParam{
+			db.Mood.Valence.SetIfPresent(valence),
+			db.Mood.Sentiment.SetIfPresent(moodSentiment(moodInput)),
+		}
+		if dominant.MoodTypeID != nil {
+			moodParams = append(moodParams, db.Mood.MoodType.Link(
+				db.MoodType.ID.Equals(*dominant.MoodTypeID),
+			))
+		}
+
+		locationParams, err := resolveMoodLocation(c.Request.Context(), client, int(userID), moodInput)
+		if err != nil {
+			switch err {
+			case errPlaceNotFound:
+				c.JSON(http.StatusNotFound, gin.H{"error": "Place not found"})
+			case errInvalidCoordinates:
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve location: " + err.Error()})
+			}
+			return
+		}
+		moodParams = append(moodParams, locationParams...)
+
+		if moodInput.MealID != nil {
+			msg, err := validateMeal(c.Request.Context(), client, int(userID), *moodInput.MealID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch meal"})
+				return
+			}
+			if msg != "" {
+				c.JSON(http.StatusNotFound, gin.H{"error": msg})
 				return
-			} else {
-				tagIDs = append(tagIDs, tag.ID)
 			}
+			moodParams = append(moodParams, db.Mood.Meal.Link(
+				db.Meal.ID.Equals(*moodInput.MealID),
+			))
+		}
+
+		tagIDs, err := resolveMoodTags(c.Request.Context(), client, int(userID), moodInput.Tags)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve tags: " + err.Error()})
+			return
 		}
 
-		// Create mood
 		createdMood, err := client.Mood.CreateOne(
 			db.Mood.Title.Set(moodInput.Title),
 			db.Mood.Description.Set(moodInput.Description),
-			db.Mood.Emoji.Set(moodInput.Emoji),
+			db.Mood.Emoji.Set(dominant.Emoji),
 			db.Mood.User.Link(
 				db.User.ID.Equals(int(userID)),
 			),
+			moodParams...,
 		).Exec(c.Request.Context())
 
 		if err != nil {
@@ -84,20 +162,35 @@ func CreateMood(client *db.PrismaClient) gin.HandlerFunc {
 			return
 		}
 
-		// Link tags to mood
+		// Store the components and tags of the new mood together; if that
+		// fails the mood is removed again, so it is never left without them
+		ops := moodComponentOps(client, createdMood.ID, components)
 		for _, tagID := range tagIDs {
-			_, err := client.Mood.FindUnique(
+			ops = append(ops, client.Mood.FindUnique(
 				db.Mood.ID.Equals(createdMood.ID),
 			).Update(
 				db.Mood.Tags.Link(
 					db.Tag.ID.Equals(tagID),
 				),
-			).Exec(c.Request.Context())
+			).Tx())
+		}
 
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link tag to mood: " + err.Error()})
-				return
+		if err := client.Prisma.Transaction(ops...).Exec(c.Request.Context()); err != nil {
+			if _, deleteErr := client.Mood.FindUnique(
+				db.Mood.ID.Equals(createdMood.ID),
+			).Delete().Exec(c.Request.Context()); deleteErr != nil {
+				log.Println("Mood", createdMood.ID, "could not be removed after its components failed:", deleteErr)
 			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create mood: " + err.Error()})
+			return
+		}
+
+		moodStatsCache.invalidate(int(userID))
+
+		// A failed check-in evaluation must not fail the mood itself
+		checkIns, err := evaluateWellbeing(c.Request.Context(), client, int(userID))
+		if err != nil {
+			log.Println("Wellbeing check-ins could not be evaluated for user", userID, err)
 		}
 
 		// Fetch the final mood with tags
@@ -105,6 +198,10 @@ func CreateMood(client *db.PrismaClient) gin.HandlerFunc {
 			db.Mood.ID.Equals(createdMood.ID),
 		).With(
 			db.Mood.Tags.Fetch(),
+			db.Mood.MoodType.Fetch(),
+			db.Mood.Components.Fetch(),
+			db.Mood.Place.Fetch(),
+			db.Mood.Meal.Fetch(),
 		).Exec(c.Request.Context())
 
 		if err != nil {
@@ -112,10 +209,16 @@ func CreateMood(client *db.PrismaClient) gin.HandlerFunc {
 			return
 		}
 
-		c.JSON(http.StatusOK, moodWithTags)
+		c.JSON(http.StatusOK, MoodResponse{
+			MoodModel: moodWithTags,
+			Safety:    moodSafety(c.Request.Context(), client, int(userID), moodInput),
+			CheckIns:  checkIns,
+		})
 	}
 }
 
+// GetMoods lists the user's moods, newest first. ?tag=<id> keeps only moods
+// tagged with that tag or any tag nested below it.
 func GetMoods(client *db.PrismaClient) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID, exists := c.Get("user_id")
@@ -124,12 +227,38 @@ func GetMoods(client *db.PrismaClient) gin.HandlerFunc {
 			return
 		}
 
-		moods, err := client.Mood.FindMany(
+		userIDInt := int(userID.(uint))
+
+		filters := []db.MoodWhereParam{
 			db.Mood.User.Where(
-				db.User.ID.Equals(int(userID.(uint))),
+				db.User.ID.Equals(userIDInt),
 			),
+		}
+		if tag := c.Query("tag"); tag != "" {
+			tagID, err := strconv.Atoi(tag)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+				return
+			}
+
+			tagIDs, err := tagDescendants(c.Request.Context(), client, userIDInt, tagID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+				return
+			}
+			filters = append(filters, db.Mood.Tags.Some(
+				db.Tag.ID.In(tagIDs),
+			))
+		}
+
+		moods, err := client.Mood.FindMany(
+			filters...,
 		).With(
 			db.Mood.Tags.Fetch(),
+			db.Mood.MoodType.Fetch(),
+			db.Mood.Components.Fetch(),
+			db.Mood.Place.Fetch(),
+			db.Mood.Meal.Fetch(),
 		).OrderBy(
 			db.Mood.CreatedAt.Order(db.DESC),
 		).Exec(c.Request.Context())
@@ -164,6 +293,10 @@ func GetMoodByID(client *db.PrismaClient) gin.HandlerFunc {
 			),
 		).With(
 			db.Mood.Tags.Fetch(),
+			db.Mood.MoodType.Fetch(),
+			db.Mood.Components.Fetch(),
+			db.Mood.Place.Fetch(),
+			db.Mood.Meal.Fetch(),
 		).Exec(c.Request.Context())
 
 		if err != nil {
@@ -179,7 +312,198 @@ func GetMoodByID(client *db.PrismaClient) gin.HandlerFunc {
 	}
 }
 
-func DeleteMood(client *db.PrismaClient) gin.HandlerFunc {
+// UpdateMood replaces an entry's text, moods, location, meal and tags. Fields
+// left out of the input are cleared, and the text's sentiment is scored
+// again. Stored reports covering the entry are dropped so they are rendered
+// again with the new text.
+func UpdateMood(client *db.PrismaClient, store storage.BlobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var moodInput MoodInput
+		if err := c.ShouldBindJSON(&moodInput); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+
+		moodID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mood ID"})
+			return
+		}
+
+		existing, err := client.Mood.FindFirst(
+			db.Mood.ID.Equals(moodID),
+			db.Mood.User.Where(
+				db.User.ID.Equals(userIDInt),
+			),
+		).With(
+			db.Mood.Tags.Fetch(),
+			db.Mood.Components.Fetch(),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			if err == db.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Mood not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch mood"})
+			}
+			return
+		}
+
+		// Types archived since the entry was written may stay on it
+		kept := make(map[int]bool)
+		if moodTypeID, ok := existing.MoodTypeID(); ok {
+			kept[moodTypeID] = true
+		}
+		for _, component := range existing.Components() {
+			if moodTypeID, ok := component.MoodTypeID(); ok {
+				kept[moodTypeID] = true
+			}
+		}
+
+		components, err := resolveMoodComponents(c.Request.Context(), client, userIDInt, moodInput, kept)
+		if err != nil {
+			c.JSON(moodComponentErrorStatus(err), gin.H{"error": "Invalid mood: " + err.Error()})
+			return
+		}
+
+		dominant, valence := summarizeMoodComponents(components)
+		moodParams := []db.MoodSetParam{
+			db.Mood.Title.Set(moodInput.Title),
+			db.Mood.Description.Set(moodInput.Description),
+			db.Mood.Emoji.Set(dominant.Emoji),
+			db.Mood.Valence.SetOptional(valence),
+			db.Mood.Sentiment.SetOptional(moodSentiment(moodInput)),
+		}
+		if dominant.MoodTypeID != nil {
+			moodParams = append(moodParams, db.Mood.MoodType.Link(
+				db.MoodType.ID.Equals(*dominant.MoodTypeID),
+			))
+		}
+
+		locationParams, err := resolveMoodLocation(c.Request.Context(), client, userIDInt, moodInput)
+		if err != nil {
+			switch err {
+			case errPlaceNotFound:
+				c.JSON(http.StatusNotFound, gin.H{"error": "Place not found"})
+			case errInvalidCoordinates:
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve location: " + err.Error()})
+			}
+			return
+		}
+		moodParams = append(moodParams, locationParams...)
+
+		if moodInput.MealID != nil {
+			msg, err := validateMeal(c.Request.Context(), client, userIDInt, *moodInput.MealID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch meal"})
+				return
+			}
+			if msg != "" {
+				c.JSON(http.StatusNotFound, gin.H{"error": msg})
+				return
+			}
+			moodParams = append(moodParams, db.Mood.Meal.Link(
+				db.Meal.ID.Equals(*moodInput.MealID),
+			))
+		}
+
+		tagIDs, err := resolveMoodTags(c.Request.Context(), client, userIDInt, moodInput.Tags)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve tags: " + err.Error()})
+			return
+		}
+
+		// Relations and coordinates are cleared first, so the update below
+		// only has to set what the new input contains
+		ops := []transaction.Param{
+			client.Mood.FindUnique(
+				db.Mood.ID.Equals(moodID),
+			).Update(
+				db.Mood.MoodType.Unlink(),
+				db.Mood.Place.Unlink(),
+				db.Mood.Meal.Unlink(),
+				db.Mood.Latitude.SetOptional(nil),
+				db.Mood.Longitude.SetOptional(nil),
+			).Tx(),
+			client.Mood.FindUnique(
+				db.Mood.ID.Equals(moodID),
+			).Update(
+				moodParams...,
+			).Tx(),
+			client.MoodComponent.FindMany(
+				db.MoodComponent.Mood.Where(
+					db.Mood.ID.Equals(moodID),
+				),
+			).Delete().Tx(),
+		}
+		for _, tag := range existing.Tags() {
+			ops = append(ops, client.Mood.FindUnique(
+				db.Mood.ID.Equals(moodID),
+			).Update(
+				db.Mood.Tags.Unlink(
+					db.Tag.ID.Equals(tag.ID),
+				),
+			).Tx())
+		}
+		for _, tagID := range tagIDs {
+			ops = append(ops, client.Mood.FindUnique(
+				db.Mood.ID.Equals(moodID),
+			).Update(
+				db.Mood.Tags.Link(
+					db.Tag.ID.Equals(tagID),
+				),
+			).Tx())
+		}
+		ops = append(ops, moodComponentOps(client, moodID, components)...)
+
+		if err := client.Prisma.Transaction(ops...).Exec(c.Request.Context()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update mood: " + err.Error()})
+			return
+		}
+
+		moodStatsCache.invalidate(userIDInt)
+		invalidateStoredReports(c.Request.Context(), client, store, userIDInt, existing.CreatedAt)
+
+		// The new moods can change the recent days' valence like a new entry
+		checkIns, err := evaluateWellbeing(c.Request.Context(), client, userIDInt)
+		if err != nil {
+			log.Println("Wellbeing check-ins could not be evaluated for user", userIDInt, err)
+		}
+
+		mood, err := client.Mood.FindUnique(
+			db.Mood.ID.Equals(moodID),
+		).With(
+			db.Mood.Tags.Fetch(),
+			db.Mood.MoodType.Fetch(),
+			db.Mood.Components.Fetch(),
+			db.Mood.Place.Fetch(),
+			db.Mood.Meal.Fetch(),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch mood"})
+			return
+		}
+
+		c.JSON(http.StatusOK, MoodResponse{
+			MoodModel: mood,
+			Safety:    moodSafety(c.Request.Context(), client, userIDInt, moodInput),
+			CheckIns:  checkIns,
+		})
+	}
+}
+
+func DeleteMood(client *db.PrismaClient, store storage.BlobStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID, exists := c.Get("user_id")
 		if !exists {
@@ -193,7 +517,7 @@ func DeleteMood(client *db.PrismaClient) gin.HandlerFunc {
 			return
 		}
 
-		_, err = client.Mood.FindFirst(
+		mood, err := client.Mood.FindFirst(
 			db.Mood.ID.Equals(moodID),
 			db.Mood.User.Where(
 				db.User.ID.Equals(int(userID.(uint))),
@@ -209,6 +533,17 @@ func DeleteMood(client *db.PrismaClient) gin.HandlerFunc {
 			return
 		}
 
+		attachments, err := client.Attachment.FindMany(
+			db.Attachment.Mood.Where(
+				db.Mood.ID.Equals(moodID),
+			),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attachments"})
+			return
+		}
+
 		_, err = client.Mood.FindUnique(
 			db.Mood.ID.Equals(moodID),
 		).Delete().Exec(c.Request.Context())
@@ -218,6 +553,11 @@ func DeleteMood(client *db.PrismaClient) gin.HandlerFunc {
 			return
 		}
 
+		// Attachment rows are removed by the cascade, their files are not
+		deleteAttachmentBlobs(c.Request.Context(), store, attachments)
+		moodStatsCache.invalidate(int(userID.(uint)))
+		invalidateStoredReports(c.Request.Context(), client, store, int(userID.(uint)), mood.CreatedAt)
+
 		c.JSON(http.StatusOK, gin.H{"message": "Mood successfully deleted"})
 	}
 }
@@ -244,6 +584,10 @@ func GetMoodByDate(client *db.PrismaClient) gin.HandlerFunc {
 			db.Mood.CreatedAt.Equals(date),
 		).With(
 			db.Mood.Tags.Fetch(),
+			db.Mood.MoodType.Fetch(),
+			db.Mood.Components.Fetch(),
+			db.Mood.Place.Fetch(),
+			db.Mood.Meal.Fetch(),
 		).Exec(c.Request.Context())
 
 		if err != nil {
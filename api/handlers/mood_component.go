@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"api/prisma/db"
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/steebchen/prisma-client-go/runtime/transaction"
+)
+
+// MoodComponentInput is one of several moods felt at the same time. Weights
+// are relative and normalized before storage.
+type MoodComponentInput struct {
+	Emoji      string  `json:"emoji"`
+	MoodTypeID *int    `json:"moodTypeId"`
+	Weight     float64 `json:"weight"`
+}
+
+const maxMoodComponents = 5
+
+var (
+	errMoodTypeNotFound      = errors.New("mood type not found")
+	errMoodTypeArchived      = errors.New("mood type is archived")
+	errInvalidMoodComponents = errors.New("either emoji, moodTypeId or moods is required")
+	errTooManyMoodComponents = errors.New("too many moods in one entry")
+	errInvalidMoodWeight     = errors.New("mood weights must be positive")
+)
+
+type resolvedMoodComponent struct {
+	Emoji      string
+	MoodTypeID *int
+	Weight     float64
+	Valence    *float64
+}
+
+// resolveMoodComponents turns the mood part of a MoodInput into weighted
+// components. Older clients send a single emoji or moodTypeId, which becomes
+// one component with weight 1. Archived types are rejected unless they are
+// in kept, the types an edited entry already has, so old entries stay
+// editable.
+func resolveMoodComponents(ctx context.Context, client *db.PrismaClient, userID int, input MoodInput, kept map[int]bool) ([]resolvedMoodComponent, error) {
+	inputs := input.Moods
+	if len(inputs) == 0 {
+		if input.Emoji == "" && input.MoodTypeID == nil {
+			return nil, errInvalidMoodComponents
+		}
+		inputs = []MoodComponentInput{{Emoji: input.Emoji, MoodTypeID: input.MoodTypeID, Weight: 1}}
+	}
+
+	if len(inputs) > maxMoodComponents {
+		return nil, errTooManyMoodComponents
+	}
+
+	var totalWeight float64
+	components := make([]resolvedMoodComponent, 0, len(inputs))
+	for _, componentInput := range inputs {
+		if componentInput.Weight <= 0 {
+			return nil, errInvalidMoodWeight
+		}
+		totalWeight += componentInput.Weight
+
+		component := resolvedMoodComponent{Emoji: componentInput.Emoji, Weight: componentInput.Weight}
+
+		if componentInput.MoodTypeID != nil {
+			moodType, err := client.MoodType.FindFirst(
+				db.MoodType.ID.Equals(*componentInput.MoodTypeID),
+				db.MoodType.User.Where(
+					db.User.ID.Equals(userID),
+				),
+			).Exec(ctx)
+
+			if err != nil {
+				if err == db.ErrNotFound {
+					return nil, errMoodTypeNotFound
+				}
+				return nil, err
+			}
+
+			if _, archived := moodType.ArchivedAt(); archived && !kept[moodType.ID] {
+				return nil, errMoodTypeArchived
+			}
+
+			valence := moodType.Valence
+			component.Emoji = moodType.Emoji
+			component.MoodTypeID = &moodType.ID
+			component.Valence = &valence
+		} else if component.Emoji == "" {
+			return nil, errInvalidMoodComponents
+		} else if builtin, ok := findBuiltinMoodType(component.Emoji); ok {
+			valence := builtin.Valence
+			component.Valence = &valence
+		}
+
+		components = append(components, component)
+	}
+
+	for i := range components {
+		components[i].Weight /= totalWeight
+	}
+
+	return components, nil
+}
+
+// summarizeMoodComponents picks the dominant component, which keeps filling
+// the single emoji field older clients read, and the weighted mean valence.
+func summarizeMoodComponents(components []resolvedMoodComponent) (resolvedMoodComponent, *float64) {
+	dominant := components[0]
+	var valenceSum, valenceWeight float64
+	for _, component := range components {
+		if component.Weight > dominant.Weight {
+			dominant = component
+		}
+		if component.Valence != nil {
+			valenceSum += *component.Valence * component.Weight
+			valenceWeight += component.Weight
+		}
+	}
+
+	if valenceWeight == 0 {
+		return dominant, nil
+	}
+	valence := valenceSum / valenceWeight
+	return dominant, &valence
+}
+
+// moodComponentOps returns the operations storing the resolved components of
+// a mood entry, to run in one transaction with the entry's other changes
+func moodComponentOps(client *db.PrismaClient, moodID int, components []resolvedMoodComponent) []transaction.Param {
+	var ops []transaction.Param
+	for _, component := range components {
+		params := []db.MoodComponentSetParam{
+			db.MoodComponent.Weight.Set(component.Weight),
+			db.MoodComponent.Valence.SetIfPresent(component.Valence),
+		}
+		if component.MoodTypeID != nil {
+			params = append(params, db.MoodComponent.MoodType.Link(
+				db.MoodType.ID.Equals(*component.MoodTypeID),
+			))
+		}
+
+		ops = append(ops, client.MoodComponent.CreateOne(
+			db.MoodComponent.Mood.Link(
+				db.Mood.ID.Equals(moodID),
+			),
+			db.MoodComponent.Emoji.Set(component.Emoji),
+			params...,
+		).Tx())
+	}
+	return ops
+}
+
+func moodComponentErrorStatus(err error) int {
+	switch err {
+	case errMoodTypeNotFound:
+		return http.StatusNotFound
+	case errMoodTypeArchived, errInvalidMoodComponents, errTooManyMoodComponents, errInvalidMoodWeight:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,429 @@
+package handler
+
+import (
+	"api/prisma/db"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/steebchen/prisma-client-go/runtime/transaction"
+)
+
+type MoodTypeInput struct {
+	Label   string   `json:"label" binding:"required"`
+	Emoji   string   `json:"emoji" binding:"required"`
+	Color   string   `json:"color" binding:"required"`
+	Valence *float64 `json:"valence" binding:"required"`
+}
+
+type MoodTypeOrderInput struct {
+	IDs []int `json:"ids" binding:"required"`
+}
+
+// MoodTypeView is the merged representation of built-in and custom mood types
+type MoodTypeView struct {
+	Key      string  `json:"key"`
+	ID       int     `json:"id,omitempty"`
+	Label    string  `json:"label"`
+	Emoji    string  `json:"emoji"`
+	Color    string  `json:"color"`
+	Valence  float64 `json:"valence"`
+	Position int     `json:"position"`
+	BuiltIn  bool    `json:"builtIn"`
+	Archived bool    `json:"archived"`
+}
+
+// builtinMoodTypes mirrors the fixed mood list shipped with the client
+// (client/constants/moodOptions.ts). Valence ranges from -1 (very negative)
+// to 1 (very positive).
+var builtinMoodTypes = []MoodTypeView{
+	{Key: "builtin:1", Label: "Coşkulu", Emoji: "🤩", Color: "#FFD700", Valence: 1.0},
+	{Key: "builtin:2", Label: "Mutlu", Emoji: "😊", Color: "#FF69B4", Valence: 0.8},
+	{Key: "builtin:3", Label: "Heyecanlı", Emoji: "🥳", Color: "#98FB98", Valence: 0.8},
+	{Key: "builtin:4", Label: "Neşeli", Emoji: "😄", Color: "#FFA07A", Valence: 0.8},
+	{Key: "builtin:5", Label: "Sevgi dolu", Emoji: "🥰", Color: "#FF69B4", Valence: 0.9},
+	{Key: "builtin:6", Label: "Minnettar", Emoji: "🙏", Color: "#DDA0DD", Valence: 0.7},
+	{Key: "builtin:7", Label: "Huzurlu", Emoji: "😌", Color: "#87CEEB", Valence: 0.6},
+	{Key: "builtin:8", Label: "Enerjik", Emoji: "⚡️", Color: "#FFD700", Valence: 0.6},
+	{Key: "builtin:9", Label: "Sakin", Emoji: "😐", Color: "#D3D3D3", Valence: 0.2},
+	{Key: "builtin:10", Label: "Düşünceli", Emoji: "🤔", Color: "#B8B8B8", Valence: 0.0},
+	{Key: "builtin:11", Label: "Uykulu", Emoji: "😴", Color: "#C0C0C0", Valence: -0.2},
+	{Key: "builtin:12", Label: "Meşgul", Emoji: "💭", Color: "#A9A9A9", Valence: -0.1},
+	{Key: "builtin:13", Label: "Kafası karışık", Emoji: "😕", Color: "#A9A9A9", Valence: -0.3},
+	{Key: "builtin:14", Label: "Yorgun", Emoji: "😮‍💨", Color: "#A9A9A9", Valence: -0.4},
+	{Key: "builtin:15", Label: "Üzgün", Emoji: "😢", Color: "#4169E1", Valence: -0.8},
+	{Key: "builtin:16", Label: "Stresli", Emoji: "😰", Color: "#FF6347", Valence: -0.7},
+	{Key: "builtin:17", Label: "Sinirli", Emoji: "😤", Color: "#FF4500", Valence: -0.7},
+	{Key: "builtin:18", Label: "Endişeli", Emoji: "😟", Color: "#8B0000", Valence: -0.7},
+	{Key: "builtin:19", Label: "Hayal kırıklığı", Emoji: "😞", Color: "#4B0082", Valence: -0.7},
+	{Key: "builtin:20", Label: "Öfkeli", Emoji: "😠", Color: "#DC143C", Valence: -0.9},
+	{Key: "builtin:21", Label: "Kırgın", Emoji: "💔", Color: "#8B0000", Valence: -0.8},
+	{Key: "builtin:22", Label: "Hasta", Emoji: "🤒", Color: "#DEB887", Valence: -0.6},
+	{Key: "builtin:23", Label: "Motive", Emoji: "💪", Color: "#FFD700", Valence: 0.7},
+	{Key: "builtin:24", Label: "Uykusuz", Emoji: "🥱", Color: "#8B4513", Valence: -0.4},
+	{Key: "builtin:25", Label: "Dinlenmiş", Emoji: "✨", Color: "#98FB98", Valence: 0.5},
+	{Key: "builtin:26", Label: "Ağrılı", Emoji: "🤕", Color: "#8B0000", Valence: -0.6},
+	{Key: "builtin:27", Label: "İlham almış", Emoji: "💫", Color: "#9370DB", Valence: 0.7},
+	{Key: "builtin:28", Label: "Yaratıcı", Emoji: "🎨", Color: "#BA55D3", Valence: 0.6},
+	{Key: "builtin:29", Label: "Odaklanmış", Emoji: "🎯", Color: "#4B0082", Valence: 0.5},
+	{Key: "builtin:30", Label: "Hayalperest", Emoji: "🌟", Color: "#9932CC", Valence: 0.4},
+}
+
+var hexColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// findBuiltinMoodType matches a mood emoji against the built-in taxonomy.
+// Older clients send the label together with the emoji ("Mutlu 😊"), so a
+// trailing emoji is accepted as well.
+func findBuiltinMoodType(emoji string) (MoodTypeView, bool) {
+	emoji = strings.TrimSpace(emoji)
+	for _, moodType := range builtinMoodTypes {
+		if emoji == moodType.Emoji || strings.HasSuffix(emoji, " "+moodType.Emoji) {
+			return moodType, true
+		}
+	}
+	return MoodTypeView{}, false
+}
+
+func moodTypeToView(moodType db.MoodTypeModel) MoodTypeView {
+	_, archived := moodType.ArchivedAt()
+	return MoodTypeView{
+		Key:      "custom:" + strconv.Itoa(moodType.ID),
+		ID:       moodType.ID,
+		Label:    moodType.Label,
+		Emoji:    moodType.Emoji,
+		Color:    moodType.Color,
+		Valence:  moodType.Valence,
+		Position: moodType.Position,
+		Archived: archived,
+	}
+}
+
+func validateMoodTypeInput(input MoodTypeInput) string {
+	if strings.TrimSpace(input.Label) == "" {
+		return "Label cannot be empty"
+	}
+	if !hexColorPattern.MatchString(input.Color) {
+		return "Color must be a hex value like #A1B2C3"
+	}
+	if *input.Valence < -1 || *input.Valence > 1 {
+		return "Valence must be between -1 and 1"
+	}
+	return ""
+}
+
+// moodTypeLabelTaken reports whether a mood type other than exceptID already
+// has label, ignoring case and surrounding spaces
+func moodTypeLabelTaken(moodTypes []db.MoodTypeModel, label string, exceptID int) bool {
+	label = strings.TrimSpace(label)
+	for _, moodType := range moodTypes {
+		if moodType.ID != exceptID && strings.EqualFold(moodType.Label, label) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetMoodTypes returns the built-in mood types followed by the user's own,
+// ordered by position. Archived custom types are only included on request.
+func GetMoodTypes(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		params := []db.MoodTypeWhereParam{
+			db.MoodType.User.Where(
+				db.User.ID.Equals(int(userID.(uint))),
+			),
+		}
+		if c.Query("includeArchived") != "true" {
+			params = append(params, db.MoodType.ArchivedAt.IsNull())
+		}
+
+		customTypes, err := client.MoodType.FindMany(params...).OrderBy(
+			db.MoodType.Position.Order(db.ASC),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch mood types"})
+			return
+		}
+
+		moodTypes := make([]MoodTypeView, 0, len(builtinMoodTypes)+len(customTypes))
+		for i, moodType := range builtinMoodTypes {
+			moodType.BuiltIn = true
+			moodType.Position = i
+			moodTypes = append(moodTypes, moodType)
+		}
+		for _, moodType := range customTypes {
+			moodTypes = append(moodTypes, moodTypeToView(moodType))
+		}
+
+		c.JSON(http.StatusOK, moodTypes)
+	}
+}
+
+// CreateMoodType adds a custom mood type at the end of the user's list
+func CreateMoodType(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input MoodTypeInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		if msg := validateMoodTypeInput(input); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+
+		existingTypes, err := client.MoodType.FindMany(
+			db.MoodType.User.Where(
+				db.User.ID.Equals(userIDInt),
+			),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch mood types"})
+			return
+		}
+
+		if moodTypeLabelTaken(existingTypes, input.Label, 0) {
+			c.JSON(http.StatusConflict, gin.H{"error": "A mood type with this label already exists"})
+			return
+		}
+
+		moodType, err := client.MoodType.CreateOne(
+			db.MoodType.Label.Set(strings.TrimSpace(input.Label)),
+			db.MoodType.Emoji.Set(input.Emoji),
+			db.MoodType.Color.Set(input.Color),
+			db.MoodType.Valence.Set(*input.Valence),
+			db.MoodType.User.Link(
+				db.User.ID.Equals(userIDInt),
+			),
+			db.MoodType.Position.Set(len(existingTypes)),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create mood type: " + err.Error()})
+			return
+		}
+
+		moodStatsCache.invalidate(userIDInt)
+
+		c.JSON(http.StatusCreated, moodTypeToView(*moodType))
+	}
+}
+
+// UpdateMoodType edits a custom mood type. Existing moods keep the valence
+// they were logged with.
+func UpdateMoodType(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input MoodTypeInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		if msg := validateMoodTypeInput(input); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		moodTypeID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mood type ID"})
+			return
+		}
+
+		ownedTypes, err := client.MoodType.FindMany(
+			db.MoodType.User.Where(
+				db.User.ID.Equals(int(userID.(uint))),
+			),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch mood types"})
+			return
+		}
+
+		owned := false
+		for _, moodType := range ownedTypes {
+			if moodType.ID == moodTypeID {
+				owned = true
+				break
+			}
+		}
+		if !owned {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Mood type not found"})
+			return
+		}
+
+		if moodTypeLabelTaken(ownedTypes, input.Label, moodTypeID) {
+			c.JSON(http.StatusConflict, gin.H{"error": "A mood type with this label already exists"})
+			return
+		}
+
+		updatedType, err := client.MoodType.FindUnique(
+			db.MoodType.ID.Equals(moodTypeID),
+		).Update(
+			db.MoodType.Label.Set(strings.TrimSpace(input.Label)),
+			db.MoodType.Emoji.Set(input.Emoji),
+			db.MoodType.Color.Set(input.Color),
+			db.MoodType.Valence.Set(*input.Valence),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			if strings.Contains(err.Error(), "Unique constraint failed") {
+				c.JSON(http.StatusConflict, gin.H{"error": "A mood type with this label already exists"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update mood type: " + err.Error()})
+			}
+			return
+		}
+
+		moodStatsCache.invalidate(int(userID.(uint)))
+
+		c.JSON(http.StatusOK, moodTypeToView(*updatedType))
+	}
+}
+
+// SetMoodTypeArchived archives or restores a custom mood type. Archived types
+// disappear from pickers but moods already linked to them are left untouched.
+func SetMoodTypeArchived(client *db.PrismaClient, archived bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		moodTypeID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mood type ID"})
+			return
+		}
+
+		_, err = client.MoodType.FindFirst(
+			db.MoodType.ID.Equals(moodTypeID),
+			db.MoodType.User.Where(
+				db.User.ID.Equals(int(userID.(uint))),
+			),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			if err == db.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Mood type not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch mood type"})
+			}
+			return
+		}
+
+		var archivedAt *time.Time
+		if archived {
+			now := time.Now()
+			archivedAt = &now
+		}
+
+		updatedType, err := client.MoodType.FindUnique(
+			db.MoodType.ID.Equals(moodTypeID),
+		).Update(
+			db.MoodType.ArchivedAt.SetOptional(archivedAt),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update mood type: " + err.Error()})
+			return
+		}
+
+		moodStatsCache.invalidate(int(userID.(uint)))
+
+		c.JSON(http.StatusOK, moodTypeToView(*updatedType))
+	}
+}
+
+// ReorderMoodTypes stores a new order for the user's custom mood types. The
+// request must list every custom type the user owns exactly once.
+func ReorderMoodTypes(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input MoodTypeOrderInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		ownedTypes, err := client.MoodType.FindMany(
+			db.MoodType.User.Where(
+				db.User.ID.Equals(int(userID.(uint))),
+			),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch mood types"})
+			return
+		}
+
+		owned := make(map[int]bool, len(ownedTypes))
+		for _, moodType := range ownedTypes {
+			owned[moodType.ID] = true
+		}
+
+		if len(input.IDs) != len(owned) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Order must include every custom mood type exactly once"})
+			return
+		}
+
+		seen := make(map[int]bool, len(input.IDs))
+		var ops []transaction.Param
+		for position, id := range input.IDs {
+			if !owned[id] || seen[id] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Order must include every custom mood type exactly once"})
+				return
+			}
+			seen[id] = true
+
+			ops = append(ops, client.MoodType.FindUnique(
+				db.MoodType.ID.Equals(id),
+			).Update(
+				db.MoodType.Position.Set(position),
+			).Tx())
+		}
+
+		if len(ops) > 0 {
+			if err := client.Prisma.Transaction(ops...).Exec(c.Request.Context()); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder mood types: " + err.Error()})
+				return
+			}
+		}
+
+		moodStatsCache.invalidate(int(userID.(uint)))
+
+		c.JSON(http.StatusOK, gin.H{"message": "Mood types reordered"})
+	}
+}
@@ -0,0 +1,539 @@
+package handler
+
+import (
+	"api/prisma/db"
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PlaceInput struct {
+	Name      string   `json:"name" binding:"required"`
+	Latitude  *float64 `json:"latitude" binding:"required"`
+	Longitude *float64 `json:"longitude" binding:"required"`
+	Radius    *float64 `json:"radius"`
+}
+
+type LocationPrivacyInput struct {
+	Precision string `json:"precision" binding:"required"`
+}
+
+type PlaceStats struct {
+	PlaceID        int     `json:"placeId"`
+	Name           string  `json:"name"`
+	Count          int     `json:"count"`
+	AverageValence float64 `json:"averageValence"`
+}
+
+const (
+	defaultPlaceRadius  = 150.0
+	defaultNearbyRadius = 500.0
+	maxNearbyRadius     = 50000.0
+	earthRadiusMeters   = 6371000.0
+	metersPerDegree     = 111320.0
+)
+
+// locationPrecisions maps the user's privacy setting to the number of
+// decimals kept from mood coordinates. "off" drops coordinates entirely.
+var locationPrecisions = map[string]int{
+	"exact":        5, // ~1 m
+	"street":       3, // ~110 m
+	"neighborhood": 2, // ~1.1 km
+	"city":         1, // ~11 km
+	"off":          -1,
+}
+
+var (
+	errPlaceNotFound      = errors.New("place not found")
+	errInvalidCoordinates = errors.New("latitude and longitude must be given together and be valid")
+)
+
+func validCoordinates(latitude, longitude float64) bool {
+	return latitude >= -90 && latitude <= 90 && longitude >= -180 && longitude <= 180
+}
+
+func coarsenCoordinate(value float64, decimals int) float64 {
+	factor := math.Pow(10, float64(decimals))
+	return math.Round(value*factor) / factor
+}
+
+// haversineMeters returns the great-circle distance between two points
+func haversineMeters(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLng := (lng2 - lng1) * toRad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(a))
+}
+
+// resolveMoodLocation validates the location part of a MoodInput. A mood
+// logged with coordinates but without a place is linked to the nearest saved
+// place covering it, unless the user turned location off. Coordinates are
+// coarsened to the user's privacy setting only after that match, so the match
+// itself stays accurate.
+func resolveMoodLocation(ctx context.Context, client *db.PrismaClient, userID int, input MoodInput) ([]db.MoodSetParam, error) {
+	if (input.Latitude == nil) != (input.Longitude == nil) {
+		return nil, errInvalidCoordinates
+	}
+	if input.Latitude != nil && !validCoordinates(*input.Latitude, *input.Longitude) {
+		return nil, errInvalidCoordinates
+	}
+
+	var params []db.MoodSetParam
+
+	if input.PlaceID != nil {
+		_, err := client.Place.FindFirst(
+			db.Place.ID.Equals(*input.PlaceID),
+			db.Place.User.Where(
+				db.User.ID.Equals(userID),
+			),
+		).Exec(ctx)
+
+		if err != nil {
+			if err == db.ErrNotFound {
+				return nil, errPlaceNotFound
+			}
+			return nil, err
+		}
+
+		params = append(params, db.Mood.Place.Link(
+			db.Place.ID.Equals(*input.PlaceID),
+		))
+	}
+
+	if input.Latitude == nil {
+		return params, nil
+	}
+
+	user, err := client.User.FindUnique(
+		db.User.ID.Equals(userID),
+	).Exec(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// With location off the coordinates are dropped, and so is the match, as
+	// a saved place would reveal the location just as well. A place the user
+	// picked themselves is kept.
+	decimals, ok := locationPrecisions[user.LocationPrecision]
+	if !ok || decimals < 0 {
+		return params, nil
+	}
+
+	if input.PlaceID == nil {
+		places, err := client.Place.FindMany(
+			db.Place.User.Where(
+				db.User.ID.Equals(userID),
+			),
+		).Exec(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		nearestID, nearestDistance := 0, math.MaxFloat64
+		for _, place := range places {
+			distance := haversineMeters(*input.Latitude, *input.Longitude, place.Latitude, place.Longitude)
+			if distance <= place.Radius && distance < nearestDistance {
+				nearestID, nearestDistance = place.ID, distance
+			}
+		}
+
+		if nearestID != 0 {
+			params = append(params, db.Mood.Place.Link(
+				db.Place.ID.Equals(nearestID),
+			))
+		}
+	}
+
+	return append(params,
+		db.Mood.Latitude.Set(coarsenCoordinate(*input.Latitude, decimals)),
+		db.Mood.Longitude.Set(coarsenCoordinate(*input.Longitude, decimals)),
+	), nil
+}
+
+func validatePlaceInput(input PlaceInput) string {
+	if strings.TrimSpace(input.Name) == "" {
+		return "Name cannot be empty"
+	}
+	if !validCoordinates(*input.Latitude, *input.Longitude) {
+		return "Invalid coordinates"
+	}
+	if input.Radius != nil && (*input.Radius <= 0 || *input.Radius > maxNearbyRadius) {
+		return "Radius must be between 0 and 50000 meters"
+	}
+	return ""
+}
+
+// GetPlaces returns the user's saved places
+func GetPlaces(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		places, err := client.Place.FindMany(
+			db.Place.User.Where(
+				db.User.ID.Equals(int(userID.(uint))),
+			),
+		).OrderBy(
+			db.Place.Name.Order(db.ASC),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch places"})
+			return
+		}
+
+		c.JSON(http.StatusOK, places)
+	}
+}
+
+// CreatePlace saves a named place such as "home" or "office"
+func CreatePlace(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input PlaceInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		if msg := validatePlaceInput(input); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		radius := defaultPlaceRadius
+		if input.Radius != nil {
+			radius = *input.Radius
+		}
+
+		place, err := client.Place.CreateOne(
+			db.Place.Name.Set(strings.TrimSpace(input.Name)),
+			db.Place.Latitude.Set(*input.Latitude),
+			db.Place.Longitude.Set(*input.Longitude),
+			db.Place.User.Link(
+				db.User.ID.Equals(int(userID.(uint))),
+			),
+			db.Place.Radius.Set(radius),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			if strings.Contains(err.Error(), "Unique constraint failed") {
+				c.JSON(http.StatusConflict, gin.H{"error": "A place with this name already exists"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create place: " + err.Error()})
+			}
+			return
+		}
+
+		c.JSON(http.StatusCreated, place)
+	}
+}
+
+// UpdatePlace renames or moves a saved place
+func UpdatePlace(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input PlaceInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		if msg := validatePlaceInput(input); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		placeID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid place ID"})
+			return
+		}
+
+		_, err = client.Place.FindFirst(
+			db.Place.ID.Equals(placeID),
+			db.Place.User.Where(
+				db.User.ID.Equals(int(userID.(uint))),
+			),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			if err == db.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Place not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch place"})
+			}
+			return
+		}
+
+		updatedPlace, err := client.Place.FindUnique(
+			db.Place.ID.Equals(placeID),
+		).Update(
+			db.Place.Name.Set(strings.TrimSpace(input.Name)),
+			db.Place.Latitude.Set(*input.Latitude),
+			db.Place.Longitude.Set(*input.Longitude),
+			db.Place.Radius.SetIfPresent(input.Radius),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			if strings.Contains(err.Error(), "Unique constraint failed") {
+				c.JSON(http.StatusConflict, gin.H{"error": "A place with this name already exists"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update place: " + err.Error()})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, updatedPlace)
+	}
+}
+
+// DeletePlace removes a saved place. Moods logged there keep their
+// coordinates and simply lose the place link.
+func DeletePlace(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		placeID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid place ID"})
+			return
+		}
+
+		_, err = client.Place.FindFirst(
+			db.Place.ID.Equals(placeID),
+			db.Place.User.Where(
+				db.User.ID.Equals(int(userID.(uint))),
+			),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			if err == db.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Place not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch place"})
+			}
+			return
+		}
+
+		_, err = client.Place.FindUnique(
+			db.Place.ID.Equals(placeID),
+		).Delete().Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete place"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Place successfully deleted"})
+	}
+}
+
+// GetNearbyMoods returns moods logged within ?radius= meters (500 by default)
+// of a saved place (?placeId=) or of a point (?lat=&lng=), nearest first
+func GetNearbyMoods(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+
+		radius := defaultNearbyRadius
+		if radiusStr := c.Query("radius"); radiusStr != "" {
+			parsed, err := strconv.ParseFloat(radiusStr, 64)
+			if err != nil || parsed <= 0 || parsed > maxNearbyRadius {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Radius must be between 0 and 50000 meters"})
+				return
+			}
+			radius = parsed
+		}
+
+		var latitude, longitude float64
+		if placeIDStr := c.Query("placeId"); placeIDStr != "" {
+			placeID, err := strconv.Atoi(placeIDStr)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid place ID"})
+				return
+			}
+
+			place, err := client.Place.FindFirst(
+				db.Place.ID.Equals(placeID),
+				db.Place.User.Where(
+					db.User.ID.Equals(userIDInt),
+				),
+			).Exec(c.Request.Context())
+
+			if err != nil {
+				if err == db.ErrNotFound {
+					c.JSON(http.StatusNotFound, gin.H{"error": "Place not found"})
+				} else {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch place"})
+				}
+				return
+			}
+			latitude, longitude = place.Latitude, place.Longitude
+		} else {
+			var latErr, lngErr error
+			latitude, latErr = strconv.ParseFloat(c.Query("lat"), 64)
+			longitude, lngErr = strconv.ParseFloat(c.Query("lng"), 64)
+			if latErr != nil || lngErr != nil || !validCoordinates(latitude, longitude) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Either placeId or valid lat and lng are required"})
+				return
+			}
+		}
+
+		// Narrow down with a bounding box in the database, then apply the
+		// exact distance check
+		latDelta := radius / metersPerDegree
+		lngDelta := radius / (metersPerDegree * math.Max(math.Cos(latitude*math.Pi/180), 0.01))
+
+		moods, err := client.Mood.FindMany(
+			db.Mood.User.Where(
+				db.User.ID.Equals(userIDInt),
+			),
+			db.Mood.And(
+				db.Mood.Latitude.Gte(latitude-latDelta),
+				db.Mood.Latitude.Lte(latitude+latDelta),
+				db.Mood.Longitude.Gte(longitude-lngDelta),
+				db.Mood.Longitude.Lte(longitude+lngDelta),
+			),
+		).With(
+			db.Mood.Tags.Fetch(),
+			db.Mood.MoodType.Fetch(),
+			db.Mood.Components.Fetch(),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch moods"})
+			return
+		}
+
+		distances := make(map[int]float64, len(moods))
+		nearby := make([]db.MoodModel, 0, len(moods))
+		for _, mood := range moods {
+			moodLat, _ := mood.Latitude()
+			moodLng, _ := mood.Longitude()
+			distance := haversineMeters(latitude, longitude, moodLat, moodLng)
+			if distance <= radius {
+				distances[mood.ID] = distance
+				nearby = append(nearby, mood)
+			}
+		}
+
+		sort.Slice(nearby, func(i, j int) bool {
+			return distances[nearby[i].ID] < distances[nearby[j].ID]
+		})
+
+		c.JSON(http.StatusOK, nearby)
+	}
+}
+
+// GetPlaceStats returns the number of moods and the average valence per
+// saved place
+func GetPlaceStats(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		var rows []struct {
+			PlaceID        db.RawInt    `json:"placeId"`
+			Name           db.RawString `json:"name"`
+			Count          db.RawBigInt `json:"count"`
+			AverageValence db.RawFloat  `json:"averageValence"`
+		}
+
+		err := client.Prisma.QueryRaw(`
+			SELECT p."id" AS placeId, p."name" AS name, COUNT(m."id") AS count, AVG(m."valence") AS averageValence
+			FROM "Place" p
+			JOIN "Mood" m ON m."placeId" = p."id" AND m."valence" IS NOT NULL
+			WHERE p."userId" = ?
+			GROUP BY p."id", p."name"
+			ORDER BY averageValence DESC
+		`, int(userID.(uint))).Exec(c.Request.Context(), &rows)
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute place stats: " + err.Error()})
+			return
+		}
+
+		stats := make([]PlaceStats, 0, len(rows))
+		for _, row := range rows {
+			stats = append(stats, PlaceStats{
+				PlaceID:        int(row.PlaceID),
+				Name:           string(row.Name),
+				Count:          int(row.Count),
+				AverageValence: float64(row.AverageValence),
+			})
+		}
+
+		c.JSON(http.StatusOK, stats)
+	}
+}
+
+// UpdateLocationPrivacy sets how precisely mood coordinates are stored
+func UpdateLocationPrivacy(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input LocationPrivacyInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		if _, ok := locationPrecisions[input.Precision]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Precision must be one of exact, street, neighborhood, city or off"})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		_, err := client.User.FindUnique(
+			db.User.ID.Equals(int(userID.(uint))),
+		).Update(
+			db.User.LocationPrecision.Set(input.Precision),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update location privacy"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"precision": input.Precision})
+	}
+}
@@ -0,0 +1,532 @@
+package handler
+
+import (
+	"api/prisma/db"
+	"api/report"
+	"api/storage"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	reportTopTags    = 5
+	reportTopFoods   = 5
+	reportNotableEnd = 2
+)
+
+var reportContentTypes = map[string]string{
+	"html": "text/html; charset=utf-8",
+	"pdf":  "application/pdf",
+}
+
+// buildReportData gathers everything shown on a report for [from, to)
+func buildReportData(ctx context.Context, client *db.PrismaClient, user *db.UserModel, period string, from, to time.Time) (report.Data, error) {
+	loc := from.Location()
+	data := report.Data{
+		Username:    user.Username,
+		Period:      period,
+		From:        from.Format("2006-01-02"),
+		To:          to.AddDate(0, 0, -1).Format("2006-01-02"),
+		Timezone:    loc.String(),
+		GeneratedAt: time.Now().In(loc),
+	}
+
+	moodRows, err := queryMoodBuckets(ctx, client, user.ID, from, to)
+	if err != nil {
+		return data, err
+	}
+
+	var total bucketTotals
+	days := make(map[string]*bucketTotals)
+	for _, row := range moodRows {
+		date := bucketTime(row.Bucket, loc).Format("2006-01-02")
+		if days[date] == nil {
+			days[date] = &bucketTotals{}
+		}
+		days[date].add(row)
+		total.add(row)
+	}
+	data.MoodCount = total.count
+	data.AverageValence = total.average()
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		data.Days = append(data.Days, report.DayValence{Date: date, Count: countOf(days[date]), AverageValence: days[date].average()})
+	}
+
+	tagCounts, err := queryTagCounts(ctx, client, user.ID, from, to)
+	if err != nil {
+		return data, err
+	}
+
+	data.TopTags, err = topTagCounts(ctx, client, user.ID, tagCounts, reportTopTags)
+	if err != nil {
+		return data, err
+	}
+
+	moods, err := client.Mood.FindMany(
+		db.Mood.User.Where(
+			db.User.ID.Equals(user.ID),
+		),
+		db.Mood.CreatedAt.Gte(from),
+		db.Mood.CreatedAt.Lt(to),
+	).Exec(ctx)
+
+	if err != nil {
+		return data, err
+	}
+
+	data.Notable = notableEntries(moods, loc, reportNotableEnd)
+
+	assessments, err := queryAssessments(ctx, client, user.ID, from, to)
+	if err != nil {
+		return data, err
+	}
+
+	data.Assessments = reportAssessments(assessments, loc)
+
+	foodLogs, err := queryFoodLogs(ctx, client, user.ID, from, to)
+	if err != nil {
+		return data, err
+	}
+
+	data.Foods, data.TotalCalories, data.AverageDailyCalories = summarizeFoodLogs(foodLogs, loc, reportTopFoods)
+
+	return data, nil
+}
+
+// notableEntries returns the perEnd best and perEnd worst rated moods, best
+// first
+func notableEntries(moods []db.MoodModel, loc *time.Location, perEnd int) []report.Entry {
+	var rated []db.MoodModel
+	for _, mood := range moods {
+		if _, ok := mood.Valence(); ok {
+			rated = append(rated, mood)
+		}
+	}
+	sort.SliceStable(rated, func(i, j int) bool {
+		a, _ := rated[i].Valence()
+		b, _ := rated[j].Valence()
+		return a > b
+	})
+
+	notable := rated
+	if len(rated) > 2*perEnd {
+		notable = append(rated[:perEnd:perEnd], rated[len(rated)-perEnd:]...)
+	}
+
+	entries := []report.Entry{}
+	for _, mood := range notable {
+		valence, _ := mood.Valence()
+		entries = append(entries, report.Entry{
+			Date:        mood.CreatedAt.In(loc).Format("2006-01-02"),
+			Emoji:       mood.Emoji,
+			Title:       mood.Title,
+			Description: mood.Description,
+			Valence:     &valence,
+		})
+	}
+	return entries
+}
+
+// summarizeFoodLogs returns the top most logged foods with the total calories
+// and the average per day with anything logged
+func summarizeFoodLogs(foodLogs []foodLogRow, loc *time.Location, top int) ([]report.FoodCount, int, float64) {
+	var totalCalories int
+	foods := make(map[int]*report.FoodCount)
+	foodDays := make(map[string]bool)
+	for _, entry := range foodLogs {
+		food := foods[int(entry.FoodID)]
+		if food == nil {
+			food = &report.FoodCount{Name: string(entry.FoodName)}
+			foods[int(entry.FoodID)] = food
+		}
+		food.Count++
+		food.Calories += int(entry.Calories)
+		totalCalories += int(entry.Calories)
+		foodDays[time.UnixMilli(int64(entry.EatenAt)).In(loc).Format("2006-01-02")] = true
+	}
+
+	counts := make([]report.FoodCount, 0, len(foods))
+	for _, food := range foods {
+		counts = append(counts, *food)
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	if len(counts) > top {
+		counts = counts[:top]
+	}
+
+	var average float64
+	if len(foodDays) > 0 {
+		average = float64(totalCalories) / float64(len(foodDays))
+	}
+	return counts, totalCalories, average
+}
+
+// topTagCounts names and ranks tag usage, keeping the top entries
+func topTagCounts(ctx context.Context, client *db.PrismaClient, userID int, rows []tagCountRow, top int) ([]report.TagCount, error) {
+	tags, err := client.Tag.FindMany(
+		db.Tag.User.Where(
+			db.User.ID.Equals(userID),
+		),
+	).Exec(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tagNames := make(map[int]string, len(tags))
+	for _, tag := range tags {
+		tagNames[tag.ID] = tag.Name
+	}
+
+	counts := []report.TagCount{}
+	for _, row := range rows {
+		counts = append(counts, report.TagCount{Name: tagNames[int(row.TagID)], Count: int(row.Count)})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	if len(counts) > top {
+		counts = counts[:top]
+	}
+	return counts, nil
+}
+
+// reportKey is the storage key a report's files share, each format adding
+// its own extension
+func reportKey(userID int, period, periodStart string) string {
+	return fmt.Sprintf("users/%d/reports/%s-%s", userID, period, periodStart)
+}
+
+// storeReport renders data as HTML and PDF, saves both in the blob store and
+// records them so later downloads are served from there
+func storeReport(ctx context.Context, client *db.PrismaClient, store storage.BlobStore, userID int, data report.Data) (*db.ReportModel, error) {
+	html, err := report.HTML(data)
+	if err != nil {
+		return nil, err
+	}
+	pdf, err := report.PDF(data)
+	if err != nil {
+		return nil, err
+	}
+
+	key := reportKey(userID, data.Period, data.From)
+	if err := store.Put(ctx, key+".html", html, reportContentTypes["html"]); err != nil {
+		return nil, err
+	}
+	if err := store.Put(ctx, key+".pdf", pdf, reportContentTypes["pdf"]); err != nil {
+		return nil, err
+	}
+
+	stored, err := client.Report.CreateOne(
+		db.Report.User.Link(
+			db.User.ID.Equals(userID),
+		),
+		db.Report.Period.Set(data.Period),
+		db.Report.PeriodStart.Set(data.From),
+		db.Report.StorageKey.Set(key),
+	).Exec(ctx)
+
+	// The scheduler and a download may generate the same report at once;
+	// the files are identical, so the first record wins
+	if err != nil && strings.Contains(err.Error(), "Unique constraint failed") {
+		return findStoredReport(ctx, client, userID, data.Period, data.From)
+	}
+	return stored, err
+}
+
+func findStoredReport(ctx context.Context, client *db.PrismaClient, userID int, period, periodStart string) (*db.ReportModel, error) {
+	return client.Report.FindFirst(
+		db.Report.User.Where(
+			db.User.ID.Equals(userID),
+		),
+		db.Report.Period.Equals(period),
+		db.Report.PeriodStart.Equals(periodStart),
+	).Exec(ctx)
+}
+
+// deleteReportBlobs removes the stored files of the given reports
+func deleteReportBlobs(ctx context.Context, store storage.BlobStore, reports []db.ReportModel) {
+	for _, stored := range reports {
+		deleteBlobs(ctx, store, stored.StorageKey+".html", stored.StorageKey+".pdf")
+	}
+}
+
+// invalidateStoredReports deletes the stored week and month reports that
+// cover any of times, so the next download renders them again from the
+// changed entries. Failures are only logged: a stale report must not fail
+// the edit.
+func invalidateStoredReports(ctx context.Context, client *db.PrismaClient, store storage.BlobStore, userID int, times ...time.Time) {
+	loc, err := userLocation(ctx, client, userID)
+	if err != nil {
+		log.Println("Reports could not be invalidated for user", userID, err)
+		return
+	}
+
+	// Several times usually fall in the same period, which is looked up once
+	seen := make(map[string]bool)
+	for _, at := range times {
+		for _, period := range []string{report.PeriodWeek, report.PeriodMonth} {
+			from, _, _ := report.Bounds(period, at.In(loc))
+			periodStart := from.Format("2006-01-02")
+			if seen[period+periodStart] {
+				continue
+			}
+			seen[period+periodStart] = true
+
+			stored, err := findStoredReport(ctx, client, userID, period, periodStart)
+			if err == db.ErrNotFound {
+				continue
+			}
+			if err == nil {
+				_, err = client.Report.FindUnique(
+					db.Report.ID.Equals(stored.ID),
+				).Delete().Exec(ctx)
+			}
+			if err != nil {
+				log.Println("Report could not be invalidated for user", userID, err)
+				continue
+			}
+			deleteReportBlobs(ctx, store, []db.ReportModel{*stored})
+		}
+	}
+}
+
+// invalidateAllStoredReports deletes every stored report of the user, for
+// changes such as tag merges that can reach any period. Failures are only
+// logged, like in invalidateStoredReports.
+func invalidateAllStoredReports(ctx context.Context, client *db.PrismaClient, store storage.BlobStore, userID int) {
+	reports, err := client.Report.FindMany(
+		db.Report.User.Where(
+			db.User.ID.Equals(userID),
+		),
+	).Exec(ctx)
+
+	if err == nil && len(reports) > 0 {
+		// Only the reports found are deleted, so no blob is left behind by a
+		// report stored in between
+		ids := make([]int, len(reports))
+		for i, stored := range reports {
+			ids[i] = stored.ID
+		}
+		_, err = client.Report.FindMany(
+			db.Report.ID.In(ids),
+		).Delete().Exec(ctx)
+	}
+	if err != nil {
+		log.Println("Reports could not be invalidated for user", userID, err)
+		return
+	}
+	deleteReportBlobs(ctx, store, reports)
+}
+
+// generateDueReports stores the last completed week and month of every user
+// that has not been generated yet
+func generateDueReports(ctx context.Context, client *db.PrismaClient, store storage.BlobStore) {
+	users, err := client.User.FindMany().Exec(ctx)
+	if err != nil {
+		log.Println("Reports could not be scheduled:", err)
+		return
+	}
+
+	for i := range users {
+		user := &users[i]
+		loc, err := time.LoadLocation(user.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+		now := time.Now().In(loc)
+
+		for _, period := range []string{report.PeriodWeek, report.PeriodMonth} {
+			current, _, _ := report.Bounds(period, now)
+			from, to, _ := report.Bounds(period, current.AddDate(0, 0, -1))
+
+			_, err := findStoredReport(ctx, client, user.ID, period, from.Format("2006-01-02"))
+			if err == nil {
+				continue
+			}
+			if err != db.ErrNotFound {
+				log.Println("Report lookup failed for user", user.ID, err)
+				continue
+			}
+
+			data, err := buildReportData(ctx, client, user, period, from, to)
+			if err != nil {
+				log.Println("Report could not be built for user", user.ID, err)
+				continue
+			}
+			if _, err := storeReport(ctx, client, store, user.ID, data); err != nil {
+				log.Println("Report could not be stored for user", user.ID, err)
+			}
+		}
+	}
+}
+
+// ScheduleReports prepares the previous week's and month's reports in the
+// background, checking every interval. It is meant to run in its own
+// goroutine for the lifetime of the server.
+func ScheduleReports(client *db.PrismaClient, store storage.BlobStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		generateDueReports(context.Background(), client, store)
+		<-ticker.C
+	}
+}
+
+func GetReports(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		reports, err := client.Report.FindMany(
+			db.Report.User.Where(
+				db.User.ID.Equals(int(userID.(uint))),
+			),
+		).OrderBy(
+			db.Report.PeriodStart.Order(db.SortOrderDesc),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reports"})
+			return
+		}
+
+		c.JSON(http.StatusOK, reports)
+	}
+}
+
+// GetReport returns the week or month report containing ?date= (YYYY-MM-DD,
+// the previous period by default) as ?format=html, pdf or json. Reports of
+// finished periods are served from storage and generated on first access if
+// the scheduler has not prepared them yet; the running period is always
+// rendered live.
+func GetReport(client *db.PrismaClient, store storage.BlobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+		period := c.Param("period")
+		format := strings.ToLower(c.DefaultQuery("format", "html"))
+		if _, ok := reportContentTypes[format]; !ok && format != "json" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Format must be html, pdf or json"})
+			return
+		}
+
+		user, err := client.User.FindUnique(
+			db.User.ID.Equals(userIDInt),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+			return
+		}
+
+		loc, err := time.LoadLocation(user.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+		now := time.Now().In(loc)
+
+		current, _, err := report.Bounds(period, now)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		date := current.AddDate(0, 0, -1)
+		if dateStr := c.Query("date"); dateStr != "" {
+			date, err = time.ParseInLocation("2006-01-02", dateStr, loc)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date, use YYYY-MM-DD"})
+				return
+			}
+		}
+
+		from, to, _ := report.Bounds(period, date)
+		if from.After(now) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Report period has not started yet"})
+			return
+		}
+		finished := !to.After(now)
+
+		if format != "json" && finished {
+			stored, err := findStoredReport(c.Request.Context(), client, userIDInt, period, from.Format("2006-01-02"))
+			if err != nil && err != db.ErrNotFound {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch report"})
+				return
+			}
+
+			if err == db.ErrNotFound {
+				data, err := buildReportData(c.Request.Context(), client, user, period, from, to)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build report: " + err.Error()})
+					return
+				}
+				stored, err = storeReport(c.Request.Context(), client, store, userIDInt, data)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store report: " + err.Error()})
+					return
+				}
+			}
+
+			reader, err := store.Open(c.Request.Context(), stored.StorageKey+"."+format)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read report"})
+				return
+			}
+			defer reader.Close()
+
+			c.DataFromReader(http.StatusOK, -1, reportContentTypes[format], reader, nil)
+			return
+		}
+
+		data, err := buildReportData(c.Request.Context(), client, user, period, from, to)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build report: " + err.Error()})
+			return
+		}
+
+		switch format {
+		case "json":
+			c.JSON(http.StatusOK, data)
+		case "pdf":
+			pdf, err := report.PDF(data)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render report"})
+				return
+			}
+			c.Data(http.StatusOK, reportContentTypes["pdf"], pdf)
+		default:
+			html, err := report.HTML(data)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render report"})
+				return
+			}
+			c.Data(http.StatusOK, reportContentTypes["html"], html)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"api/prisma/db"
+	"api/safety"
+	"context"
+	"log"
+)
+
+// safetyMessages are shown with the resources, in the language the crisis
+// language was written in
+var safetyMessages = map[string]string{
+	safety.LanguageEnglish: "It sounds like you're going through something really painful. You don't have to face it alone. If you are in danger, please call emergency services now, or reach out to one of these free, confidential lines.",
+	safety.LanguageTurkish: "Çok zor bir şeyden geçiyor gibisin. Bununla tek başına yüzleşmek zorunda değilsin. Tehlikedeysen lütfen hemen acil yardımı ara ya da bu ücretsiz ve gizli hatlardan birine ulaş.",
+}
+
+// SafetyPayload is attached to a mood whose text contains crisis language.
+// The matched phrases are left out on purpose; the client only needs to know
+// to show the resources.
+type SafetyPayload struct {
+	Severity  string                    `json:"severity"`
+	Message   string                    `json:"message"`
+	Resources []db.SupportResourceModel `json:"resources"`
+}
+
+// MoodResponse is a mood as returned after it was written, with the safety
+// payload when one applies and the wellbeing check-ins the write created
+type MoodResponse struct {
+	*db.MoodModel
+	Safety   *SafetyPayload    `json:"safety,omitempty"`
+	CheckIns []db.CheckInModel `json:"checkIns,omitempty"`
+}
+
+// moodSafety checks the text of a mood for crisis language
+func moodSafety(ctx context.Context, client *db.PrismaClient, userID int, input MoodInput) *SafetyPayload {
+	result, ok := safety.Check(input.Title + "\n" + input.Description)
+	if !ok {
+		return nil
+	}
+	return safetyPayloadFor(ctx, client, userID, result.Severity, result.Language)
+}
+
+// countryLanguage is the message language for a country, used when there is
+// no text to take the language from
+func countryLanguage(country string) string {
+	if country == "TR" {
+		return safety.LanguageTurkish
+	}
+	return safety.LanguageEnglish
+}
+
+// safetyPayloadFor builds the payload with the resources for the user's
+// country. An empty language picks the country's. It never fails: if the
+// resources can't be loaded, the message is still returned.
+func safetyPayloadFor(ctx context.Context, client *db.PrismaClient, userID int, severity, language string) *SafetyPayload {
+	settings, err := wellbeingSettingsFor(ctx, client, userID)
+	if err != nil {
+		log.Println("Wellbeing settings could not be loaded for user", userID, err)
+		settings.Country = defaultWellbeingCountry
+	}
+	if language == "" {
+		language = countryLanguage(settings.Country)
+	}
+
+	payload := &SafetyPayload{
+		Severity:  severity,
+		Message:   safetyMessages[language],
+		Resources: []db.SupportResourceModel{},
+	}
+
+	resources, err := supportResourcesFor(ctx, client, settings.Country)
+	if err != nil {
+		log.Println("Support resources could not be loaded for user", userID, err)
+		return payload
+	}
+	payload.Resources = resources
+	return payload
+}
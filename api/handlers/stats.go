@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"api/prisma/db"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// statsBucketMillis is the granularity SQL aggregates are grouped by before
+// they are folded into local days or hours. Every UTC offset in use is a
+// multiple of 15 minutes, so day boundaries stay exact in any timezone,
+// including across DST changes.
+const statsBucketMillis = 15 * 60 * 1000
+
+type moodBucketRow struct {
+	Bucket       db.RawBigInt `json:"bucket"`
+	Count        db.RawBigInt `json:"count"`
+	ValenceSum   db.RawFloat  `json:"valenceSum"`
+	ValenceCount db.RawBigInt `json:"valenceCount"`
+}
+
+type emojiBucketRow struct {
+	Bucket db.RawBigInt `json:"bucket"`
+	Emoji  db.RawString `json:"emoji"`
+	Weight db.RawFloat  `json:"weight"`
+}
+
+type CalendarDay struct {
+	Date           string   `json:"date"`
+	Count          int      `json:"count"`
+	DominantEmoji  string   `json:"dominantEmoji"`
+	DominantLabel  string   `json:"dominantLabel,omitempty"`
+	AverageValence *float64 `json:"averageValence"`
+	Color          string   `json:"color"`
+}
+
+type CalendarResponse struct {
+	Year     int           `json:"year"`
+	Timezone string        `json:"timezone"`
+	Days     []CalendarDay `json:"days"`
+}
+
+// queryMoodBuckets counts moods and sums their valence per 15-minute bucket
+// in [from, to)
+func queryMoodBuckets(ctx context.Context, client *db.PrismaClient, userID int, from, to time.Time) ([]moodBucketRow, error) {
+	var rows []moodBucketRow
+	err := client.Prisma.QueryRaw(`
+		SELECT "createdAt" / ? AS bucket,
+			COUNT(*) AS count,
+			TOTAL("valence") AS valenceSum,
+			COUNT("valence") AS valenceCount
+		FROM "Mood"
+		WHERE "userId" = ? AND "createdAt" >= ? AND "createdAt" < ?
+		GROUP BY bucket
+	`, statsBucketMillis, userID, from.UnixMilli(), to.UnixMilli()).Exec(ctx, &rows)
+	return rows, err
+}
+
+// queryEmojiBuckets sums the weight of every mood component per emoji and
+// 15-minute bucket, so mixed entries count towards each of their moods
+func queryEmojiBuckets(ctx context.Context, client *db.PrismaClient, userID int, from, to time.Time) ([]emojiBucketRow, error) {
+	var rows []emojiBucketRow
+	err := client.Prisma.QueryRaw(`
+		SELECT m."createdAt" / ? AS bucket, mc."emoji" AS emoji, TOTAL(mc."weight") AS weight
+		FROM "MoodComponent" mc
+		JOIN "Mood" m ON m."id" = mc."moodId"
+		WHERE m."userId" = ? AND m."createdAt" >= ? AND m."createdAt" < ?
+		GROUP BY bucket, mc."emoji"
+	`, statsBucketMillis, userID, from.UnixMilli(), to.UnixMilli()).Exec(ctx, &rows)
+	return rows, err
+}
+
+func bucketTime(bucket db.RawBigInt, loc *time.Location) time.Time {
+	return time.UnixMilli(int64(bucket) * statsBucketMillis).In(loc)
+}
+
+// userLocation loads the user's timezone, falling back to UTC
+func userLocation(ctx context.Context, client *db.PrismaClient, userID int) (*time.Location, error) {
+	user, err := client.User.FindUnique(
+		db.User.ID.Equals(userID),
+	).Exec(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		return time.UTC, nil
+	}
+	return loc, nil
+}
+
+// moodTypesByEmoji maps emojis to the built-in and the user's custom mood
+// types, custom ones taking precedence
+func moodTypesByEmoji(ctx context.Context, client *db.PrismaClient, userID int) (map[string]MoodTypeView, error) {
+	customTypes, err := client.MoodType.FindMany(
+		db.MoodType.User.Where(
+			db.User.ID.Equals(userID),
+		),
+	).Exec(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	types := make(map[string]MoodTypeView, len(builtinMoodTypes)+len(customTypes))
+	for _, moodType := range builtinMoodTypes {
+		types[moodType.Emoji] = moodType
+	}
+	for _, moodType := range customTypes {
+		types[moodType.Emoji] = moodTypeToView(moodType)
+	}
+	return types, nil
+}
+
+// valenceColor blends from red (-1) through yellow (0) to green (1) for
+// moods that have no color of their own
+func valenceColor(valence float64) string {
+	valence = math.Max(-1, math.Min(1, valence))
+	var r, g float64
+	if valence < 0 {
+		r, g = 255, 255*(1+valence)
+	} else {
+		r, g = 255*(1-valence), 255
+	}
+	return fmt.Sprintf("#%02X%02X%02X", int(r), int(g), 80)
+}
+
+// GetCalendarStats returns per-day aggregates for the year view, with days
+// computed in the user's timezone. Only days with entries are listed.
+func GetCalendarStats(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+
+		loc, err := userLocation(c.Request.Context(), client, userIDInt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+			return
+		}
+
+		year := time.Now().In(loc).Year()
+		if yearStr := c.Query("year"); yearStr != "" {
+			year, err = strconv.Atoi(yearStr)
+			if err != nil || year < 1970 || year > 9999 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid year"})
+				return
+			}
+		}
+
+		cacheKey := fmt.Sprintf("calendar:%d:%s", year, loc.String())
+		if cached, ok := moodStatsCache.get(userIDInt, cacheKey); ok {
+			c.JSON(http.StatusOK, cached)
+			return
+		}
+
+		from := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+		to := from.AddDate(1, 0, 0)
+
+		moodRows, err := queryMoodBuckets(c.Request.Context(), client, userIDInt, from, to)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute calendar: " + err.Error()})
+			return
+		}
+
+		emojiRows, err := queryEmojiBuckets(c.Request.Context(), client, userIDInt, from, to)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute calendar: " + err.Error()})
+			return
+		}
+
+		moodTypes, err := moodTypesByEmoji(c.Request.Context(), client, userIDInt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch mood types"})
+			return
+		}
+
+		type dayTotals struct {
+			count        int
+			valenceSum   float64
+			valenceCount int
+			weights      map[string]float64
+		}
+
+		days := make(map[string]*dayTotals)
+		dayFor := func(bucket db.RawBigInt) *dayTotals {
+			date := bucketTime(bucket, loc).Format("2006-01-02")
+			if days[date] == nil {
+				days[date] = &dayTotals{weights: make(map[string]float64)}
+			}
+			return days[date]
+		}
+
+		for _, row := range moodRows {
+			day := dayFor(row.Bucket)
+			day.count += int(row.Count)
+			day.valenceSum += float64(row.ValenceSum)
+			day.valenceCount += int(row.ValenceCount)
+		}
+		for _, row := range emojiRows {
+			dayFor(row.Bucket).weights[string(row.Emoji)] += float64(row.Weight)
+		}
+
+		calendar := CalendarResponse{Year: year, Timezone: loc.String(), Days: make([]CalendarDay, 0, len(days))}
+		for date, totals := range days {
+			day := CalendarDay{Date: date, Count: totals.count}
+
+			var dominantWeight float64
+			for emoji, weight := range totals.weights {
+				if weight > dominantWeight || (weight == dominantWeight && emoji < day.DominantEmoji) {
+					day.DominantEmoji, dominantWeight = emoji, weight
+				}
+			}
+
+			if totals.valenceCount > 0 {
+				average := totals.valenceSum / float64(totals.valenceCount)
+				day.AverageValence = &average
+				day.Color = valenceColor(average)
+			}
+
+			if moodType, ok := moodTypes[day.DominantEmoji]; ok {
+				day.DominantLabel = moodType.Label
+				day.Color = moodType.Color
+			} else if builtin, ok := findBuiltinMoodType(day.DominantEmoji); ok {
+				day.DominantLabel = builtin.Label
+				day.Color = builtin.Color
+			}
+			if day.Color == "" {
+				day.Color = "#D3D3D3"
+			}
+
+			calendar.Days = append(calendar.Days, day)
+		}
+
+		sort.Slice(calendar.Days, func(i, j int) bool {
+			return calendar.Days[i].Date < calendar.Days[j].Date
+		})
+
+		moodStatsCache.set(userIDInt, cacheKey, calendar)
+
+		c.JSON(http.StatusOK, calendar)
+	}
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"container/list"
+	"sync"
+)
+
+const (
+	// maxCachedUsers bounds how many users have statistics cached; the least
+	// recently used are evicted first
+	maxCachedUsers = 1000
+	// maxCachedPerUser bounds the entries of one user, as every range and
+	// filter gets its own key
+	maxCachedPerUser = 64
+)
+
+// statsCache keeps computed statistics per user until one of the user's
+// moods or mood types changes
+type statsCache struct {
+	mu      sync.Mutex
+	entries map[int]*list.Element
+	// recent orders the cached users from most to least recently used
+	recent *list.List
+}
+
+type userStats struct {
+	userID int
+	values map[string]interface{}
+}
+
+var moodStatsCache = newStatsCache()
+
+func newStatsCache() *statsCache {
+	return &statsCache{entries: make(map[int]*list.Element), recent: list.New()}
+}
+
+func (c *statsCache) get(userID int, key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[userID]
+	if !ok {
+		return nil, false
+	}
+	c.recent.MoveToFront(element)
+	value, ok := element.Value.(*userStats).values[key]
+	return value, ok
+}
+
+func (c *statsCache) set(userID int, key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[userID]
+	if ok {
+		c.recent.MoveToFront(element)
+	} else {
+		element = c.recent.PushFront(&userStats{userID: userID, values: make(map[string]interface{})})
+		c.entries[userID] = element
+		if c.recent.Len() > maxCachedUsers {
+			oldest := c.recent.Back()
+			c.recent.Remove(oldest)
+			delete(c.entries, oldest.Value.(*userStats).userID)
+		}
+	}
+
+	values := element.Value.(*userStats).values
+	if _, exists := values[key]; !exists && len(values) >= maxCachedPerUser {
+		// Drop an arbitrary entry to make room
+		for old := range values {
+			delete(values, old)
+			break
+		}
+	}
+	values[key] = value
+}
+
+// invalidate drops everything cached for the user. Call it after any write
+// to the user's moods or mood types.
+func (c *statsCache) invalidate(userID int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[userID]; ok {
+		c.recent.Remove(element)
+		delete(c.entries, userID)
+	}
+}
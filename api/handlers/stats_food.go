@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"api/analysis"
+	"api/prisma/db"
+	"context"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultMinSamples = 5
+
+// foodMoodLags are the windows in which a logged food is considered to have
+// preceded a mood
+var foodMoodLags = map[string]bool{
+	"same_day": true,
+	"next_3h":  true,
+	"next_day": true,
+}
+
+type valenceRow struct {
+	ID        db.RawInt    `json:"id"`
+	CreatedAt db.RawBigInt `json:"createdAt"`
+	Valence   db.RawFloat  `json:"valence"`
+}
+
+type foodLogRow struct {
+	FoodID       db.RawInt    `json:"foodId"`
+	FoodName     db.RawString `json:"foodName"`
+	CategoryID   db.RawInt    `json:"categoryId"`
+	CategoryName db.RawString `json:"categoryName"`
+	EatenAt      db.RawBigInt `json:"eatenAt"`
+	Calories     db.RawBigInt `json:"calories"`
+}
+
+type FoodMoodFinding struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	analysis.Effect
+}
+
+type FoodMoodResponse struct {
+	Lag        string                `json:"lag"`
+	From       string                `json:"from"`
+	To         string                `json:"to"`
+	Timezone   string                `json:"timezone"`
+	MinSamples int                   `json:"minSamples"`
+	MoodCount  int                   `json:"moodCount"`
+	Foods      []FoodMoodFinding     `json:"foods"`
+	Categories []FoodMoodFinding     `json:"categories"`
+	Calories   *analysis.Correlation `json:"calories"`
+	Suppressed int                   `json:"suppressed"`
+}
+
+// queryMoodValences returns the moods in [from, to) that have a valence
+func queryMoodValences(ctx context.Context, client *db.PrismaClient, userID int, from, to time.Time) ([]valenceRow, error) {
+	var rows []valenceRow
+	err := client.Prisma.QueryRaw(`
+		SELECT "id", CAST("createdAt" AS INTEGER) AS createdAt, "valence"
+		FROM "Mood"
+		WHERE "userId" = ? AND "valence" IS NOT NULL AND "createdAt" >= ? AND "createdAt" < ?
+		ORDER BY "createdAt"
+	`, userID, from.UnixMilli(), to.UnixMilli()).Exec(ctx, &rows)
+	return rows, err
+}
+
+// userFoodCaloriesSQL is the calories of UserFood uf of Food f in whole
+// calories. Weighed entries are scaled by their weight, the rest by their
+// number of servings.
+const userFoodCaloriesSQL = `COALESCE(CAST(ROUND(f."calories" * CASE
+		WHEN uf."grams" IS NOT NULL THEN uf."grams" / CASE f."nutrientBasis" WHEN '100g' THEN 100.0 ELSE f."servingGrams" END
+		WHEN f."nutrientBasis" = '100g' THEN uf."quantity" * f."servingGrams" / 100.0
+		ELSE uf."quantity"
+	END) AS INTEGER), 0)`
+
+// queryFoodLogs returns the user's food log in [from, to) with catalog data
+func queryFoodLogs(ctx context.Context, client *db.PrismaClient, userID int, from, to time.Time) ([]foodLogRow, error) {
+	var rows []foodLogRow
+	err := client.Prisma.QueryRaw(`
+		SELECT f."id" AS foodId, f."name" AS foodName, c."id" AS categoryId, c."name" AS categoryName,
+			CAST(uf."eatenAt" AS INTEGER) AS eatenAt, `+userFoodCaloriesSQL+` AS calories
+		FROM "UserFood" uf
+		JOIN "Food" f ON f."id" = uf."foodId"
+		JOIN "Category" c ON c."id" = f."categoryId"
+		WHERE uf."userId" = ? AND uf."eatenAt" >= ? AND uf."eatenAt" < ?
+		ORDER BY uf."eatenAt"
+	`, userID, from.UnixMilli(), to.UnixMilli()).Exec(ctx, &rows)
+	return rows, err
+}
+
+// foodPrecedesMood reports whether a food eaten at eatenAt falls in the lag
+// window before a mood logged at moodAt
+func foodPrecedesMood(lag string, eatenAt, moodAt time.Time) bool {
+	switch lag {
+	case "next_3h":
+		return !eatenAt.After(moodAt) && moodAt.Sub(eatenAt) <= 3*time.Hour
+	case "next_day":
+		previous := moodAt.AddDate(0, 0, -1)
+		return eatenAt.Year() == previous.Year() && eatenAt.YearDay() == previous.YearDay()
+	default:
+		return !eatenAt.After(moodAt) && eatenAt.Year() == moodAt.Year() && eatenAt.YearDay() == moodAt.YearDay()
+	}
+}
+
+// findingsFor compares valence of moods exposed to each key with all other
+// moods, dropping keys without enough samples on either side
+func findingsFor(exposures []map[int]bool, valences []float64, names map[int]string, minSamples int) ([]FoodMoodFinding, int) {
+	findings := []FoodMoodFinding{}
+	suppressed := 0
+	for id, name := range names {
+		var with, without []float64
+		for i, exposed := range exposures {
+			if exposed[id] {
+				with = append(with, valences[i])
+			} else {
+				without = append(without, valences[i])
+			}
+		}
+
+		effect, ok := analysis.Compare(with, without, minSamples)
+		if !ok {
+			suppressed++
+			continue
+		}
+		findings = append(findings, FoodMoodFinding{ID: id, Name: name, Effect: effect})
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		return math.Abs(findings[i].EffectSize) > math.Abs(findings[j].EffectSize)
+	})
+	return findings, suppressed
+}
+
+// GetFoodMoodStats correlates foods, food categories and calorie totals with
+// the valence of moods logged after them. ?lag= selects the window
+// (same_day, next_3h or next_day) and ?minSamples= the minimum number of
+// moods required on each side before a finding is reported.
+func GetFoodMoodStats(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+
+		lag := c.DefaultQuery("lag", "same_day")
+		if !foodMoodLags[lag] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Lag must be one of same_day, next_3h or next_day"})
+			return
+		}
+
+		minSamples := defaultMinSamples
+		if minSamplesStr := c.Query("minSamples"); minSamplesStr != "" {
+			parsed, err := strconv.Atoi(minSamplesStr)
+			if err != nil || parsed < 3 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "minSamples must be a number of at least 3"})
+				return
+			}
+			minSamples = parsed
+		}
+
+		loc, err := userLocation(c.Request.Context(), client, userIDInt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+			return
+		}
+
+		from, to, err := parseStatsRange(c, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid range: " + err.Error()})
+			return
+		}
+
+		moods, err := queryMoodValences(c.Request.Context(), client, userIDInt, from, to)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch moods: " + err.Error()})
+			return
+		}
+
+		// Foods from the day before the range can still precede its first moods
+		foods, err := queryFoodLogs(c.Request.Context(), client, userIDInt, from.AddDate(0, 0, -1), to)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch food logs: " + err.Error()})
+			return
+		}
+
+		foodNames := make(map[int]string)
+		categoryNames := make(map[int]string)
+		valences := make([]float64, len(moods))
+		foodExposures := make([]map[int]bool, len(moods))
+		categoryExposures := make([]map[int]bool, len(moods))
+		var calorieTotals, calorieValences []float64
+
+		for i, mood := range moods {
+			moodAt := time.UnixMilli(int64(mood.CreatedAt)).In(loc)
+			valences[i] = float64(mood.Valence)
+			foodExposures[i] = make(map[int]bool)
+			categoryExposures[i] = make(map[int]bool)
+
+			var calories float64
+			for _, food := range foods {
+				eatenAt := time.UnixMilli(int64(food.EatenAt)).In(loc)
+				if !foodPrecedesMood(lag, eatenAt, moodAt) {
+					continue
+				}
+				foodExposures[i][int(food.FoodID)] = true
+				categoryExposures[i][int(food.CategoryID)] = true
+				foodNames[int(food.FoodID)] = string(food.FoodName)
+				categoryNames[int(food.CategoryID)] = string(food.CategoryName)
+				calories += float64(food.Calories)
+			}
+
+			// Zero usually means nothing was logged rather than a fast, so
+			// those moods are left out of the calorie correlation
+			if calories > 0 {
+				calorieTotals = append(calorieTotals, calories)
+				calorieValences = append(calorieValences, valences[i])
+			}
+		}
+
+		response := FoodMoodResponse{
+			Lag:        lag,
+			From:       from.Format("2006-01-02"),
+			To:         to.AddDate(0, 0, -1).Format("2006-01-02"),
+			Timezone:   loc.String(),
+			MinSamples: minSamples,
+			MoodCount:  len(moods),
+		}
+
+		var suppressedFoods, suppressedCategories int
+		response.Foods, suppressedFoods = findingsFor(foodExposures, valences, foodNames, minSamples)
+		response.Categories, suppressedCategories = findingsFor(categoryExposures, valences, categoryNames, minSamples)
+		response.Suppressed = suppressedFoods + suppressedCategories
+
+		if correlation, ok := analysis.Pearson(calorieTotals, calorieValences, minSamples); ok {
+			response.Calories = &correlation
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
@@ -0,0 +1,296 @@
+package handler
+
+import (
+	"api/prisma/db"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultStatsDays = 30
+	maxStatsDays     = 3 * 366
+)
+
+type MoodShare struct {
+	Emoji  string  `json:"emoji"`
+	Label  string  `json:"label,omitempty"`
+	Color  string  `json:"color,omitempty"`
+	Weight float64 `json:"weight"`
+	Share  float64 `json:"share"`
+}
+
+type MoodBucketStats struct {
+	Key            string   `json:"key"`
+	Count          int      `json:"count"`
+	AverageValence *float64 `json:"averageValence"`
+}
+
+type MoodPeriodStats struct {
+	From           string            `json:"from"`
+	To             string            `json:"to"`
+	Count          int               `json:"count"`
+	AverageValence *float64          `json:"averageValence"`
+	Distribution   []MoodShare       `json:"distribution"`
+	Weekdays       []MoodBucketStats `json:"weekdays"`
+	Hours          []MoodBucketStats `json:"hours"`
+	Weekly         []MoodBucketStats `json:"weekly"`
+	Monthly        []MoodBucketStats `json:"monthly"`
+}
+
+type MoodStatsChange struct {
+	Count          int      `json:"count"`
+	AverageValence *float64 `json:"averageValence"`
+}
+
+type MoodStatsResponse struct {
+	Timezone string          `json:"timezone"`
+	Current  MoodPeriodStats `json:"current"`
+	Previous MoodPeriodStats `json:"previous"`
+	Change   MoodStatsChange `json:"change"`
+}
+
+// bucketTotals accumulates mood counts and valence for one stats bucket
+type bucketTotals struct {
+	count        int
+	valenceSum   float64
+	valenceCount int
+}
+
+func (t *bucketTotals) add(row moodBucketRow) {
+	t.count += int(row.Count)
+	t.valenceSum += float64(row.ValenceSum)
+	t.valenceCount += int(row.ValenceCount)
+}
+
+func (t *bucketTotals) average() *float64 {
+	if t == nil || t.valenceCount == 0 {
+		return nil
+	}
+	average := t.valenceSum / float64(t.valenceCount)
+	return &average
+}
+
+// parseStatsRange reads ?from= and ?to= (YYYY-MM-DD, both inclusive) as local
+// dates. Without them the last 30 days up to today are used.
+func parseStatsRange(c *gin.Context, loc *time.Location) (time.Time, time.Time, error) {
+	now := time.Now().In(loc)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1)
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", toStr, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to date, use YYYY-MM-DD")
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+
+	from := to.AddDate(0, 0, -defaultStatsDays)
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", fromStr, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from date, use YYYY-MM-DD")
+		}
+		from = parsed
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must not be after to")
+	}
+	if to.Sub(from) > maxStatsDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("range must not exceed %d days", maxStatsDays)
+	}
+	return from, to, nil
+}
+
+// weekStart returns local midnight of the Monday starting t's week
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+}
+
+// computeMoodPeriodStats aggregates moods in [from, to) in SQL down to
+// 15-minute buckets, then folds the buckets into local weekdays, hours,
+// weeks and months
+func computeMoodPeriodStats(ctx context.Context, client *db.PrismaClient, userID int, from, to time.Time, moodTypes map[string]MoodTypeView) (MoodPeriodStats, error) {
+	stats := MoodPeriodStats{
+		From: from.Format("2006-01-02"),
+		To:   to.AddDate(0, 0, -1).Format("2006-01-02"),
+	}
+
+	moodRows, err := queryMoodBuckets(ctx, client, userID, from, to)
+	if err != nil {
+		return stats, err
+	}
+
+	emojiRows, err := queryEmojiBuckets(ctx, client, userID, from, to)
+	if err != nil {
+		return stats, err
+	}
+
+	var total bucketTotals
+	var weekdays [7]bucketTotals
+	var hours [24]bucketTotals
+	weekly := make(map[string]*bucketTotals)
+	monthly := make(map[string]*bucketTotals)
+
+	for _, row := range moodRows {
+		t := bucketTime(row.Bucket, from.Location())
+		total.add(row)
+		weekdays[(int(t.Weekday())+6)%7].add(row)
+		hours[t.Hour()].add(row)
+
+		weekKey := weekStart(t).Format("2006-01-02")
+		if weekly[weekKey] == nil {
+			weekly[weekKey] = &bucketTotals{}
+		}
+		weekly[weekKey].add(row)
+
+		monthKey := t.Format("2006-01")
+		if monthly[monthKey] == nil {
+			monthly[monthKey] = &bucketTotals{}
+		}
+		monthly[monthKey].add(row)
+	}
+
+	stats.Count = total.count
+	stats.AverageValence = total.average()
+
+	// Mixed entries contribute to each of their moods by weight
+	weights := make(map[string]float64)
+	var totalWeight float64
+	for _, row := range emojiRows {
+		weights[string(row.Emoji)] += float64(row.Weight)
+		totalWeight += float64(row.Weight)
+	}
+
+	stats.Distribution = make([]MoodShare, 0, len(weights))
+	for emoji, weight := range weights {
+		share := MoodShare{Emoji: emoji, Weight: weight, Share: weight / totalWeight}
+		if moodType, ok := moodTypes[emoji]; ok {
+			share.Label, share.Color = moodType.Label, moodType.Color
+		} else if builtin, ok := findBuiltinMoodType(emoji); ok {
+			share.Label, share.Color = builtin.Label, builtin.Color
+		}
+		stats.Distribution = append(stats.Distribution, share)
+	}
+	sort.Slice(stats.Distribution, func(i, j int) bool {
+		if stats.Distribution[i].Weight != stats.Distribution[j].Weight {
+			return stats.Distribution[i].Weight > stats.Distribution[j].Weight
+		}
+		return stats.Distribution[i].Emoji < stats.Distribution[j].Emoji
+	})
+
+	weekdayNames := []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+	for i := range weekdays {
+		stats.Weekdays = append(stats.Weekdays, MoodBucketStats{Key: weekdayNames[i], Count: weekdays[i].count, AverageValence: weekdays[i].average()})
+	}
+	for hour := range hours {
+		stats.Hours = append(stats.Hours, MoodBucketStats{Key: fmt.Sprintf("%02d", hour), Count: hours[hour].count, AverageValence: hours[hour].average()})
+	}
+
+	// Trend lines list every week and month of the range, including empty ones
+	for week := weekStart(from); week.Before(to); week = week.AddDate(0, 0, 7) {
+		key := week.Format("2006-01-02")
+		stats.Weekly = append(stats.Weekly, MoodBucketStats{Key: key, Count: countOf(weekly[key]), AverageValence: weekly[key].average()})
+	}
+	for month := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, from.Location()); month.Before(to); month = month.AddDate(0, 1, 0) {
+		key := month.Format("2006-01")
+		stats.Monthly = append(stats.Monthly, MoodBucketStats{Key: key, Count: countOf(monthly[key]), AverageValence: monthly[key].average()})
+	}
+
+	return stats, nil
+}
+
+func countOf(totals *bucketTotals) int {
+	if totals == nil {
+		return 0
+	}
+	return totals.count
+}
+
+// GetMoodStats returns mood statistics for a range together with the
+// previous range of the same length. section narrows the response to one
+// part ("distribution", "weekdays", "hours" or "trend"); an empty section
+// returns everything.
+func GetMoodStats(client *db.PrismaClient, section string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+
+		loc, err := userLocation(c.Request.Context(), client, userIDInt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+			return
+		}
+
+		from, to, err := parseStatsRange(c, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid range: " + err.Error()})
+			return
+		}
+
+		cacheKey := fmt.Sprintf("moods:%d:%d:%s", from.Unix(), to.Unix(), loc.String())
+		cached, ok := moodStatsCache.get(userIDInt, cacheKey)
+		if !ok {
+			moodTypes, err := moodTypesByEmoji(c.Request.Context(), client, userIDInt)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch mood types"})
+				return
+			}
+
+			current, err := computeMoodPeriodStats(c.Request.Context(), client, userIDInt, from, to, moodTypes)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute stats: " + err.Error()})
+				return
+			}
+
+			days := int(to.Sub(from).Hours()/24 + 0.5)
+			previous, err := computeMoodPeriodStats(c.Request.Context(), client, userIDInt, from.AddDate(0, 0, -days), from, moodTypes)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute stats: " + err.Error()})
+				return
+			}
+
+			response := MoodStatsResponse{
+				Timezone: loc.String(),
+				Current:  current,
+				Previous: previous,
+				Change:   MoodStatsChange{Count: current.Count - previous.Count},
+			}
+			if current.AverageValence != nil && previous.AverageValence != nil {
+				delta := *current.AverageValence - *previous.AverageValence
+				response.Change.AverageValence = &delta
+			}
+
+			moodStatsCache.set(userIDInt, cacheKey, response)
+			cached = response
+		}
+
+		response := cached.(MoodStatsResponse)
+		switch section {
+		case "distribution":
+			c.JSON(http.StatusOK, gin.H{"timezone": response.Timezone, "current": response.Current.Distribution, "previous": response.Previous.Distribution})
+		case "weekdays":
+			c.JSON(http.StatusOK, gin.H{"timezone": response.Timezone, "current": response.Current.Weekdays, "previous": response.Previous.Weekdays})
+		case "hours":
+			c.JSON(http.StatusOK, gin.H{"timezone": response.Timezone, "current": response.Current.Hours, "previous": response.Previous.Hours})
+		case "trend":
+			if c.DefaultQuery("interval", "week") == "month" {
+				c.JSON(http.StatusOK, gin.H{"timezone": response.Timezone, "interval": "month", "current": response.Current.Monthly, "previous": response.Previous.Monthly})
+			} else {
+				c.JSON(http.StatusOK, gin.H{"timezone": response.Timezone, "interval": "week", "current": response.Current.Weekly, "previous": response.Previous.Weekly})
+			}
+		default:
+			c.JSON(http.StatusOK, response)
+		}
+	}
+}
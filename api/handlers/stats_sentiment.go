@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"api/analysis"
+	"api/prisma/db"
+	"context"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// mismatchThreshold is how far apart, on the -1..1 scale, the chosen
+	// mood and the text have to be for an entry to be listed as a mismatch
+	mismatchThreshold = 1.0
+	maxMismatches     = 10
+)
+
+type sentimentRow struct {
+	ID        db.RawInt    `json:"id"`
+	Title     db.RawString `json:"title"`
+	Emoji     db.RawString `json:"emoji"`
+	CreatedAt db.RawBigInt `json:"createdAt"`
+	Valence   db.RawFloat  `json:"valence"`
+	Sentiment db.RawFloat  `json:"sentiment"`
+}
+
+type SentimentMismatch struct {
+	MoodID      int     `json:"moodId"`
+	Date        string  `json:"date"`
+	Title       string  `json:"title"`
+	Emoji       string  `json:"emoji"`
+	Valence     float64 `json:"valence"`
+	Sentiment   float64 `json:"sentiment"`
+	Discrepancy float64 `json:"discrepancy"`
+}
+
+type SentimentStats struct {
+	From                    string                `json:"from"`
+	To                      string                `json:"to"`
+	Timezone                string                `json:"timezone"`
+	Count                   int                   `json:"count"`
+	AverageValence          *float64              `json:"averageValence"`
+	AverageSentiment        *float64              `json:"averageSentiment"`
+	MeanDiscrepancy         *float64              `json:"meanDiscrepancy"`
+	MeanAbsoluteDiscrepancy *float64              `json:"meanAbsoluteDiscrepancy"`
+	Agreement               *analysis.Correlation `json:"agreement"`
+	Mismatches              []SentimentMismatch   `json:"mismatches"`
+}
+
+// querySentiments returns the moods in [from, to) that have both a valence
+// and a text sentiment
+func querySentiments(ctx context.Context, client *db.PrismaClient, userID int, from, to time.Time) ([]sentimentRow, error) {
+	var rows []sentimentRow
+	err := client.Prisma.QueryRaw(`
+		SELECT "id", "title", "emoji", CAST("createdAt" AS INTEGER) AS createdAt, "valence", "sentiment"
+		FROM "Mood"
+		WHERE "userId" = ? AND "valence" IS NOT NULL AND "sentiment" IS NOT NULL
+			AND "createdAt" >= ? AND "createdAt" < ?
+		ORDER BY "createdAt"
+	`, userID, from.UnixMilli(), to.UnixMilli()).Exec(ctx, &rows)
+	return rows, err
+}
+
+// GetSentimentStats compares the mood users picked with the sentiment of
+// what they wrote. A positive discrepancy means the chosen mood was brighter
+// than the text.
+func GetSentimentStats(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+
+		loc, err := userLocation(c.Request.Context(), client, userIDInt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+			return
+		}
+
+		from, to, err := parseStatsRange(c, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid range: " + err.Error()})
+			return
+		}
+
+		rows, err := querySentiments(c.Request.Context(), client, userIDInt, from, to)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute sentiment stats: " + err.Error()})
+			return
+		}
+
+		stats := SentimentStats{
+			From:       from.Format("2006-01-02"),
+			To:         to.AddDate(0, 0, -1).Format("2006-01-02"),
+			Timezone:   loc.String(),
+			Count:      len(rows),
+			Mismatches: []SentimentMismatch{},
+		}
+
+		valences := make([]float64, len(rows))
+		sentiments := make([]float64, len(rows))
+		var valenceSum, sentimentSum, discrepancySum, absoluteSum float64
+		for i, row := range rows {
+			valences[i], sentiments[i] = float64(row.Valence), float64(row.Sentiment)
+			discrepancy := valences[i] - sentiments[i]
+			valenceSum += valences[i]
+			sentimentSum += sentiments[i]
+			discrepancySum += discrepancy
+			absoluteSum += math.Abs(discrepancy)
+
+			if math.Abs(discrepancy) >= mismatchThreshold {
+				stats.Mismatches = append(stats.Mismatches, SentimentMismatch{
+					MoodID:      int(row.ID),
+					Date:        time.UnixMilli(int64(row.CreatedAt)).In(loc).Format("2006-01-02"),
+					Title:       string(row.Title),
+					Emoji:       string(row.Emoji),
+					Valence:     valences[i],
+					Sentiment:   sentiments[i],
+					Discrepancy: discrepancy,
+				})
+			}
+		}
+
+		if n := float64(len(rows)); n > 0 {
+			averageValence, averageSentiment := valenceSum/n, sentimentSum/n
+			meanDiscrepancy, meanAbsolute := discrepancySum/n, absoluteSum/n
+			stats.AverageValence, stats.AverageSentiment = &averageValence, &averageSentiment
+			stats.MeanDiscrepancy, stats.MeanAbsoluteDiscrepancy = &meanDiscrepancy, &meanAbsolute
+		}
+
+		if correlation, ok := analysis.Pearson(valences, sentiments, defaultMinSamples); ok {
+			stats.Agreement = &correlation
+		}
+
+		sort.Slice(stats.Mismatches, func(i, j int) bool {
+			return math.Abs(stats.Mismatches[i].Discrepancy) > math.Abs(stats.Mismatches[j].Discrepancy)
+		})
+		if len(stats.Mismatches) > maxMismatches {
+			stats.Mismatches = stats.Mismatches[:maxMismatches]
+		}
+
+		c.JSON(http.StatusOK, stats)
+	}
+}
@@ -0,0 +1,373 @@
+package handler
+
+import (
+	"api/analysis"
+	"api/prisma/db"
+	"context"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type moodTagRow struct {
+	MoodID  db.RawInt   `json:"moodId"`
+	Valence db.RawFloat `json:"valence"`
+	TagID   db.RawInt   `json:"tagId"`
+}
+
+type tagPairRow struct {
+	TagA  db.RawInt    `json:"tagA"`
+	TagB  db.RawInt    `json:"tagB"`
+	Count db.RawBigInt `json:"count"`
+}
+
+type tagCountRow struct {
+	TagID db.RawInt    `json:"tagId"`
+	Count db.RawBigInt `json:"count"`
+}
+
+type moodCountRow struct {
+	Count db.RawBigInt `json:"count"`
+}
+
+type tagFoodRow struct {
+	TagID    db.RawInt    `json:"tagId"`
+	Entries  db.RawBigInt `json:"entries"`
+	Calories db.RawBigInt `json:"calories"`
+}
+
+type TagCoOccurrence struct {
+	TagA    TagRef  `json:"tagA"`
+	TagB    TagRef  `json:"tagB"`
+	Count   int     `json:"count"`
+	Jaccard float64 `json:"jaccard"`
+	Lift    float64 `json:"lift"`
+}
+
+type TagRef struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type TagImpact struct {
+	Tag TagRef `json:"tag"`
+	analysis.Effect
+}
+
+type TagTrend struct {
+	Tag      TagRef   `json:"tag"`
+	Current  int      `json:"current"`
+	Previous int      `json:"previous"`
+	Change   int      `json:"change"`
+	Growth   *float64 `json:"growth"`
+}
+
+// TagFoodUsage is how often a tag was put on food entries and the calories
+// those entries add up to
+type TagFoodUsage struct {
+	Tag      TagRef `json:"tag"`
+	Entries  int    `json:"entries"`
+	Calories int    `json:"calories"`
+}
+
+type TagStatsResponse struct {
+	From         string            `json:"from"`
+	To           string            `json:"to"`
+	Timezone     string            `json:"timezone"`
+	MoodCount    int               `json:"moodCount"`
+	CoOccurrence []TagCoOccurrence `json:"coOccurrence"`
+	Impact       []TagImpact       `json:"impact"`
+	Trending     []TagTrend        `json:"trending"`
+	Foods        []TagFoodUsage    `json:"foods"`
+	Suppressed   int               `json:"suppressed"`
+}
+
+// queryMoodTags returns one row per mood and linked tag in [from, to), and
+// a row with tag 0 for moods without tags
+func queryMoodTags(ctx context.Context, client *db.PrismaClient, userID int, from, to time.Time) ([]moodTagRow, error) {
+	var rows []moodTagRow
+	err := client.Prisma.QueryRaw(`
+		SELECT m."id" AS moodId, m."valence" AS valence, COALESCE(mt."B", 0) AS tagId
+		FROM "Mood" m
+		LEFT JOIN "_MoodToTag" mt ON mt."A" = m."id"
+		WHERE m."userId" = ? AND m."valence" IS NOT NULL AND m."createdAt" >= ? AND m."createdAt" < ?
+	`, userID, from.UnixMilli(), to.UnixMilli()).Exec(ctx, &rows)
+	return rows, err
+}
+
+// queryTagPairs counts how often two tags are attached to the same mood
+func queryTagPairs(ctx context.Context, client *db.PrismaClient, userID int, from, to time.Time) ([]tagPairRow, error) {
+	var rows []tagPairRow
+	err := client.Prisma.QueryRaw(`
+		SELECT a."B" AS tagA, b."B" AS tagB, COUNT(*) AS count
+		FROM "_MoodToTag" a
+		JOIN "_MoodToTag" b ON b."A" = a."A" AND a."B" < b."B"
+		JOIN "Mood" m ON m."id" = a."A"
+		WHERE m."userId" = ? AND m."createdAt" >= ? AND m."createdAt" < ?
+		GROUP BY a."B", b."B"
+		ORDER BY count DESC
+	`, userID, from.UnixMilli(), to.UnixMilli()).Exec(ctx, &rows)
+	return rows, err
+}
+
+// queryTagCounts counts moods per tag in [from, to)
+func queryTagCounts(ctx context.Context, client *db.PrismaClient, userID int, from, to time.Time) ([]tagCountRow, error) {
+	var rows []tagCountRow
+	err := client.Prisma.QueryRaw(`
+		SELECT mt."B" AS tagId, COUNT(*) AS count
+		FROM "_MoodToTag" mt
+		JOIN "Mood" m ON m."id" = mt."A"
+		WHERE m."userId" = ? AND m."createdAt" >= ? AND m."createdAt" < ?
+		GROUP BY mt."B"
+	`, userID, from.UnixMilli(), to.UnixMilli()).Exec(ctx, &rows)
+	return rows, err
+}
+
+// queryMoodCount counts all moods in [from, to), the population the tag pair
+// and tag counts are taken from
+func queryMoodCount(ctx context.Context, client *db.PrismaClient, userID int, from, to time.Time) (int, error) {
+	var rows []moodCountRow
+	err := client.Prisma.QueryRaw(`
+		SELECT COUNT(*) AS count
+		FROM "Mood" m
+		WHERE m."userId" = ? AND m."createdAt" >= ? AND m."createdAt" < ?
+	`, userID, from.UnixMilli(), to.UnixMilli()).Exec(ctx, &rows)
+	if err != nil || len(rows) == 0 {
+		return 0, err
+	}
+	return int(rows[0].Count), nil
+}
+
+// queryTagFoods counts food entries per tag in [from, to) and sums their
+// calories
+func queryTagFoods(ctx context.Context, client *db.PrismaClient, userID int, from, to time.Time) ([]tagFoodRow, error) {
+	var rows []tagFoodRow
+	err := client.Prisma.QueryRaw(`
+		SELECT tf."A" AS tagId, COUNT(*) AS entries, SUM(`+userFoodCaloriesSQL+`) AS calories
+		FROM "_TagToUserFood" tf
+		JOIN "UserFood" uf ON uf."id" = tf."B"
+		JOIN "Food" f ON f."id" = uf."foodId"
+		WHERE uf."userId" = ? AND uf."eatenAt" >= ? AND uf."eatenAt" < ?
+		GROUP BY tf."A"
+		ORDER BY entries DESC
+	`, userID, from.UnixMilli(), to.UnixMilli()).Exec(ctx, &rows)
+	return rows, err
+}
+
+// GetTagStats returns tag co-occurrence, the average valence of moods with
+// versus without each tag, tags trending compared to the previous period of
+// the same length and how tags are used on food entries. section narrows the
+// response like GetMoodStats.
+func GetTagStats(client *db.PrismaClient, section string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+
+		minSamples := defaultMinSamples
+		if minSamplesStr := c.Query("minSamples"); minSamplesStr != "" {
+			parsed, err := strconv.Atoi(minSamplesStr)
+			if err != nil || parsed < 3 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "minSamples must be a number of at least 3"})
+				return
+			}
+			minSamples = parsed
+		}
+
+		loc, err := userLocation(c.Request.Context(), client, userIDInt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+			return
+		}
+
+		from, to, err := parseStatsRange(c, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid range: " + err.Error()})
+			return
+		}
+		days := int(to.Sub(from).Hours()/24 + 0.5)
+		previousFrom := from.AddDate(0, 0, -days)
+
+		tags, err := client.Tag.FindMany(
+			db.Tag.User.Where(
+				db.User.ID.Equals(userIDInt),
+			),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+			return
+		}
+
+		tagNames := make(map[int]string, len(tags))
+		for _, tag := range tags {
+			tagNames[tag.ID] = tag.Name
+		}
+
+		moodTags, err := queryMoodTags(c.Request.Context(), client, userIDInt, from, to)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute tag stats: " + err.Error()})
+			return
+		}
+
+		pairs, err := queryTagPairs(c.Request.Context(), client, userIDInt, from, to)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute tag stats: " + err.Error()})
+			return
+		}
+
+		currentCounts, err := queryTagCounts(c.Request.Context(), client, userIDInt, from, to)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute tag stats: " + err.Error()})
+			return
+		}
+
+		previousCounts, err := queryTagCounts(c.Request.Context(), client, userIDInt, previousFrom, from)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute tag stats: " + err.Error()})
+			return
+		}
+
+		moodCount, err := queryMoodCount(c.Request.Context(), client, userIDInt, from, to)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute tag stats: " + err.Error()})
+			return
+		}
+
+		foods, err := queryTagFoods(c.Request.Context(), client, userIDInt, from, to)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute tag stats: " + err.Error()})
+			return
+		}
+
+		// Collapse the joined rows into one entry per mood
+		moodValences := make(map[int]float64)
+		moodTagSets := make(map[int]map[int]bool)
+		for _, row := range moodTags {
+			moodID := int(row.MoodID)
+			moodValences[moodID] = float64(row.Valence)
+			if moodTagSets[moodID] == nil {
+				moodTagSets[moodID] = make(map[int]bool)
+			}
+			if row.TagID != 0 {
+				moodTagSets[moodID][int(row.TagID)] = true
+			}
+		}
+
+		response := TagStatsResponse{
+			From:         from.Format("2006-01-02"),
+			To:           to.AddDate(0, 0, -1).Format("2006-01-02"),
+			Timezone:     loc.String(),
+			MoodCount:    len(moodValences),
+			CoOccurrence: []TagCoOccurrence{},
+			Impact:       []TagImpact{},
+			Trending:     []TagTrend{},
+			Foods:        []TagFoodUsage{},
+		}
+
+		current := make(map[int]int, len(currentCounts))
+		for _, row := range currentCounts {
+			current[int(row.TagID)] = int(row.Count)
+		}
+		previous := make(map[int]int, len(previousCounts))
+		for _, row := range previousCounts {
+			previous[int(row.TagID)] = int(row.Count)
+		}
+
+		// Pairs and per-tag counts include moods without a valence, so lift
+		// is measured against every mood in the range rather than just the
+		// ones used for impact
+		totalMoods := float64(moodCount)
+		for _, pair := range pairs {
+			a, b, count := int(pair.TagA), int(pair.TagB), int(pair.Count)
+			coOccurrence := TagCoOccurrence{
+				TagA:  TagRef{ID: a, Name: tagNames[a]},
+				TagB:  TagRef{ID: b, Name: tagNames[b]},
+				Count: count,
+			}
+			if union := current[a] + current[b] - count; union > 0 {
+				coOccurrence.Jaccard = float64(count) / float64(union)
+			}
+			if current[a] > 0 && current[b] > 0 && totalMoods > 0 {
+				coOccurrence.Lift = float64(count) * totalMoods / float64(current[a]*current[b])
+			}
+			response.CoOccurrence = append(response.CoOccurrence, coOccurrence)
+		}
+
+		for tagID, name := range tagNames {
+			var with, without []float64
+			for moodID, tagSet := range moodTagSets {
+				if tagSet[tagID] {
+					with = append(with, moodValences[moodID])
+				} else {
+					without = append(without, moodValences[moodID])
+				}
+			}
+
+			effect, ok := analysis.Compare(with, without, minSamples)
+			if !ok {
+				if len(with) > 0 {
+					response.Suppressed++
+				}
+				continue
+			}
+			response.Impact = append(response.Impact, TagImpact{Tag: TagRef{ID: tagID, Name: name}, Effect: effect})
+		}
+		sort.Slice(response.Impact, func(i, j int) bool {
+			return math.Abs(response.Impact[i].EffectSize) > math.Abs(response.Impact[j].EffectSize)
+		})
+
+		for tagID, name := range tagNames {
+			if current[tagID] == 0 && previous[tagID] == 0 {
+				continue
+			}
+			trend := TagTrend{
+				Tag:      TagRef{ID: tagID, Name: name},
+				Current:  current[tagID],
+				Previous: previous[tagID],
+				Change:   current[tagID] - previous[tagID],
+			}
+			if previous[tagID] > 0 {
+				growth := float64(trend.Change) / float64(previous[tagID])
+				trend.Growth = &growth
+			}
+			response.Trending = append(response.Trending, trend)
+		}
+		sort.Slice(response.Trending, func(i, j int) bool {
+			if response.Trending[i].Change != response.Trending[j].Change {
+				return response.Trending[i].Change > response.Trending[j].Change
+			}
+			return response.Trending[i].Tag.Name < response.Trending[j].Tag.Name
+		})
+
+		for _, row := range foods {
+			tagID := int(row.TagID)
+			response.Foods = append(response.Foods, TagFoodUsage{
+				Tag:      TagRef{ID: tagID, Name: tagNames[tagID]},
+				Entries:  int(row.Entries),
+				Calories: int(row.Calories),
+			})
+		}
+
+		switch section {
+		case "co-occurrence":
+			c.JSON(http.StatusOK, response.CoOccurrence)
+		case "impact":
+			c.JSON(http.StatusOK, response.Impact)
+		case "trending":
+			c.JSON(http.StatusOK, response.Trending)
+		case "foods":
+			c.JSON(http.StatusOK, response.Foods)
+		default:
+			c.JSON(http.StatusOK, response)
+		}
+	}
+}
@@ -0,0 +1,458 @@
+package handler
+
+import (
+	"api/prisma/db"
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/steebchen/prisma-client-go/runtime/transaction"
+)
+
+// maxStreakFreezeDays limits how many days a single request can mark as
+// vacation
+const maxStreakFreezeDays = 31
+
+type StreakFreezeInput struct {
+	From   string  `json:"from" binding:"required"`
+	To     string  `json:"to"`
+	Reason *string `json:"reason"`
+}
+
+type StreakGap struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Days int    `json:"days"`
+}
+
+type WeekCompletion struct {
+	Week          string   `json:"week"`
+	LoggedDays    int      `json:"loggedDays"`
+	FrozenDays    int      `json:"frozenDays"`
+	AvailableDays int      `json:"availableDays"`
+	Rate          *float64 `json:"rate"`
+}
+
+type StreakStats struct {
+	Timezone           string           `json:"timezone"`
+	IncludeFood        bool             `json:"includeFood"`
+	LoggedToday        bool             `json:"loggedToday"`
+	CurrentStreak      int              `json:"currentStreak"`
+	CurrentStreakStart string           `json:"currentStreakStart,omitempty"`
+	LongestStreak      int              `json:"longestStreak"`
+	LongestStreakStart string           `json:"longestStreakStart,omitempty"`
+	LongestStreakEnd   string           `json:"longestStreakEnd,omitempty"`
+	TotalLoggedDays    int              `json:"totalLoggedDays"`
+	From               string           `json:"from"`
+	To                 string           `json:"to"`
+	CompletionRate     *float64         `json:"completionRate"`
+	FrozenDays         int              `json:"frozenDays"`
+	Weeks              []WeekCompletion `json:"weeks"`
+	Gaps               []StreakGap      `json:"gaps"`
+	LongestGap         int              `json:"longestGap"`
+}
+
+type activityBucketRow struct {
+	Bucket db.RawBigInt `json:"bucket"`
+}
+
+// queryMoodActivity returns every 15-minute bucket before to with a mood
+func queryMoodActivity(ctx context.Context, client *db.PrismaClient, userID int, to time.Time) ([]activityBucketRow, error) {
+	var rows []activityBucketRow
+	err := client.Prisma.QueryRaw(`
+		SELECT DISTINCT "createdAt" / ? AS bucket
+		FROM "Mood"
+		WHERE "userId" = ? AND "createdAt" < ?
+	`, statsBucketMillis, userID, to.UnixMilli()).Exec(ctx, &rows)
+	return rows, err
+}
+
+// queryFoodActivity returns every 15-minute bucket before to with a food log
+func queryFoodActivity(ctx context.Context, client *db.PrismaClient, userID int, to time.Time) ([]activityBucketRow, error) {
+	var rows []activityBucketRow
+	err := client.Prisma.QueryRaw(`
+		SELECT DISTINCT "eatenAt" / ? AS bucket
+		FROM "UserFood"
+		WHERE "userId" = ? AND "eatenAt" < ?
+	`, statsBucketMillis, userID, to.UnixMilli()).Exec(ctx, &rows)
+	return rows, err
+}
+
+// loggedDays returns the local dates the user logged anything on, with food
+// logs counted only when includeFood is set
+func loggedDays(ctx context.Context, client *db.PrismaClient, userID int, to time.Time, loc *time.Location, includeFood bool) (map[string]bool, error) {
+	rows, err := queryMoodActivity(ctx, client, userID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if includeFood {
+		foodRows, err := queryFoodActivity(ctx, client, userID, to)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, foodRows...)
+	}
+
+	days := make(map[string]bool)
+	for _, row := range rows {
+		days[bucketTime(row.Bucket, loc).Format("2006-01-02")] = true
+	}
+	return days, nil
+}
+
+// frozenDays returns the local dates the user marked as vacation
+func frozenDays(ctx context.Context, client *db.PrismaClient, userID int) (map[string]bool, error) {
+	freezes, err := client.StreakFreeze.FindMany(
+		db.StreakFreeze.User.Where(
+			db.User.ID.Equals(userID),
+		),
+	).Exec(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	frozen := make(map[string]bool, len(freezes))
+	for _, freeze := range freezes {
+		frozen[freeze.Date] = true
+	}
+	return frozen, nil
+}
+
+// computeStreakStats walks the days from the first logged day up to today.
+// Frozen days neither break nor extend a streak, and a missing entry today
+// does not break the current streak since the day is not over yet.
+func computeStreakStats(logged, frozen map[string]bool, from, to, today time.Time) StreakStats {
+	stats := StreakStats{
+		From:  from.Format("2006-01-02"),
+		To:    to.AddDate(0, 0, -1).Format("2006-01-02"),
+		Weeks: []WeekCompletion{},
+		Gaps:  []StreakGap{},
+	}
+
+	dates := make([]string, 0, len(logged))
+	for date := range logged {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	stats.TotalLoggedDays = len(dates)
+
+	todayKey := today.Format("2006-01-02")
+	stats.LoggedToday = logged[todayKey]
+
+	var first time.Time
+	if len(dates) > 0 {
+		first, _ = time.ParseInLocation("2006-01-02", dates[0], today.Location())
+	}
+
+	run, runStart := 0, ""
+	var gap *StreakGap
+	for day := first; len(dates) > 0 && !day.After(today); day = day.AddDate(0, 0, 1) {
+		key := day.Format("2006-01-02")
+		inRange := !day.Before(from) && day.Before(to)
+
+		switch {
+		case logged[key]:
+			if run == 0 {
+				runStart = key
+			}
+			run++
+			if run > stats.LongestStreak {
+				stats.LongestStreak = run
+				stats.LongestStreakStart, stats.LongestStreakEnd = runStart, key
+			}
+		case frozen[key]:
+		case key == todayKey:
+		default:
+			run = 0
+		}
+
+		if !logged[key] && !frozen[key] && key != todayKey && inRange {
+			if gap == nil {
+				stats.Gaps = append(stats.Gaps, StreakGap{From: key})
+				gap = &stats.Gaps[len(stats.Gaps)-1]
+			}
+			gap.To = key
+			gap.Days++
+			if gap.Days > stats.LongestGap {
+				stats.LongestGap = gap.Days
+			}
+		} else {
+			gap = nil
+		}
+	}
+
+	stats.CurrentStreak = run
+	if run > 0 {
+		stats.CurrentStreakStart = runStart
+	}
+
+	// Completion only counts days that have already started and were not
+	// marked as vacation
+	end := to
+	if tomorrow := today.AddDate(0, 0, 1); tomorrow.Before(end) {
+		end = tomorrow
+	}
+	var totalLogged, totalAvailable int
+	for week := weekStart(from); week.Before(end); week = week.AddDate(0, 0, 7) {
+		completion := WeekCompletion{Week: week.Format("2006-01-02")}
+		for day := week; day.Before(week.AddDate(0, 0, 7)) && day.Before(end); day = day.AddDate(0, 0, 1) {
+			if day.Before(from) {
+				continue
+			}
+			key := day.Format("2006-01-02")
+			switch {
+			case logged[key]:
+				completion.LoggedDays++
+				completion.AvailableDays++
+			case frozen[key]:
+				completion.FrozenDays++
+			default:
+				completion.AvailableDays++
+			}
+		}
+		if completion.AvailableDays > 0 {
+			rate := float64(completion.LoggedDays) / float64(completion.AvailableDays)
+			completion.Rate = &rate
+		}
+		totalLogged += completion.LoggedDays
+		totalAvailable += completion.AvailableDays
+		stats.FrozenDays += completion.FrozenDays
+		stats.Weeks = append(stats.Weeks, completion)
+	}
+	if totalAvailable > 0 {
+		rate := float64(totalLogged) / float64(totalAvailable)
+		stats.CompletionRate = &rate
+	}
+
+	return stats
+}
+
+// GetStreakStats returns the current and longest daily logging streaks and,
+// for the requested range, per-week completion and the gaps between entries.
+// ?includeFood=true also counts days with only food logged.
+func GetStreakStats(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+		includeFood := c.Query("includeFood") == "true"
+
+		loc, err := userLocation(c.Request.Context(), client, userIDInt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+			return
+		}
+
+		from, to, err := parseStatsRange(c, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid range: " + err.Error()})
+			return
+		}
+
+		now := time.Now().In(loc)
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+		logged, err := loggedDays(c.Request.Context(), client, userIDInt, today.AddDate(0, 0, 1), loc, includeFood)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute streaks: " + err.Error()})
+			return
+		}
+
+		frozen, err := frozenDays(c.Request.Context(), client, userIDInt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch streak freezes"})
+			return
+		}
+
+		stats := computeStreakStats(logged, frozen, from, to, today)
+		stats.Timezone = loc.String()
+		stats.IncludeFood = includeFood
+
+		c.JSON(http.StatusOK, stats)
+	}
+}
+
+func GetStreakFreezes(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		freezes, err := client.StreakFreeze.FindMany(
+			db.StreakFreeze.User.Where(
+				db.User.ID.Equals(int(userID.(uint))),
+			),
+		).OrderBy(
+			db.StreakFreeze.Date.Order(db.SortOrderAsc),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch streak freezes"})
+			return
+		}
+
+		c.JSON(http.StatusOK, freezes)
+	}
+}
+
+// CreateStreakFreeze marks the days from input.From to input.To (inclusive)
+// as vacation. Days that are already frozen are left as they are.
+func CreateStreakFreeze(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input StreakFreezeInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+
+		from, err := time.Parse("2006-01-02", input.From)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, use YYYY-MM-DD"})
+			return
+		}
+
+		to := from
+		if input.To != "" {
+			to, err = time.Parse("2006-01-02", input.To)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, use YYYY-MM-DD"})
+				return
+			}
+		}
+
+		if to.Before(from) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be after to"})
+			return
+		}
+		if int(to.Sub(from).Hours()/24)+1 > maxStreakFreezeDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "At most " + strconv.Itoa(maxStreakFreezeDays) + " days can be frozen at once"})
+			return
+		}
+
+		existing, err := client.StreakFreeze.FindMany(
+			db.StreakFreeze.User.Where(
+				db.User.ID.Equals(userIDInt),
+			),
+			db.StreakFreeze.Date.Gte(from.Format("2006-01-02")),
+			db.StreakFreeze.Date.Lte(to.Format("2006-01-02")),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch streak freezes"})
+			return
+		}
+
+		alreadyFrozen := make(map[string]bool, len(existing))
+		for _, freeze := range existing {
+			alreadyFrozen[freeze.Date] = true
+		}
+
+		var reason *string
+		if input.Reason != nil {
+			trimmed := strings.TrimSpace(*input.Reason)
+			reason = &trimmed
+		}
+
+		var ops []transaction.Param
+		for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+			date := day.Format("2006-01-02")
+			if alreadyFrozen[date] {
+				continue
+			}
+			ops = append(ops, client.StreakFreeze.CreateOne(
+				db.StreakFreeze.Date.Set(date),
+				db.StreakFreeze.User.Link(
+					db.User.ID.Equals(userIDInt),
+				),
+				db.StreakFreeze.Reason.SetIfPresent(reason),
+			).Tx())
+		}
+
+		if len(ops) > 0 {
+			if err := client.Prisma.Transaction(ops...).Exec(c.Request.Context()); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create streak freeze: " + err.Error()})
+				return
+			}
+		}
+
+		freezes, err := client.StreakFreeze.FindMany(
+			db.StreakFreeze.User.Where(
+				db.User.ID.Equals(userIDInt),
+			),
+			db.StreakFreeze.Date.Gte(from.Format("2006-01-02")),
+			db.StreakFreeze.Date.Lte(to.Format("2006-01-02")),
+		).OrderBy(
+			db.StreakFreeze.Date.Order(db.SortOrderAsc),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch streak freezes"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, freezes)
+	}
+}
+
+func DeleteStreakFreeze(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		freezeID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid streak freeze ID"})
+			return
+		}
+
+		_, err = client.StreakFreeze.FindFirst(
+			db.StreakFreeze.ID.Equals(freezeID),
+			db.StreakFreeze.User.Where(
+				db.User.ID.Equals(int(userID.(uint))),
+			),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			if err == db.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Streak freeze not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch streak freeze"})
+			}
+			return
+		}
+
+		_, err = client.StreakFreeze.FindUnique(
+			db.StreakFreeze.ID.Equals(freezeID),
+		).Delete().Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete streak freeze"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Streak freeze successfully deleted"})
+	}
+}
@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"api/prisma/db"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var supportResourceKinds = map[string]bool{
+	"emergency": true,
+	"helpline":  true,
+	"chat":      true,
+	"website":   true,
+}
+
+type SupportResourceInput struct {
+	Country     string  `json:"country" binding:"required"`
+	Kind        string  `json:"kind"`
+	Name        string  `json:"name" binding:"required"`
+	Phone       *string `json:"phone"`
+	URL         *string `json:"url"`
+	Description *string `json:"description"`
+	Position    *int    `json:"position"`
+}
+
+// normalizeSupportResourceInput cleans up the input in place and returns a
+// validation message, or "" if the input is fine
+func normalizeSupportResourceInput(input *SupportResourceInput) string {
+	input.Country = strings.ToUpper(strings.TrimSpace(input.Country))
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Kind == "" {
+		input.Kind = "helpline"
+	}
+
+	switch {
+	case input.Country != internationalResources && !countryPattern.MatchString(input.Country):
+		return "country must be a two-letter country code or INTL"
+	case !supportResourceKinds[input.Kind]:
+		return "kind must be one of emergency, helpline, chat or website"
+	case input.Name == "":
+		return "name must not be empty"
+	case input.Phone == nil && input.URL == nil:
+		return "phone or url is required"
+	}
+	return ""
+}
+
+// GetAllSupportResources lists every support resource for admins, optionally
+// only those of ?country=
+func GetAllSupportResources(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var filters []db.SupportResourceWhereParam
+		if country := c.Query("country"); country != "" {
+			filters = append(filters, db.SupportResource.Country.Equals(strings.ToUpper(country)))
+		}
+
+		resources, err := client.SupportResource.FindMany(
+			filters...,
+		).OrderBy(
+			db.SupportResource.Country.Order(db.SortOrderAsc),
+		).OrderBy(
+			db.SupportResource.Position.Order(db.SortOrderAsc),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch support resources"})
+			return
+		}
+
+		c.JSON(http.StatusOK, resources)
+	}
+}
+
+func CreateSupportResource(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input SupportResourceInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		if msg := normalizeSupportResourceInput(&input); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		resource, err := client.SupportResource.CreateOne(
+			db.SupportResource.Country.Set(input.Country),
+			db.SupportResource.Name.Set(input.Name),
+			db.SupportResource.Kind.Set(input.Kind),
+			db.SupportResource.Phone.SetIfPresent(input.Phone),
+			db.SupportResource.URL.SetIfPresent(input.URL),
+			db.SupportResource.Description.SetIfPresent(input.Description),
+			db.SupportResource.Position.SetIfPresent(input.Position),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create support resource: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, resource)
+	}
+}
+
+// UpdateSupportResource replaces a support resource; optional fields that are
+// left out are cleared
+func UpdateSupportResource(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input SupportResourceInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		if msg := normalizeSupportResourceInput(&input); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		resourceID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid support resource ID"})
+			return
+		}
+
+		position := 0
+		if input.Position != nil {
+			position = *input.Position
+		}
+
+		resource, err := client.SupportResource.FindUnique(
+			db.SupportResource.ID.Equals(resourceID),
+		).Update(
+			db.SupportResource.Country.Set(input.Country),
+			db.SupportResource.Name.Set(input.Name),
+			db.SupportResource.Kind.Set(input.Kind),
+			db.SupportResource.Phone.SetOptional(input.Phone),
+			db.SupportResource.URL.SetOptional(input.URL),
+			db.SupportResource.Description.SetOptional(input.Description),
+			db.SupportResource.Position.Set(position),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			if err == db.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Support resource not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update support resource: " + err.Error()})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, resource)
+	}
+}
+
+func DeleteSupportResource(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resourceID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid support resource ID"})
+			return
+		}
+
+		_, err = client.SupportResource.FindUnique(
+			db.SupportResource.ID.Equals(resourceID),
+		).Delete().Exec(c.Request.Context())
+
+		if err != nil {
+			if err == db.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Support resource not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete support resource"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Support resource successfully deleted"})
+	}
+}
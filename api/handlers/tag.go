@@ -2,16 +2,26 @@ package handler
 
 import (
 	"api/prisma/db"
+	"api/storage"
+	"context"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/steebchen/prisma-client-go/runtime/transaction"
 )
 
 func CreateTag(client *db.PrismaClient) gin.HandlerFunc {
     return func(c *gin.Context) {
         var tag struct {
-            Name     string `json:"name" binding:"required"`
-            IsPublic bool   `json:"isPublic"`
+            Name     string  `json:"name" binding:"required"`
+            IsPublic bool    `json:"isPublic"`
+            Color    *string `json:"color"`
+            Icon     *string `json:"icon"`
+            GroupID  *int    `json:"groupId"`
+            ParentID *int    `json:"parentId"`
         }
         if err := c.ShouldBindJSON(&tag); err != nil {
             c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
@@ -43,12 +53,38 @@ func CreateTag(client *db.PrismaClient) gin.HandlerFunc {
             return
         }
 
+        msg, err := validateTagPlacement(c.Request.Context(), client, int(userID), 0, tag.Color, tag.GroupID, tag.ParentID)
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate tag: " + err.Error()})
+            return
+        }
+        if msg != "" {
+            c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+            return
+        }
+
+        params := []db.TagSetParam{
+            db.Tag.IsPublic.Set(tag.IsPublic),
+            db.Tag.Color.SetIfPresent(tag.Color),
+            db.Tag.Icon.SetIfPresent(tag.Icon),
+        }
+        if tag.GroupID != nil && *tag.GroupID != 0 {
+            params = append(params, db.Tag.Group.Link(
+                db.TagGroup.ID.Equals(*tag.GroupID),
+            ))
+        }
+        if tag.ParentID != nil && *tag.ParentID != 0 {
+            params = append(params, db.Tag.Parent.Link(
+                db.Tag.ID.Equals(*tag.ParentID),
+            ))
+        }
+
         createdTag, err := client.Tag.CreateOne(
             db.Tag.Name.Set(tag.Name),
             db.Tag.User.Link(
                 db.User.ID.Equals(int(userID)),
             ),
-            db.Tag.IsPublic.Set(tag.IsPublic),
+            params...,
         ).Exec(c.Request.Context())
 
         if err != nil {
@@ -60,6 +96,8 @@ func CreateTag(client *db.PrismaClient) gin.HandlerFunc {
     }
 }
 
+// GetAllTags lists the user's tags. Archived tags are only included with
+// ?includeArchived=true.
 func GetAllTags(client *db.PrismaClient) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userIDInterface, exists := c.Get("user_id")
@@ -74,11 +112,16 @@ func GetAllTags(client *db.PrismaClient) gin.HandlerFunc {
 			return
 		}
 
-		tags, err := client.Tag.FindMany(
+		params := []db.TagWhereParam{
 			db.Tag.User.Where(
 				db.User.ID.Equals(int(userID)),
 			),
-		).Exec(c.Request.Context())
+		}
+		if c.Query("includeArchived") != "true" {
+			params = append(params, db.Tag.ArchivedAt.IsNull())
+		}
+
+		tags, err := client.Tag.FindMany(params...).Exec(c.Request.Context())
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
@@ -89,6 +132,9 @@ func GetAllTags(client *db.PrismaClient) gin.HandlerFunc {
 	}
 }
 
+// AddUserTag adopts a public tag: the user gets their own copy with the same
+// name, color and icon. The original is never linked to the user, so nothing
+// of the creator's (their moods in particular) becomes reachable through it.
 func AddUserTag(client *db.PrismaClient) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var tagID int
@@ -109,11 +155,165 @@ func AddUserTag(client *db.PrismaClient) gin.HandlerFunc {
 			return
 		}
 
-		_, err := client.Tag.FindUnique(
+		publicTag, err := client.Tag.FindFirst(
 			db.Tag.ID.Equals(tagID),
+			db.Tag.IsPublic.Equals(true),
+			db.Tag.HiddenAt.IsNull(),
+			db.Tag.ArchivedAt.IsNull(),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			if err == db.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tag"})
+			}
+			return
+		}
+
+		if publicTag.UserID == int(userID) {
+			c.JSON(http.StatusConflict, gin.H{"error": "This tag is already yours"})
+			return
+		}
+
+		color, hasColor := publicTag.Color()
+		icon, hasIcon := publicTag.Icon()
+
+		adoptedTag, err := client.Tag.CreateOne(
+			db.Tag.Name.Set(publicTag.Name),
+			db.Tag.User.Link(
+				db.User.ID.Equals(int(userID)),
+			),
+			db.Tag.Color.SetOptional(optionalString(color, hasColor)),
+			db.Tag.Icon.SetOptional(optionalString(icon, hasIcon)),
+			db.Tag.AdoptedFrom.Link(
+				db.Tag.ID.Equals(publicTag.ID),
+			),
 		).Exec(c.Request.Context())
 
 		if err != nil {
+			if strings.Contains(err.Error(), "Unique constraint failed") {
+				c.JSON(http.StatusConflict, gin.H{"error": "A tag with this name already exists for the user"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add tag to user: " + err.Error()})
+			}
+			return
+		}
+
+		c.JSON(http.StatusCreated, adoptedTag)
+	}
+}
+
+// TagUpdateInput changes only the fields present. An empty color or icon and
+// a groupId or parentId of 0 remove them.
+type TagUpdateInput struct {
+	Name     string  `json:"name" binding:"required"`
+	IsPublic *bool   `json:"isPublic"`
+	Color    *string `json:"color"`
+	Icon     *string `json:"icon"`
+	GroupID  *int    `json:"groupId"`
+	ParentID *int    `json:"parentId"`
+}
+
+type TagMergeInput struct {
+	SourceIDs []int `json:"sourceIds" binding:"required"`
+	TargetID  int   `json:"targetId" binding:"required"`
+}
+
+// findUserTag returns the user's tag with the given ID, with its moods and
+// food entries
+func findUserTag(ctx context.Context, client *db.PrismaClient, userID, tagID int) (*db.TagModel, error) {
+	return client.Tag.FindFirst(
+		db.Tag.ID.Equals(tagID),
+		db.Tag.User.Where(
+			db.User.ID.Equals(userID),
+		),
+	).With(
+		db.Tag.Moods.Fetch(),
+		db.Tag.UserFoods.Fetch(),
+	).Exec(ctx)
+}
+
+// moveTagEntries returns the operations linking every mood and food entry of
+// the source tags to target and deleting the sources
+func moveTagEntries(client *db.PrismaClient, sources []db.TagModel, target *db.TagModel) []transaction.Param {
+	linkedMoods := make(map[int]bool)
+	for _, mood := range target.Moods() {
+		linkedMoods[mood.ID] = true
+	}
+	linkedFoods := make(map[int]bool)
+	for _, userFood := range target.UserFoods() {
+		linkedFoods[userFood.ID] = true
+	}
+
+	var moods []db.MoodWhereParam
+	var userFoods []db.UserFoodWhereParam
+	var sourceIDs []int
+	for _, source := range sources {
+		sourceIDs = append(sourceIDs, source.ID)
+		for _, mood := range source.Moods() {
+			if !linkedMoods[mood.ID] {
+				linkedMoods[mood.ID] = true
+				moods = append(moods, db.Mood.ID.Equals(mood.ID))
+			}
+		}
+		for _, userFood := range source.UserFoods() {
+			if !linkedFoods[userFood.ID] {
+				linkedFoods[userFood.ID] = true
+				userFoods = append(userFoods, db.UserFood.ID.Equals(userFood.ID))
+			}
+		}
+	}
+
+	var ops []transaction.Param
+	if len(moods) > 0 {
+		ops = append(ops, client.Tag.FindUnique(
+			db.Tag.ID.Equals(target.ID),
+		).Update(
+			db.Tag.Moods.Link(moods...),
+		).Tx())
+	}
+	if len(userFoods) > 0 {
+		ops = append(ops, client.Tag.FindUnique(
+			db.Tag.ID.Equals(target.ID),
+		).Update(
+			db.Tag.UserFoods.Link(userFoods...),
+		).Tx())
+	}
+	ops = append(ops, client.Tag.FindMany(
+		db.Tag.ID.In(sourceIDs),
+	).Delete().Tx())
+	return ops
+}
+
+// UpdateTag renames a tag and changes its visibility
+func UpdateTag(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input TagUpdateInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		input.Name = strings.TrimSpace(input.Name)
+		if input.Name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name must not be empty"})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		tagID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+			return
+		}
+
+		if _, err := findUserTag(c.Request.Context(), client, int(userID.(uint)), tagID); err != nil {
 			if err == db.ErrNotFound {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
 			} else {
@@ -122,19 +322,253 @@ func AddUserTag(client *db.PrismaClient) gin.HandlerFunc {
 			return
 		}
 
-		_, err = client.User.FindUnique(
-			db.User.ID.Equals(int(userID)),
+		msg, err := validateTagPlacement(c.Request.Context(), client, int(userID.(uint)), tagID, input.Color, input.GroupID, input.ParentID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate tag: " + err.Error()})
+			return
+		}
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		params := []db.TagSetParam{
+			db.Tag.Name.Set(input.Name),
+			db.Tag.IsPublic.SetIfPresent(input.IsPublic),
+		}
+		if input.Color != nil {
+			params = append(params, db.Tag.Color.SetOptional(nonEmpty(*input.Color)))
+		}
+		if input.Icon != nil {
+			params = append(params, db.Tag.Icon.SetOptional(nonEmpty(*input.Icon)))
+		}
+		if input.GroupID != nil {
+			if *input.GroupID == 0 {
+				params = append(params, db.Tag.Group.Unlink())
+			} else {
+				params = append(params, db.Tag.Group.Link(
+					db.TagGroup.ID.Equals(*input.GroupID),
+				))
+			}
+		}
+		if input.ParentID != nil {
+			if *input.ParentID == 0 {
+				params = append(params, db.Tag.Parent.Unlink())
+			} else {
+				params = append(params, db.Tag.Parent.Link(
+					db.Tag.ID.Equals(*input.ParentID),
+				))
+			}
+		}
+
+		updatedTag, err := client.Tag.FindUnique(
+			db.Tag.ID.Equals(tagID),
 		).Update(
-			db.User.Tags.Link(
+			params...,
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			if strings.Contains(err.Error(), "Unique constraint failed") {
+				c.JSON(http.StatusConflict, gin.H{"error": "A tag with this name already exists for the user"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tag: " + err.Error()})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, updatedTag)
+	}
+}
+
+// DeleteTag deletes a tag. By default its moods and food entries simply lose
+// the tag; with ?moveTo=<tag ID> they are linked to that tag instead. The
+// tag can appear in any stored report, so all of them are dropped.
+func DeleteTag(client *db.PrismaClient, store storage.BlobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+
+		tagID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+			return
+		}
+
+		tag, err := findUserTag(c.Request.Context(), client, userIDInt, tagID)
+		if err != nil {
+			if err == db.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tag"})
+			}
+			return
+		}
+
+		ops := []transaction.Param{
+			client.Tag.FindUnique(
 				db.Tag.ID.Equals(tagID),
-			),
+			).Delete().Tx(),
+		}
+
+		if moveTo := c.Query("moveTo"); moveTo != "" {
+			targetID, err := strconv.Atoi(moveTo)
+			if err != nil || targetID == tagID {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid moveTo tag ID"})
+				return
+			}
+
+			target, err := findUserTag(c.Request.Context(), client, userIDInt, targetID)
+			if err != nil {
+				if err == db.ErrNotFound {
+					c.JSON(http.StatusNotFound, gin.H{"error": "Target tag not found"})
+				} else {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch target tag"})
+				}
+				return
+			}
+
+			ops = moveTagEntries(client, []db.TagModel{*tag}, target)
+		}
+
+		if err := client.Prisma.Transaction(ops...).Exec(c.Request.Context()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete tag: " + err.Error()})
+			return
+		}
+
+		moodStatsCache.invalidate(userIDInt)
+		invalidateAllStoredReports(c.Request.Context(), client, store, userIDInt)
+
+		c.JSON(http.StatusOK, gin.H{"message": "Tag successfully deleted"})
+	}
+}
+
+// MergeTags links every mood and food entry of the source tags to the target
+// tag and deletes the sources, all in one transaction. Stored reports are
+// dropped like in DeleteTag.
+func MergeTags(client *db.PrismaClient, store storage.BlobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input TagMergeInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		if len(input.SourceIDs) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "sourceIds must not be empty"})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+
+		target, err := findUserTag(c.Request.Context(), client, userIDInt, input.TargetID)
+		if err != nil {
+			if err == db.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Target tag not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch target tag"})
+			}
+			return
+		}
+
+		seen := make(map[int]bool)
+		var sources []db.TagModel
+		for _, sourceID := range input.SourceIDs {
+			if sourceID == input.TargetID {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "targetId must not be one of sourceIds"})
+				return
+			}
+			if seen[sourceID] {
+				continue
+			}
+			seen[sourceID] = true
+
+			source, err := findUserTag(c.Request.Context(), client, userIDInt, sourceID)
+			if err != nil {
+				if err == db.ErrNotFound {
+					c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found: " + strconv.Itoa(sourceID)})
+				} else {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tag"})
+				}
+				return
+			}
+			sources = append(sources, *source)
+		}
+
+		ops := moveTagEntries(client, sources, target)
+		if err := client.Prisma.Transaction(ops...).Exec(c.Request.Context()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge tags: " + err.Error()})
+			return
+		}
+
+		moodStatsCache.invalidate(userIDInt)
+		invalidateAllStoredReports(c.Request.Context(), client, store, userIDInt)
+
+		mergedTag, err := client.Tag.FindUnique(
+			db.Tag.ID.Equals(input.TargetID),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch merged tag"})
+			return
+		}
+
+		c.JSON(http.StatusOK, mergedTag)
+	}
+}
+
+// SetTagArchived archives or restores a tag. Archived tags are hidden from
+// pickers but stay on the moods they were used on.
+func SetTagArchived(client *db.PrismaClient, archived bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		tagID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+			return
+		}
+
+		if _, err := findUserTag(c.Request.Context(), client, int(userID.(uint)), tagID); err != nil {
+			if err == db.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tag"})
+			}
+			return
+		}
+
+		var archivedAt *time.Time
+		if archived {
+			now := time.Now()
+			archivedAt = &now
+		}
+
+		updatedTag, err := client.Tag.FindUnique(
+			db.Tag.ID.Equals(tagID),
+		).Update(
+			db.Tag.ArchivedAt.SetOptional(archivedAt),
 		).Exec(c.Request.Context())
 
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add tag to user"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tag: " + err.Error()})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"message": "Tag added to user successfully"})
+		c.JSON(http.StatusOK, updatedTag)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,642 @@
+package handler
+
+import (
+	"api/prisma/db"
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/steebchen/prisma-client-go/runtime/transaction"
+)
+
+// maxTagDepth limits how deep tags can be nested under each other
+const maxTagDepth = 5
+
+type TagGroupInput struct {
+	Name  string  `json:"name" binding:"required"`
+	Color *string `json:"color"`
+	Icon  *string `json:"icon"`
+}
+
+type TagOrderInput struct {
+	IDs []int `json:"ids" binding:"required"`
+}
+
+// TagNode is a tag with its child tags
+type TagNode struct {
+	ID       int       `json:"id"`
+	Name     string    `json:"name"`
+	Color    *string   `json:"color"`
+	Icon     *string   `json:"icon"`
+	Position int       `json:"position"`
+	Archived bool      `json:"archived"`
+	Children []TagNode `json:"children"`
+}
+
+type TagGroupNode struct {
+	ID       int       `json:"id"`
+	Name     string    `json:"name"`
+	Color    *string   `json:"color"`
+	Icon     *string   `json:"icon"`
+	Position int       `json:"position"`
+	Tags     []TagNode `json:"tags"`
+}
+
+// TagTree is every tag of a user, nested under its parent and grouped by
+// the group of its top-level tag
+type TagTree struct {
+	Groups    []TagGroupNode `json:"groups"`
+	Ungrouped []TagNode      `json:"ungrouped"`
+}
+
+func optionalString(value string, ok bool) *string {
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+// nonEmpty returns nil for "", so an empty value clears an optional field
+func nonEmpty(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// tagChildren maps each of the user's tags to the tags nested directly below
+// it
+func tagChildren(ctx context.Context, client *db.PrismaClient, userID int) (map[int][]int, error) {
+	tags, err := client.Tag.FindMany(
+		db.Tag.User.Where(
+			db.User.ID.Equals(userID),
+		),
+	).Exec(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[int][]int)
+	for _, tag := range tags {
+		if parentID, ok := tag.ParentID(); ok {
+			children[parentID] = append(children[parentID], tag.ID)
+		}
+	}
+	return children, nil
+}
+
+// tagDescendants returns tagID followed by the IDs of every tag nested below
+// it
+func tagDescendants(ctx context.Context, client *db.PrismaClient, userID, tagID int) ([]int, error) {
+	children, err := tagChildren(ctx, client, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []int{tagID}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, children[ids[i]]...)
+	}
+	return ids, nil
+}
+
+// tagSubtreeHeight returns how many levels of tags are nested below tagID, 0
+// for a tag without children
+func tagSubtreeHeight(ctx context.Context, client *db.PrismaClient, userID, tagID int) (int, error) {
+	children, err := tagChildren(ctx, client, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	height := 0
+	for level := children[tagID]; len(level) > 0; height++ {
+		var next []int
+		for _, id := range level {
+			next = append(next, children[id]...)
+		}
+		level = next
+	}
+	return height, nil
+}
+
+// validateTagParent checks that parentID is one of the user's tags and that
+// making it the parent of tagID (0 for a new tag) neither creates a cycle nor
+// nests too deep, counting the tags already nested below tagID. It returns a
+// validation message, or "" if the parent is fine.
+func validateTagParent(ctx context.Context, client *db.PrismaClient, userID, tagID, parentID int) (string, error) {
+	depth := 1
+	for id := parentID; ; depth++ {
+		if id == tagID {
+			return "A tag cannot be nested under itself or its own children", nil
+		}
+		if depth > maxTagDepth {
+			return "Tags cannot be nested more than " + strconv.Itoa(maxTagDepth) + " levels deep", nil
+		}
+
+		parent, err := client.Tag.FindFirst(
+			db.Tag.ID.Equals(id),
+			db.Tag.User.Where(
+				db.User.ID.Equals(userID),
+			),
+		).Exec(ctx)
+
+		if err == db.ErrNotFound {
+			return "Parent tag not found", nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		next, ok := parent.ParentID()
+		if !ok {
+			break
+		}
+		id = next
+	}
+
+	// A moved tag takes its children along, so they must fit below the parent
+	// as well
+	if tagID != 0 {
+		height, err := tagSubtreeHeight(ctx, client, userID, tagID)
+		if err != nil {
+			return "", err
+		}
+		if depth+height > maxTagDepth {
+			return "Tags cannot be nested more than " + strconv.Itoa(maxTagDepth) + " levels deep", nil
+		}
+	}
+	return "", nil
+}
+
+// validateTagGroup checks that groupID is one of the user's tag groups
+func validateTagGroup(ctx context.Context, client *db.PrismaClient, userID, groupID int) (string, error) {
+	_, err := client.TagGroup.FindFirst(
+		db.TagGroup.ID.Equals(groupID),
+		db.TagGroup.User.Where(
+			db.User.ID.Equals(userID),
+		),
+	).Exec(ctx)
+
+	if err == db.ErrNotFound {
+		return "Tag group not found", nil
+	}
+	return "", err
+}
+
+// validateTagPlacement checks the color, group and parent given for tagID
+// (0 for a new tag). A group or parent of 0 means none.
+func validateTagPlacement(ctx context.Context, client *db.PrismaClient, userID, tagID int, color *string, groupID, parentID *int) (string, error) {
+	if color != nil && *color != "" && !hexColorPattern.MatchString(*color) {
+		return "Color must be a hex value like #A1B2C3", nil
+	}
+	if groupID != nil && *groupID != 0 {
+		if msg, err := validateTagGroup(ctx, client, userID, *groupID); msg != "" || err != nil {
+			return msg, err
+		}
+	}
+	if parentID != nil && *parentID != 0 {
+		return validateTagParent(ctx, client, userID, tagID, *parentID)
+	}
+	return "", nil
+}
+
+func validateTagGroupInput(input TagGroupInput) string {
+	if strings.TrimSpace(input.Name) == "" {
+		return "Name cannot be empty"
+	}
+	if input.Color != nil && *input.Color != "" && !hexColorPattern.MatchString(*input.Color) {
+		return "Color must be a hex value like #A1B2C3"
+	}
+	return ""
+}
+
+// GetTagTree returns the user's tags nested by parent and grouped, both
+// ordered by position. Archived tags are only included with
+// ?includeArchived=true.
+func GetTagTree(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+
+		params := []db.TagWhereParam{
+			db.Tag.User.Where(
+				db.User.ID.Equals(userIDInt),
+			),
+		}
+		if c.Query("includeArchived") != "true" {
+			params = append(params, db.Tag.ArchivedAt.IsNull())
+		}
+
+		tags, err := client.Tag.FindMany(params...).OrderBy(
+			db.Tag.Position.Order(db.SortOrderAsc),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+			return
+		}
+
+		groups, err := client.TagGroup.FindMany(
+			db.TagGroup.User.Where(
+				db.User.ID.Equals(userIDInt),
+			),
+		).OrderBy(
+			db.TagGroup.Position.Order(db.SortOrderAsc),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tag groups"})
+			return
+		}
+
+		included := make(map[int]bool, len(tags))
+		for _, tag := range tags {
+			included[tag.ID] = true
+		}
+
+		// A tag whose parent is archived is shown at the top level
+		children := make(map[int][]db.TagModel)
+		roots := make(map[int][]db.TagModel)
+		var ungrouped []db.TagModel
+		for _, tag := range tags {
+			if parentID, ok := tag.ParentID(); ok && included[parentID] {
+				children[parentID] = append(children[parentID], tag)
+			} else if groupID, ok := tag.GroupID(); ok {
+				roots[groupID] = append(roots[groupID], tag)
+			} else {
+				ungrouped = append(ungrouped, tag)
+			}
+		}
+
+		var build func(tags []db.TagModel) []TagNode
+		build = func(tags []db.TagModel) []TagNode {
+			nodes := []TagNode{}
+			for _, tag := range tags {
+				color, hasColor := tag.Color()
+				icon, hasIcon := tag.Icon()
+				_, archived := tag.ArchivedAt()
+				nodes = append(nodes, TagNode{
+					ID:       tag.ID,
+					Name:     tag.Name,
+					Color:    optionalString(color, hasColor),
+					Icon:     optionalString(icon, hasIcon),
+					Position: tag.Position,
+					Archived: archived,
+					Children: build(children[tag.ID]),
+				})
+			}
+			return nodes
+		}
+
+		tree := TagTree{Groups: []TagGroupNode{}, Ungrouped: build(ungrouped)}
+		for _, group := range groups {
+			color, hasColor := group.Color()
+			icon, hasIcon := group.Icon()
+			tree.Groups = append(tree.Groups, TagGroupNode{
+				ID:       group.ID,
+				Name:     group.Name,
+				Color:    optionalString(color, hasColor),
+				Icon:     optionalString(icon, hasIcon),
+				Position: group.Position,
+				Tags:     build(roots[group.ID]),
+			})
+		}
+
+		c.JSON(http.StatusOK, tree)
+	}
+}
+
+func GetTagGroups(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		groups, err := client.TagGroup.FindMany(
+			db.TagGroup.User.Where(
+				db.User.ID.Equals(int(userID.(uint))),
+			),
+		).OrderBy(
+			db.TagGroup.Position.Order(db.SortOrderAsc),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tag groups"})
+			return
+		}
+
+		c.JSON(http.StatusOK, groups)
+	}
+}
+
+// CreateTagGroup adds a group at the end of the user's groups
+func CreateTagGroup(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input TagGroupInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		if msg := validateTagGroupInput(input); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+
+		existing, err := client.TagGroup.FindMany(
+			db.TagGroup.User.Where(
+				db.User.ID.Equals(userIDInt),
+			),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tag groups"})
+			return
+		}
+
+		group, err := client.TagGroup.CreateOne(
+			db.TagGroup.Name.Set(strings.TrimSpace(input.Name)),
+			db.TagGroup.User.Link(
+				db.User.ID.Equals(userIDInt),
+			),
+			db.TagGroup.Color.SetIfPresent(input.Color),
+			db.TagGroup.Icon.SetIfPresent(input.Icon),
+			db.TagGroup.Position.Set(len(existing)),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			if strings.Contains(err.Error(), "Unique constraint failed") {
+				c.JSON(http.StatusConflict, gin.H{"error": "A tag group with this name already exists"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tag group: " + err.Error()})
+			}
+			return
+		}
+
+		c.JSON(http.StatusCreated, group)
+	}
+}
+
+// UpdateTagGroup replaces a group's name, color and icon; a missing color or
+// icon is cleared
+func UpdateTagGroup(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input TagGroupInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		if msg := validateTagGroupInput(input); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		groupID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag group ID"})
+			return
+		}
+
+		msg, err := validateTagGroup(c.Request.Context(), client, int(userID.(uint)), groupID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tag group"})
+			return
+		}
+		if msg != "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": msg})
+			return
+		}
+
+		group, err := client.TagGroup.FindUnique(
+			db.TagGroup.ID.Equals(groupID),
+		).Update(
+			db.TagGroup.Name.Set(strings.TrimSpace(input.Name)),
+			db.TagGroup.Color.SetOptional(input.Color),
+			db.TagGroup.Icon.SetOptional(input.Icon),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			if strings.Contains(err.Error(), "Unique constraint failed") {
+				c.JSON(http.StatusConflict, gin.H{"error": "A tag group with this name already exists"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tag group: " + err.Error()})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, group)
+	}
+}
+
+// DeleteTagGroup removes a group. Its tags are kept and become ungrouped.
+func DeleteTagGroup(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		groupID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag group ID"})
+			return
+		}
+
+		msg, err := validateTagGroup(c.Request.Context(), client, int(userID.(uint)), groupID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tag group"})
+			return
+		}
+		if msg != "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": msg})
+			return
+		}
+
+		_, err = client.TagGroup.FindUnique(
+			db.TagGroup.ID.Equals(groupID),
+		).Delete().Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete tag group"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Tag group successfully deleted"})
+	}
+}
+
+// ReorderTagGroups stores a new order for the user's tag groups. The request
+// must list every group exactly once.
+func ReorderTagGroups(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input TagOrderInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		groups, err := client.TagGroup.FindMany(
+			db.TagGroup.User.Where(
+				db.User.ID.Equals(int(userID.(uint))),
+			),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tag groups"})
+			return
+		}
+
+		owned := make([]int, len(groups))
+		for i, group := range groups {
+			owned[i] = group.ID
+		}
+		if !sameIDs(owned, input.IDs) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Order must include every tag group exactly once"})
+			return
+		}
+
+		var ops []transaction.Param
+		for position, id := range input.IDs {
+			ops = append(ops, client.TagGroup.FindUnique(
+				db.TagGroup.ID.Equals(id),
+			).Update(
+				db.TagGroup.Position.Set(position),
+			).Tx())
+		}
+
+		if len(ops) > 0 {
+			if err := client.Prisma.Transaction(ops...).Exec(c.Request.Context()); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder tag groups: " + err.Error()})
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Tag groups reordered"})
+	}
+}
+
+// ReorderGroupTags stores a new order for the top-level tags of a group. The
+// request must list each of them exactly once.
+func ReorderGroupTags(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input TagOrderInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+
+		groupID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag group ID"})
+			return
+		}
+
+		msg, err := validateTagGroup(c.Request.Context(), client, userIDInt, groupID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tag group"})
+			return
+		}
+		if msg != "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": msg})
+			return
+		}
+
+		tags, err := client.Tag.FindMany(
+			db.Tag.User.Where(
+				db.User.ID.Equals(userIDInt),
+			),
+			db.Tag.GroupID.Equals(groupID),
+			db.Tag.ParentID.IsNull(),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+			return
+		}
+
+		owned := make([]int, len(tags))
+		for i, tag := range tags {
+			owned[i] = tag.ID
+		}
+		if !sameIDs(owned, input.IDs) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Order must include every top-level tag of the group exactly once"})
+			return
+		}
+
+		var ops []transaction.Param
+		for position, id := range input.IDs {
+			ops = append(ops, client.Tag.FindUnique(
+				db.Tag.ID.Equals(id),
+			).Update(
+				db.Tag.Position.Set(position),
+			).Tx())
+		}
+
+		if len(ops) > 0 {
+			if err := client.Prisma.Transaction(ops...).Exec(c.Request.Context()); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder tags: " + err.Error()})
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Tags reordered"})
+	}
+}
+
+// sameIDs reports whether ids lists exactly the IDs in owned, each once
+func sameIDs(owned, ids []int) bool {
+	if len(owned) != len(ids) {
+		return false
+	}
+	a := append([]int(nil), owned...)
+	b := append([]int(nil), ids...)
+	sort.Ints(a)
+	sort.Ints(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
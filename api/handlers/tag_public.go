@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"api/prisma/db"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPublicTagLimit = 20
+	maxPublicTagLimit     = 100
+)
+
+type publicTagRow struct {
+	ID      db.RawInt    `json:"id"`
+	Name    db.RawString `json:"name"`
+	Color   db.RawString `json:"color"`
+	Icon    db.RawString `json:"icon"`
+	Users   db.RawInt    `json:"users"`
+	Adopted db.RawInt    `json:"adopted"`
+	Hidden  db.RawInt    `json:"hidden"`
+}
+
+// PublicTag is a tag in the public directory. It carries nothing about its
+// creator: no user, no moods, no usage by mood. Users counts the creator and
+// everyone who adopted the tag.
+type PublicTag struct {
+	ID      int     `json:"id"`
+	Name    string  `json:"name"`
+	Color   *string `json:"color"`
+	Icon    *string `json:"icon"`
+	Users   int     `json:"users"`
+	Adopted bool    `json:"adopted"`
+}
+
+// ModeratedTag is a public tag as admins see it
+type ModeratedTag struct {
+	PublicTag
+	Hidden bool `json:"hidden"`
+}
+
+// Which hidden tags queryPublicTags lists
+const (
+	visibleTags = iota
+	allTags
+	hiddenTags
+)
+
+// queryPublicTags lists public, unarchived tags whose name contains search,
+// most used first. Tags of excludeUserID are left out.
+func queryPublicTags(ctx context.Context, client *db.PrismaClient, excludeUserID int, search string, visibility, limit, offset int) ([]publicTagRow, error) {
+	var rows []publicTagRow
+	err := client.Prisma.QueryRaw(`
+		SELECT t."id" AS id, t."name" AS name,
+			COALESCE(t."color", '') AS color,
+			COALESCE(t."icon", '') AS icon,
+			COUNT(a."id") + 1 AS users,
+			COALESCE(MAX(a."userId" = ?), 0) AS adopted,
+			t."hiddenAt" IS NOT NULL AS hidden
+		FROM "Tag" t
+		LEFT JOIN "Tag" a ON a."adoptedFromId" = t."id"
+		WHERE t."isPublic" = 1 AND t."archivedAt" IS NULL AND t."userId" != ?
+			AND CASE ? WHEN 0 THEN t."hiddenAt" IS NULL WHEN 2 THEN t."hiddenAt" IS NOT NULL ELSE 1 END
+			AND t."name" LIKE ? ESCAPE '\'
+		GROUP BY t."id"
+		ORDER BY users DESC, t."name"
+		LIMIT ? OFFSET ?
+	`, excludeUserID, excludeUserID, visibility, "%"+escapeLike(search)+"%", limit, offset).Exec(ctx, &rows)
+	return rows, err
+}
+
+// escapeLike escapes the LIKE wildcards in s
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func publicTagFromRow(row publicTagRow) PublicTag {
+	return PublicTag{
+		ID:      int(row.ID),
+		Name:    string(row.Name),
+		Color:   nonEmpty(string(row.Color)),
+		Icon:    nonEmpty(string(row.Icon)),
+		Users:   int(row.Users),
+		Adopted: row.Adopted != 0,
+	}
+}
+
+// parsePage reads ?limit= and ?offset=
+func parsePage(c *gin.Context) (int, int, bool) {
+	limit, offset := defaultPublicTagLimit, 0
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxPublicTagLimit {
+			return 0, 0, false
+		}
+		limit = parsed
+	}
+	if value := c.Query("offset"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return 0, 0, false
+		}
+		offset = parsed
+	}
+	return limit, offset, true
+}
+
+// GetPublicTags browses the public tag directory, optionally searching by
+// ?q=. The user's own tags are not listed.
+func GetPublicTags(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		limit, offset, ok := parsePage(c)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxPublicTagLimit) + " and offset must not be negative"})
+			return
+		}
+
+		rows, err := queryPublicTags(c.Request.Context(), client, int(userID.(uint)), strings.TrimSpace(c.Query("q")), visibleTags, limit, offset)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch public tags: " + err.Error()})
+			return
+		}
+
+		tags := []PublicTag{}
+		for _, row := range rows {
+			tags = append(tags, publicTagFromRow(row))
+		}
+
+		c.JSON(http.StatusOK, tags)
+	}
+}
+
+// GetModeratedTags lists every public tag, hidden ones included, for admins.
+// ?hidden=true lists only the hidden ones.
+func GetModeratedTags(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, offset, ok := parsePage(c)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxPublicTagLimit) + " and offset must not be negative"})
+			return
+		}
+
+		visibility := allTags
+		if c.Query("hidden") == "true" {
+			visibility = hiddenTags
+		}
+
+		rows, err := queryPublicTags(c.Request.Context(), client, 0, strings.TrimSpace(c.Query("q")), visibility, limit, offset)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch public tags: " + err.Error()})
+			return
+		}
+
+		tags := []ModeratedTag{}
+		for _, row := range rows {
+			tags = append(tags, ModeratedTag{PublicTag: publicTagFromRow(row), Hidden: row.Hidden != 0})
+		}
+
+		c.JSON(http.StatusOK, tags)
+	}
+}
+
+// SetTagHidden hides a public tag from the directory or shows it again.
+// Copies already adopted are left alone. Private tags are not part of the
+// directory and are reported as not found.
+func SetTagHidden(client *db.PrismaClient, hidden bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tagID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+			return
+		}
+
+		_, err = client.Tag.FindFirst(
+			db.Tag.ID.Equals(tagID),
+			db.Tag.IsPublic.Equals(true),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			if err == db.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tag"})
+			}
+			return
+		}
+
+		var hiddenAt *time.Time
+		if hidden {
+			now := time.Now()
+			hiddenAt = &now
+		}
+
+		tag, err := client.Tag.FindUnique(
+			db.Tag.ID.Equals(tagID),
+		).Update(
+			db.Tag.HiddenAt.SetOptional(hiddenAt),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			if err == db.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tag: " + err.Error()})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, tag)
+	}
+}
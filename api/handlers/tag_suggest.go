@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"api/prisma/db"
+	"api/suggest"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// suggestTrainingEntries is how many of the user's latest entries the
+	// suggestion model learns from
+	suggestTrainingEntries = 1000
+	defaultSuggestLimit    = 5
+	maxSuggestLimit        = 20
+)
+
+// TagSuggestion is a suggested tag with why it was suggested
+type TagSuggestion struct {
+	ID      int              `json:"id"`
+	Name    string           `json:"name"`
+	Color   *string          `json:"color"`
+	Icon    *string          `json:"icon"`
+	Score   float64          `json:"score"`
+	Reasons []suggest.Reason `json:"reasons"`
+}
+
+// TagSuggestInput is the draft entry being written. Unlike MoodInput nothing
+// is required, since suggestions are asked for while the entry is still
+// empty.
+type TagSuggestInput struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Tags        []int  `json:"tags"`
+}
+
+// SuggestTags ranks the user's tags for a draft mood entry. The model is
+// trained on the user's own tagged entries every time; nothing is shared
+// between users. ?limit= caps the number of suggestions.
+func SuggestTags(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+		uid := int(userID.(uint))
+
+		var input TagSuggestInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		limit := defaultSuggestLimit
+		if value := c.Query("limit"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 || parsed > maxSuggestLimit {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxSuggestLimit)})
+				return
+			}
+			limit = parsed
+		}
+
+		ctx := c.Request.Context()
+		loc, err := userLocation(ctx, client, uid)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user: " + err.Error()})
+			return
+		}
+
+		moods, err := client.Mood.FindMany(
+			db.Mood.User.Where(
+				db.User.ID.Equals(uid),
+			),
+		).With(
+			db.Mood.Tags.Fetch(),
+		).OrderBy(
+			db.Mood.CreatedAt.Order(db.SortOrderDesc),
+		).Take(suggestTrainingEntries).Exec(ctx)
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch moods: " + err.Error()})
+			return
+		}
+
+		tags, err := client.Tag.FindMany(
+			db.Tag.User.Where(
+				db.User.ID.Equals(uid),
+			),
+			db.Tag.ArchivedAt.IsNull(),
+		).Exec(ctx)
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags: " + err.Error()})
+			return
+		}
+
+		byID := make(map[int]db.TagModel, len(tags))
+		byName := make(map[string]db.TagModel, len(tags))
+		for _, tag := range tags {
+			byID[tag.ID] = tag
+			byName[tag.Name] = tag
+		}
+
+		examples := make([]suggest.Example, 0, len(moods))
+		for _, mood := range moods {
+			example := suggest.Example{
+				Text: mood.Title + "\n" + mood.Description,
+				Time: mood.CreatedAt.In(loc),
+			}
+			for _, tag := range mood.Tags() {
+				example.Tags = append(example.Tags, tag.ID)
+			}
+			examples = append(examples, example)
+		}
+
+		// Draft tags name the tags the same way resolveMoodTags reads them,
+		// but nothing is created for a draft
+		draft := suggest.Draft{
+			Text:     input.Title + "\n" + input.Description,
+			Time:     time.Now().In(loc),
+			TagNames: make(map[int]string),
+		}
+		for _, name := range input.Tags {
+			if tag, ok := byName[strconv.Itoa(name)]; ok {
+				draft.Tags = append(draft.Tags, tag.ID)
+				draft.TagNames[tag.ID] = tag.Name
+			}
+		}
+
+		suggestions := []TagSuggestion{}
+		for _, suggestion := range suggest.Train(examples).Suggest(draft, maxSuggestLimit) {
+			tag, ok := byID[suggestion.TagID]
+			if !ok {
+				// Archived since it was used
+				continue
+			}
+			color, _ := tag.Color()
+			icon, _ := tag.Icon()
+			suggestions = append(suggestions, TagSuggestion{
+				ID:      tag.ID,
+				Name:    tag.Name,
+				Color:   nonEmpty(color),
+				Icon:    nonEmpty(icon),
+				Score:   suggestion.Score,
+				Reasons: suggestion.Reasons,
+			})
+			if len(suggestions) == limit {
+				break
+			}
+		}
+
+		c.JSON(http.StatusOK, suggestions)
+	}
+}
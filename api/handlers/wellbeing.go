@@ -0,0 +1,419 @@
+package handler
+
+import (
+	"api/prisma/db"
+	"api/wellbeing"
+	"context"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultWellbeingWindowDays   = 7
+	defaultWellbeingThreshold    = -0.3
+	defaultWellbeingMinLowDays   = 5
+	defaultWellbeingCooldownDays = 7
+	defaultWellbeingCountry      = "TR"
+	// internationalResources are listed for every country
+	internationalResources = "INTL"
+)
+
+var countryPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+
+type WellbeingSettingsInput struct {
+	Enabled      *bool    `json:"enabled"`
+	WindowDays   *int     `json:"windowDays"`
+	Threshold    *float64 `json:"threshold"`
+	MinLowDays   *int     `json:"minLowDays"`
+	CooldownDays *int     `json:"cooldownDays"`
+	Country      *string  `json:"country"`
+}
+
+type WellbeingSettingsView struct {
+	Enabled      bool    `json:"enabled"`
+	WindowDays   int     `json:"windowDays"`
+	Threshold    float64 `json:"threshold"`
+	MinLowDays   int     `json:"minLowDays"`
+	CooldownDays int     `json:"cooldownDays"`
+	Country      string  `json:"country"`
+}
+
+// wellbeingSettingsFor returns the user's settings, or the defaults if the
+// user never changed them
+func wellbeingSettingsFor(ctx context.Context, client *db.PrismaClient, userID int) (WellbeingSettingsView, error) {
+	settings, err := client.WellbeingSettings.FindUnique(
+		db.WellbeingSettings.UserID.Equals(userID),
+	).Exec(ctx)
+
+	if err == db.ErrNotFound {
+		return WellbeingSettingsView{
+			Enabled:      true,
+			WindowDays:   defaultWellbeingWindowDays,
+			Threshold:    defaultWellbeingThreshold,
+			MinLowDays:   defaultWellbeingMinLowDays,
+			CooldownDays: defaultWellbeingCooldownDays,
+			Country:      defaultWellbeingCountry,
+		}, nil
+	}
+	if err != nil {
+		return WellbeingSettingsView{}, err
+	}
+
+	return WellbeingSettingsView{
+		Enabled:      settings.Enabled,
+		WindowDays:   settings.WindowDays,
+		Threshold:    settings.Threshold,
+		MinLowDays:   settings.MinLowDays,
+		CooldownDays: settings.CooldownDays,
+		Country:      settings.Country,
+	}, nil
+}
+
+// evaluateWellbeing runs the wellbeing rules over the user's recent days and
+// stores a check-in for every finding whose rule has not fired within the
+// cooldown
+func evaluateWellbeing(ctx context.Context, client *db.PrismaClient, userID int) ([]db.CheckInModel, error) {
+	settings, err := wellbeingSettingsFor(ctx, client, userID)
+	if err != nil || !settings.Enabled {
+		return nil, err
+	}
+
+	loc, err := userLocation(ctx, client, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	// Two windows, so trends can be compared with the window before
+	rows, err := queryMoodBuckets(ctx, client, userID, today.AddDate(0, 0, -2*settings.WindowDays), today.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]*bucketTotals)
+	for _, row := range rows {
+		date := bucketTime(row.Bucket, loc).Format("2006-01-02")
+		if totals[date] == nil {
+			totals[date] = &bucketTotals{}
+		}
+		totals[date].add(row)
+	}
+
+	var days []wellbeing.Day
+	for date, total := range totals {
+		average := total.average()
+		if average == nil {
+			continue
+		}
+		day, _ := time.ParseInLocation("2006-01-02", date, loc)
+		days = append(days, wellbeing.Day{Date: day, Valence: *average})
+	}
+	sort.Slice(days, func(i, j int) bool {
+		return days[i].Date.Before(days[j].Date)
+	})
+
+	findings := wellbeing.Evaluate(days, today, wellbeing.Settings{
+		WindowDays: settings.WindowDays,
+		Threshold:  settings.Threshold,
+		MinLowDays: settings.MinLowDays,
+	})
+
+	var created []db.CheckInModel
+	for _, finding := range findings {
+		_, err := client.CheckIn.FindFirst(
+			db.CheckIn.User.Where(
+				db.User.ID.Equals(userID),
+			),
+			db.CheckIn.Rule.Equals(finding.Rule),
+			db.CheckIn.CreatedAt.Gte(now.AddDate(0, 0, -settings.CooldownDays)),
+		).Exec(ctx)
+
+		if err == nil {
+			continue
+		}
+		if err != db.ErrNotFound {
+			return created, err
+		}
+
+		checkIn, err := client.CheckIn.CreateOne(
+			db.CheckIn.User.Link(
+				db.User.ID.Equals(userID),
+			),
+			db.CheckIn.Rule.Set(finding.Rule),
+			db.CheckIn.Message.Set(finding.Message),
+			db.CheckIn.WindowStart.Set(finding.WindowStart.Format("2006-01-02")),
+			db.CheckIn.WindowEnd.Set(finding.WindowEnd.Format("2006-01-02")),
+			db.CheckIn.AverageValence.Set(finding.AverageValence),
+		).Exec(ctx)
+
+		if err != nil {
+			return created, err
+		}
+		created = append(created, *checkIn)
+	}
+
+	return created, nil
+}
+
+// supportResourcesFor lists the resources for country followed by the
+// international ones
+func supportResourcesFor(ctx context.Context, client *db.PrismaClient, country string) ([]db.SupportResourceModel, error) {
+	resources, err := client.SupportResource.FindMany(
+		db.SupportResource.Country.In([]string{country, internationalResources}),
+	).OrderBy(
+		db.SupportResource.Position.Order(db.SortOrderAsc),
+	).Exec(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(resources, func(i, j int) bool {
+		return resources[i].Country != internationalResources && resources[j].Country == internationalResources
+	})
+	return resources, nil
+}
+
+func GetWellbeingSettings(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		settings, err := wellbeingSettingsFor(c.Request.Context(), client, int(userID.(uint)))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch wellbeing settings"})
+			return
+		}
+
+		c.JSON(http.StatusOK, settings)
+	}
+}
+
+// UpdateWellbeingSettings changes the check-in thresholds. Only the fields
+// present in the input are changed; "enabled": false opts out of check-ins.
+func UpdateWellbeingSettings(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input WellbeingSettingsInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+
+		settings, err := wellbeingSettingsFor(c.Request.Context(), client, userIDInt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch wellbeing settings"})
+			return
+		}
+
+		if input.Enabled != nil {
+			settings.Enabled = *input.Enabled
+		}
+		if input.WindowDays != nil {
+			settings.WindowDays = *input.WindowDays
+		}
+		if input.Threshold != nil {
+			settings.Threshold = *input.Threshold
+		}
+		if input.MinLowDays != nil {
+			settings.MinLowDays = *input.MinLowDays
+		}
+		if input.CooldownDays != nil {
+			settings.CooldownDays = *input.CooldownDays
+		}
+		if input.Country != nil {
+			settings.Country = strings.ToUpper(strings.TrimSpace(*input.Country))
+		}
+
+		switch {
+		case settings.WindowDays < 3 || settings.WindowDays > 30:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "windowDays must be between 3 and 30"})
+			return
+		case settings.Threshold < -1 || settings.Threshold > 1:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "threshold must be between -1 and 1"})
+			return
+		case settings.MinLowDays < 2 || settings.MinLowDays > settings.WindowDays:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "minLowDays must be between 2 and windowDays"})
+			return
+		case settings.CooldownDays < 1 || settings.CooldownDays > 90:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cooldownDays must be between 1 and 90"})
+			return
+		case !countryPattern.MatchString(settings.Country):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "country must be a two-letter country code"})
+			return
+		}
+
+		_, err = client.WellbeingSettings.UpsertOne(
+			db.WellbeingSettings.UserID.Equals(userIDInt),
+		).Create(
+			db.WellbeingSettings.User.Link(
+				db.User.ID.Equals(userIDInt),
+			),
+			db.WellbeingSettings.Enabled.Set(settings.Enabled),
+			db.WellbeingSettings.WindowDays.Set(settings.WindowDays),
+			db.WellbeingSettings.Threshold.Set(settings.Threshold),
+			db.WellbeingSettings.MinLowDays.Set(settings.MinLowDays),
+			db.WellbeingSettings.CooldownDays.Set(settings.CooldownDays),
+			db.WellbeingSettings.Country.Set(settings.Country),
+		).Update(
+			db.WellbeingSettings.Enabled.Set(settings.Enabled),
+			db.WellbeingSettings.WindowDays.Set(settings.WindowDays),
+			db.WellbeingSettings.Threshold.Set(settings.Threshold),
+			db.WellbeingSettings.MinLowDays.Set(settings.MinLowDays),
+			db.WellbeingSettings.CooldownDays.Set(settings.CooldownDays),
+			db.WellbeingSettings.Country.Set(settings.Country),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update wellbeing settings: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, settings)
+	}
+}
+
+// GetCheckIns lists the user's check-ins, newest first, together with the
+// support resources for the user's country. Dismissed check-ins are only
+// included with ?includeDismissed=true.
+func GetCheckIns(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+
+		filters := []db.CheckInWhereParam{
+			db.CheckIn.User.Where(
+				db.User.ID.Equals(userIDInt),
+			),
+		}
+		if c.Query("includeDismissed") != "true" {
+			filters = append(filters, db.CheckIn.DismissedAt.IsNull())
+		}
+
+		checkIns, err := client.CheckIn.FindMany(
+			filters...,
+		).OrderBy(
+			db.CheckIn.CreatedAt.Order(db.SortOrderDesc),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch check-ins"})
+			return
+		}
+
+		settings, err := wellbeingSettingsFor(c.Request.Context(), client, userIDInt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch wellbeing settings"})
+			return
+		}
+
+		resources, err := supportResourcesFor(c.Request.Context(), client, settings.Country)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch support resources"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"checkIns": checkIns, "resources": resources})
+	}
+}
+
+func DismissCheckIn(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		checkInID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check-in ID"})
+			return
+		}
+
+		_, err = client.CheckIn.FindFirst(
+			db.CheckIn.ID.Equals(checkInID),
+			db.CheckIn.User.Where(
+				db.User.ID.Equals(int(userID.(uint))),
+			),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			if err == db.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Check-in not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch check-in"})
+			}
+			return
+		}
+
+		checkIn, err := client.CheckIn.FindUnique(
+			db.CheckIn.ID.Equals(checkInID),
+		).Update(
+			db.CheckIn.DismissedAt.Set(time.Now()),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to dismiss check-in"})
+			return
+		}
+
+		c.JSON(http.StatusOK, checkIn)
+	}
+}
+
+// GetSupportResources lists support resources for ?country=, defaulting to
+// the country in the user's wellbeing settings
+func GetSupportResources(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		country := strings.ToUpper(c.Query("country"))
+		if country == "" {
+			settings, err := wellbeingSettingsFor(c.Request.Context(), client, int(userID.(uint)))
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch wellbeing settings"})
+				return
+			}
+			country = settings.Country
+		} else if !countryPattern.MatchString(country) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "country must be a two-letter country code"})
+			return
+		}
+
+		resources, err := supportResourcesFor(c.Request.Context(), client, country)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch support resources"})
+			return
+		}
+
+		c.JSON(http.StatusOK, resources)
+	}
+}
@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"api/prisma/db"
+	"api/report"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	yearReviewTop = 5
+	// minMonthMoods keeps a month with one or two entries from being named
+	// the best or worst of the year
+	minMonthMoods = 3
+)
+
+// GetYearReview returns the recap of ?year= (the current year by default) as
+// JSON, or as a standalone HTML page with ?format=html. The page is meant to
+// be shared, so it leaves out entry descriptions unless ?descriptions=true.
+func GetYearReview(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			return
+		}
+
+		userIDInt := int(userID.(uint))
+
+		format := c.DefaultQuery("format", "json")
+		if format != "json" && format != "html" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Format must be json or html"})
+			return
+		}
+
+		user, err := client.User.FindUnique(
+			db.User.ID.Equals(userIDInt),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+			return
+		}
+
+		loc, err := time.LoadLocation(user.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+		now := time.Now().In(loc)
+
+		year := now.Year()
+		if yearStr := c.Query("year"); yearStr != "" {
+			year, err = strconv.Atoi(yearStr)
+			if err != nil || year < 1970 || year > now.Year() {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid year"})
+				return
+			}
+		}
+
+		from := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+		to := from.AddDate(1, 0, 0)
+
+		moodTypes, err := moodTypesByEmoji(c.Request.Context(), client, userIDInt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch mood types"})
+			return
+		}
+
+		stats, err := computeMoodPeriodStats(c.Request.Context(), client, userIDInt, from, to, moodTypes)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute year in review: " + err.Error()})
+			return
+		}
+
+		review := report.YearReview{
+			Username:       user.Username,
+			Year:           year,
+			Timezone:       loc.String(),
+			MoodCount:      stats.Count,
+			AverageValence: stats.AverageValence,
+			TopMoodTypes:   []report.MoodTypeCount{},
+			GeneratedAt:    now,
+		}
+
+		for _, month := range stats.Monthly {
+			summary := report.MonthSummary{Month: month.Key, Count: month.Count, AverageValence: month.AverageValence}
+			review.Months = append(review.Months, summary)
+			if summary.AverageValence == nil || summary.Count < minMonthMoods {
+				continue
+			}
+			if review.BestMonth == nil || *summary.AverageValence > *review.BestMonth.AverageValence {
+				best := summary
+				review.BestMonth = &best
+			}
+			if review.WorstMonth == nil || *summary.AverageValence < *review.WorstMonth.AverageValence {
+				worst := summary
+				review.WorstMonth = &worst
+			}
+		}
+
+		for _, share := range stats.Distribution {
+			if len(review.TopMoodTypes) == yearReviewTop {
+				break
+			}
+			review.TopMoodTypes = append(review.TopMoodTypes, report.MoodTypeCount{Emoji: share.Emoji, Label: share.Label, Share: share.Share})
+		}
+
+		tagCounts, err := queryTagCounts(c.Request.Context(), client, userIDInt, from, to)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute year in review: " + err.Error()})
+			return
+		}
+
+		review.TopTags, err = topTagCounts(c.Request.Context(), client, userIDInt, tagCounts, yearReviewTop)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+			return
+		}
+
+		logged, err := loggedDays(c.Request.Context(), client, userIDInt, to, loc, false)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute year in review: " + err.Error()})
+			return
+		}
+		for date := range logged {
+			if date[:4] != strconv.Itoa(year) {
+				delete(logged, date)
+			}
+		}
+		review.DaysLogged = len(logged)
+
+		frozen, err := frozenDays(c.Request.Context(), client, userIDInt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch streak freezes"})
+			return
+		}
+
+		// A finished year is walked up to its last day, the current one up to today
+		last := to.AddDate(0, 0, -1)
+		if today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc); today.Before(last) {
+			last = today
+		}
+		streaks := computeStreakStats(logged, frozen, from, to, last)
+		review.LongestStreak = report.Streak{Days: streaks.LongestStreak, From: streaks.LongestStreakStart, To: streaks.LongestStreakEnd}
+
+		foodLogs, err := queryFoodLogs(c.Request.Context(), client, userIDInt, from, to)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch food logs: " + err.Error()})
+			return
+		}
+		review.TopFoods, _, _ = summarizeFoodLogs(foodLogs, loc, yearReviewTop)
+
+		moods, err := client.Mood.FindMany(
+			db.Mood.User.Where(
+				db.User.ID.Equals(userIDInt),
+			),
+			db.Mood.CreatedAt.Gte(from),
+			db.Mood.CreatedAt.Lt(to),
+		).Exec(c.Request.Context())
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch moods"})
+			return
+		}
+		review.Notable = notableEntries(moods, loc, 3)
+
+		if format == "html" {
+			if c.Query("descriptions") != "true" {
+				for i := range review.Notable {
+					review.Notable[i].Description = ""
+				}
+			}
+			html, err := report.YearReviewHTML(review)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render year in review"})
+				return
+			}
+			c.Data(http.StatusOK, "text/html; charset=utf-8", html)
+			return
+		}
+
+		c.JSON(http.StatusOK, review)
+	}
+}
@@ -4,7 +4,10 @@ import (
 	handler "api/handlers"
 	"api/middleware"
 	"api/prisma/db"
+	"api/storage"
 	"log"
+	"time"
+	_ "time/tzdata"
 
 	"github.com/gin-gonic/gin"
 )
@@ -22,6 +25,15 @@ func main() {
 		}
 	}()
 
+	// Ek dosyaları için depolama (STORAGE_DRIVER=local|s3)
+	store, err := storage.NewFromEnv()
+	if err != nil {
+		log.Fatal("Depolama başlatma hatası:", err)
+	}
+
+	// Geçen hafta ve ayın raporlarını arka planda hazırla
+	go handler.ScheduleReports(client, store, time.Hour)
+
 	// Gin framework'u kullanarak router oluştur
 	r := gin.Default()
 
@@ -40,17 +52,116 @@ func main() {
 	{
 		// User routes
 		protected.GET("/user", handler.GetUserInfo(client))
-		protected.DELETE("/user", handler.DeleteUser(client))
+		protected.DELETE("/user", handler.DeleteUser(client, store))
 		protected.PUT("/user", handler.UpdateUser(client))
+		protected.PUT("/user/location-privacy", handler.UpdateLocationPrivacy(client))
 
 		// Mood routes
 		moodsGroup := protected.Group("/moods")
 		{
 			moodsGroup.POST("", handler.CreateMood(client))
 			moodsGroup.GET("", handler.GetMoods(client))
+			moodsGroup.GET("/nearby", handler.GetNearbyMoods(client))
 			moodsGroup.GET("/:id", handler.GetMoodByID(client))
-			moodsGroup.DELETE("/:id", handler.DeleteMood(client))
+			moodsGroup.PUT("/:id", handler.UpdateMood(client, store))
+			moodsGroup.DELETE("/:id", handler.DeleteMood(client, store))
 			moodsGroup.GET("/date/:date", handler.GetMoodByDate(client))
+
+			// Attachment routes
+			moodsGroup.POST("/:id/attachments", handler.UploadAttachment(client, store))
+			moodsGroup.GET("/:id/attachments", handler.GetAttachments(client))
+			moodsGroup.GET("/:id/attachments/:attachmentId", handler.DownloadAttachment(client, store))
+			moodsGroup.DELETE("/:id/attachments/:attachmentId", handler.DeleteAttachment(client, store))
+		}
+
+		// Mood type routes
+		moodTypesGroup := protected.Group("/mood-types")
+		{
+			moodTypesGroup.GET("", handler.GetMoodTypes(client))
+			moodTypesGroup.POST("", handler.CreateMoodType(client))
+			moodTypesGroup.PUT("/order", handler.ReorderMoodTypes(client))
+			moodTypesGroup.PUT("/:id", handler.UpdateMoodType(client))
+			moodTypesGroup.POST("/:id/archive", handler.SetMoodTypeArchived(client, true))
+			moodTypesGroup.POST("/:id/unarchive", handler.SetMoodTypeArchived(client, false))
+		}
+
+		// Place routes
+		placesGroup := protected.Group("/places")
+		{
+			placesGroup.GET("", handler.GetPlaces(client))
+			placesGroup.POST("", handler.CreatePlace(client))
+			placesGroup.GET("/stats", handler.GetPlaceStats(client))
+			placesGroup.PUT("/:id", handler.UpdatePlace(client))
+			placesGroup.DELETE("/:id", handler.DeletePlace(client))
+		}
+
+		// Stats routes
+		statsGroup := protected.Group("/stats")
+		{
+			statsGroup.GET("/calendar", handler.GetCalendarStats(client))
+			statsGroup.GET("/moods", handler.GetMoodStats(client, ""))
+			statsGroup.GET("/moods/distribution", handler.GetMoodStats(client, "distribution"))
+			statsGroup.GET("/moods/weekdays", handler.GetMoodStats(client, "weekdays"))
+			statsGroup.GET("/moods/hours", handler.GetMoodStats(client, "hours"))
+			statsGroup.GET("/moods/trend", handler.GetMoodStats(client, "trend"))
+			statsGroup.GET("/food-mood", handler.GetFoodMoodStats(client))
+			statsGroup.GET("/tags", handler.GetTagStats(client, ""))
+			statsGroup.GET("/tags/co-occurrence", handler.GetTagStats(client, "co-occurrence"))
+			statsGroup.GET("/tags/impact", handler.GetTagStats(client, "impact"))
+			statsGroup.GET("/tags/trending", handler.GetTagStats(client, "trending"))
+			statsGroup.GET("/tags/foods", handler.GetTagStats(client, "foods"))
+			statsGroup.GET("/streaks", handler.GetStreakStats(client))
+			statsGroup.GET("/year-in-review", handler.GetYearReview(client))
+			statsGroup.GET("/sentiment", handler.GetSentimentStats(client))
+			statsGroup.GET("/assessments", handler.GetAssessmentStats(client))
+		}
+
+		// Streak freeze routes
+		streakFreezesGroup := protected.Group("/streak-freezes")
+		{
+			streakFreezesGroup.GET("", handler.GetStreakFreezes(client))
+			streakFreezesGroup.POST("", handler.CreateStreakFreeze(client))
+			streakFreezesGroup.DELETE("/:id", handler.DeleteStreakFreeze(client))
+		}
+
+		// Report routes
+		reportsGroup := protected.Group("/reports")
+		{
+			reportsGroup.GET("", handler.GetReports(client))
+			reportsGroup.GET("/:period", handler.GetReport(client, store))
+		}
+
+		// Assessment routes
+		assessmentsGroup := protected.Group("/assessments")
+		{
+			assessmentsGroup.GET("/questionnaires", handler.GetQuestionnaires())
+			assessmentsGroup.GET("/questionnaires/:id", handler.GetQuestionnaire())
+			assessmentsGroup.POST("", handler.CreateAssessment(client, store))
+			assessmentsGroup.GET("", handler.GetAssessments(client))
+			assessmentsGroup.GET("/:id", handler.GetAssessment(client))
+			assessmentsGroup.DELETE("/:id", handler.DeleteAssessment(client, store))
+		}
+
+		// Wellbeing routes
+		wellbeingGroup := protected.Group("/wellbeing")
+		{
+			wellbeingGroup.GET("/settings", handler.GetWellbeingSettings(client))
+			wellbeingGroup.PUT("/settings", handler.UpdateWellbeingSettings(client))
+			wellbeingGroup.GET("/check-ins", handler.GetCheckIns(client))
+			wellbeingGroup.POST("/check-ins/:id/dismiss", handler.DismissCheckIn(client))
+			wellbeingGroup.GET("/resources", handler.GetSupportResources(client))
+		}
+
+		// Admin routes
+		adminGroup := protected.Group("/admin", middleware.AdminMiddleware(client))
+		{
+			adminGroup.GET("/support-resources", handler.GetAllSupportResources(client))
+			adminGroup.POST("/support-resources", handler.CreateSupportResource(client))
+			adminGroup.PUT("/support-resources/:id", handler.UpdateSupportResource(client))
+			adminGroup.DELETE("/support-resources/:id", handler.DeleteSupportResource(client))
+			adminGroup.GET("/tags", handler.GetModeratedTags(client))
+			adminGroup.POST("/tags/:id/hide", handler.SetTagHidden(client, true))
+			adminGroup.POST("/tags/:id/unhide", handler.SetTagHidden(client, false))
 		}
 
 		// Tag routes
@@ -58,6 +169,26 @@ func main() {
 		{
 			tagsGroup.POST("", handler.CreateTag(client))
 			tagsGroup.GET("", handler.GetAllTags(client))
+			tagsGroup.GET("/tree", handler.GetTagTree(client))
+			tagsGroup.GET("/public", handler.GetPublicTags(client))
+			tagsGroup.POST("/suggest", handler.SuggestTags(client))
+			tagsGroup.POST("/adopt", handler.AddUserTag(client))
+			tagsGroup.POST("/merge", handler.MergeTags(client, store))
+			tagsGroup.PUT("/:id", handler.UpdateTag(client))
+			tagsGroup.DELETE("/:id", handler.DeleteTag(client, store))
+			tagsGroup.POST("/:id/archive", handler.SetTagArchived(client, true))
+			tagsGroup.POST("/:id/unarchive", handler.SetTagArchived(client, false))
+		}
+
+		// Tag group routes
+		tagGroupsGroup := protected.Group("/tag-groups")
+		{
+			tagGroupsGroup.GET("", handler.GetTagGroups(client))
+			tagGroupsGroup.POST("", handler.CreateTagGroup(client))
+			tagGroupsGroup.PUT("/order", handler.ReorderTagGroups(client))
+			tagGroupsGroup.PUT("/:id", handler.UpdateTagGroup(client))
+			tagGroupsGroup.DELETE("/:id", handler.DeleteTagGroup(client))
+			tagGroupsGroup.PUT("/:id/order", handler.ReorderGroupTags(client))
 		}
 
 		// Category routes
@@ -75,10 +206,43 @@ func main() {
 		{
 			foodGroup.POST("", handler.CreateFood(client))
 			foodGroup.GET("", handler.GetFoods(client))
+			foodGroup.GET("/micronutrients", handler.GetMicronutrients())
+			foodGroup.GET("/units", handler.GetUnits())
 			foodGroup.PUT("/:id", handler.UpdateFood(client))
 			foodGroup.DELETE("/:id", handler.DeleteFood(client))
-			foodGroup.POST("/multiple", handler.AddMultipleUserFoods(client))
+			foodGroup.POST("/multiple", handler.AddMultipleUserFoods(client, store))
+
+		}
+
+		// Food diary routes
+		diaryGroup := protected.Group("/diary")
+		{
+			diaryGroup.POST("", handler.AddUserFood(client, store))
+			diaryGroup.GET("", handler.GetDiary(client))
+			diaryGroup.GET("/:date", handler.GetUserFoodsByDate(client))
+			diaryGroup.PUT("/:id", handler.UpdateUserFood(client, store))
+			diaryGroup.DELETE("/:id", handler.DeleteUserFood(client, store))
+		}
 
+		// Meal routes
+		mealSlotsGroup := protected.Group("/meal-slots")
+		{
+			mealSlotsGroup.GET("", handler.GetMealSlots(client))
+			mealSlotsGroup.POST("", handler.CreateMealSlot(client))
+			mealSlotsGroup.POST("/defaults", handler.AddDefaultMealSlots(client))
+			mealSlotsGroup.PUT("/order", handler.ReorderMealSlots(client))
+			mealSlotsGroup.PUT("/:id", handler.UpdateMealSlot(client))
+			mealSlotsGroup.DELETE("/:id", handler.DeleteMealSlot(client))
+		}
+
+		mealsGroup := protected.Group("/meals")
+		{
+			mealsGroup.GET("", handler.GetMeals(client))
+			mealsGroup.POST("", handler.CreateMeal(client))
+			mealsGroup.GET("/:id", handler.GetMealByID(client))
+			mealsGroup.PUT("/:id", handler.UpdateMeal(client))
+			mealsGroup.DELETE("/:id", handler.DeleteMeal(client))
+			mealsGroup.POST("/:id/copy", handler.CopyMeal(client, store))
 		}
 	}
 
@@ -0,0 +1,141 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+const (
+	KindPhoto = "photo"
+	KindAudio = "audio"
+
+	thumbnailSize = 256
+
+	// maxPixels bounds the decoded size of a photo. A small compressed file
+	// can describe a huge image, so the size is checked before decoding.
+	maxPixels = 36_000_000
+)
+
+// ErrUnsupportedType is returned for uploads that are neither a supported
+// photo nor a supported audio format
+var ErrUnsupportedType = errors.New("unsupported file type")
+
+// ErrImageTooLarge is returned for photos with more than maxPixels pixels
+var ErrImageTooLarge = errors.New("image is larger than 36 megapixels")
+
+// Sniff detects the content type from the file's leading bytes rather than
+// trusting the name or header sent by the client
+func Sniff(data []byte) (contentType string, kind string, err error) {
+	contentType = http.DetectContentType(data)
+
+	switch contentType {
+	case "image/jpeg", "image/png":
+		return contentType, KindPhoto, nil
+	case "audio/mpeg", "audio/wave", "audio/aiff", "application/ogg":
+		if contentType == "application/ogg" {
+			contentType = "audio/ogg"
+		}
+		return contentType, KindAudio, nil
+	case "video/mp4":
+		// Voice memos recorded on phones are usually M4A, which shares the
+		// MP4 container signature
+		if len(data) >= 12 && bytes.Equal(data[8:12], []byte("M4A ")) {
+			return "audio/mp4", KindAudio, nil
+		}
+	}
+	return contentType, "", ErrUnsupportedType
+}
+
+// StripMetadata re-encodes a photo, dropping EXIF data (including GPS
+// coordinates) and any other ancillary chunks. The EXIF orientation is
+// applied first, so photos stay upright without it.
+func StripMetadata(data []byte, contentType string) ([]byte, image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	if int64(config.Width)*int64(config.Height) > maxPixels {
+		return nil, nil, ErrImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	if contentType == "image/jpeg" {
+		img = orient(img, exifOrientation(data))
+	}
+
+	encoded, err := encode(img, contentType)
+	if err != nil {
+		return nil, nil, err
+	}
+	return encoded, img, nil
+}
+
+// Thumbnail scales an image down so its longest side is at most 256 pixels
+// and encodes it as JPEG
+func Thumbnail(img image.Image) ([]byte, error) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return nil, errors.New("empty image")
+	}
+
+	scale := float64(thumbnailSize) / float64(max(width, height))
+	if scale > 1 {
+		scale = 1
+	}
+	thumbWidth := max(1, int(float64(width)*scale))
+	thumbHeight := max(1, int(float64(height)*scale))
+
+	thumb := image.NewRGBA(image.Rect(0, 0, thumbWidth, thumbHeight))
+	for y := 0; y < thumbHeight; y++ {
+		srcY0 := bounds.Min.Y + y*height/thumbHeight
+		srcY1 := max(srcY0+1, bounds.Min.Y+(y+1)*height/thumbHeight)
+		for x := 0; x < thumbWidth; x++ {
+			srcX0 := bounds.Min.X + x*width/thumbWidth
+			srcX1 := max(srcX0+1, bounds.Min.X+(x+1)*width/thumbWidth)
+			thumb.Set(x, y, averageColor(img, srcX0, srcY0, srcX1, srcY1))
+		}
+	}
+
+	return encode(thumb, "image/jpeg")
+}
+
+// averageColor box-filters the source pixels covered by one thumbnail pixel
+func averageColor(img image.Image, x0, y0, x1, y1 int) color.RGBA {
+	var r, g, b, a, n uint64
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			cr, cg, cb, ca := img.At(x, y).RGBA()
+			r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+			n++
+		}
+	}
+	return color.RGBA{
+		R: uint8(r / n >> 8),
+		G: uint8(g / n >> 8),
+		B: uint8(b / n >> 8),
+		A: uint8(a / n >> 8),
+	}
+}
+
+func encode(img image.Image, contentType string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if contentType == "image/png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
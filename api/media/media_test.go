@@ -0,0 +1,128 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// withOrientation inserts an EXIF segment with the given orientation after
+// the start marker of a JPEG
+func withOrientation(t *testing.T, jpg []byte, orientation uint16) []byte {
+	t.Helper()
+	tiff := []byte("MM\x00\x2A\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, 0x0112)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3)
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, segment...)
+	return append(out, jpg[2:]...)
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExifOrientation(t *testing.T) {
+	jpg := encodeJPEG(t, image.NewRGBA(image.Rect(0, 0, 4, 2)))
+
+	if got := exifOrientation(jpg); got != 1 {
+		t.Errorf("no EXIF: got orientation %d, want 1", got)
+	}
+	for _, orientation := range []uint16{1, 3, 6, 8} {
+		if got := exifOrientation(withOrientation(t, jpg, orientation)); got != int(orientation) {
+			t.Errorf("got orientation %d, want %d", got, orientation)
+		}
+	}
+	if got := exifOrientation([]byte("not a jpeg")); got != 1 {
+		t.Errorf("garbage: got orientation %d, want 1", got)
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// A 2x1 image: red on the left, blue on the right
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, red)
+	img.Set(1, 0, blue)
+
+	tests := []struct {
+		orientation int
+		width       int
+		height      int
+		first       color.RGBA // the pixel at 0,0
+	}{
+		{1, 2, 1, red},
+		{2, 2, 1, blue},
+		{3, 2, 1, blue},
+		{4, 2, 1, red},
+		{5, 1, 2, red},
+		{6, 1, 2, red},
+		{7, 1, 2, blue},
+		{8, 1, 2, blue},
+	}
+	for _, test := range tests {
+		upright := orient(img, test.orientation)
+		bounds := upright.Bounds()
+		if bounds.Dx() != test.width || bounds.Dy() != test.height {
+			t.Errorf("orientation %d: got %dx%d, want %dx%d", test.orientation, bounds.Dx(), bounds.Dy(), test.width, test.height)
+			continue
+		}
+		if got := color.RGBAModel.Convert(upright.At(0, 0)); got != test.first {
+			t.Errorf("orientation %d: got %v at 0,0, want %v", test.orientation, got, test.first)
+		}
+	}
+}
+
+func TestStripMetadataAppliesOrientation(t *testing.T) {
+	jpg := withOrientation(t, encodeJPEG(t, image.NewRGBA(image.Rect(0, 0, 40, 20))), 6)
+
+	stripped, img, err := StripMetadata(jpg, "image/jpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != 20 || bounds.Dy() != 40 {
+		t.Errorf("got %dx%d, want 20x40", bounds.Dx(), bounds.Dy())
+	}
+	if exifOrientation(stripped) != 1 || bytes.Contains(stripped, []byte("Exif")) {
+		t.Error("EXIF data was not stripped")
+	}
+}
+
+func TestStripMetadataRejectsHugeImages(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+
+	// Claim 10000x10000 pixels in the IHDR chunk, which follows the 8 byte
+	// signature, and fix its checksum
+	data := buf.Bytes()
+	binary.BigEndian.PutUint32(data[16:], 10000)
+	binary.BigEndian.PutUint32(data[20:], 10000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+
+	if _, _, err := StripMetadata(data, "image/png"); err != ErrImageTooLarge {
+		t.Errorf("got error %v, want ErrImageTooLarge", err)
+	}
+}
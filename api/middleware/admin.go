@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"api/prisma/db"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminMiddleware lets only admins through. It must run after AuthMiddleware.
+func AdminMiddleware(client *db.PrismaClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+			c.Abort()
+			return
+		}
+
+		user, err := client.User.FindUnique(
+			db.User.ID.Equals(int(userID.(uint))),
+		).Exec(c.Request.Context())
+
+		if err != nil || !user.IsAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
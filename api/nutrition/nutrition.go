@@ -0,0 +1,159 @@
+// Package nutrition describes the nutrients tracked for foods and adds and
+// scales them with exact decimal arithmetic
+package nutrition
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// Nutrient values of a food are given per serving or per 100 g
+const (
+	BasisServing = "serving"
+	Basis100g    = "100g"
+)
+
+var (
+	ErrUnknownBasis        = errors.New("unknown nutrient basis")
+	ErrMissingServingGrams = errors.New("servingGrams is required for nutrients per 100 g")
+	ErrNegativeAmount      = errors.New("nutrient amounts must not be negative")
+	ErrUnknownNutrient     = errors.New("unknown micronutrient")
+)
+
+var hundred = decimal.NewFromInt(100)
+
+// Micronutrient is an optional nutrient a food can list
+type Micronutrient struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	Unit string `json:"unit"`
+}
+
+// Micronutrients are the ones foods can list, in display order
+var Micronutrients = []Micronutrient{
+	{Key: "saturatedFat", Name: "Saturated fat", Unit: "g"},
+	{Key: "cholesterol", Name: "Cholesterol", Unit: "mg"},
+	{Key: "potassium", Name: "Potassium", Unit: "mg"},
+	{Key: "calcium", Name: "Calcium", Unit: "mg"},
+	{Key: "iron", Name: "Iron", Unit: "mg"},
+	{Key: "magnesium", Name: "Magnesium", Unit: "mg"},
+	{Key: "zinc", Name: "Zinc", Unit: "mg"},
+	{Key: "vitaminA", Name: "Vitamin A", Unit: "µg"},
+	{Key: "vitaminC", Name: "Vitamin C", Unit: "mg"},
+	{Key: "vitaminD", Name: "Vitamin D", Unit: "µg"},
+	{Key: "vitaminB12", Name: "Vitamin B12", Unit: "µg"},
+	{Key: "folate", Name: "Folate", Unit: "µg"},
+}
+
+// FindMicronutrient returns the micronutrient with key
+func FindMicronutrient(key string) (Micronutrient, bool) {
+	for _, m := range Micronutrients {
+		if m.Key == key {
+			return m, true
+		}
+	}
+	return Micronutrient{}, false
+}
+
+// Facts are the nutrients of an amount of food. Protein, carbohydrate, fat,
+// fiber and sugar are in grams, sodium in milligrams and micronutrients in
+// the unit of their definition.
+type Facts struct {
+	Calories       decimal.Decimal            `json:"calories"`
+	Protein        decimal.Decimal            `json:"protein"`
+	Carbohydrate   decimal.Decimal            `json:"carbohydrate"`
+	Fat            decimal.Decimal            `json:"fat"`
+	Fiber          decimal.Decimal            `json:"fiber"`
+	Sugar          decimal.Decimal            `json:"sugar"`
+	Sodium         decimal.Decimal            `json:"sodium"`
+	Micronutrients map[string]decimal.Decimal `json:"micronutrients"`
+}
+
+// Validate checks that no amount is negative and every micronutrient is known
+func (f Facts) Validate() error {
+	for _, amount := range []decimal.Decimal{f.Calories, f.Protein, f.Carbohydrate, f.Fat, f.Fiber, f.Sugar, f.Sodium} {
+		if amount.IsNegative() {
+			return ErrNegativeAmount
+		}
+	}
+	for key, amount := range f.Micronutrients {
+		if _, ok := FindMicronutrient(key); !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownNutrient, key)
+		}
+		if amount.IsNegative() {
+			return ErrNegativeAmount
+		}
+	}
+	return nil
+}
+
+// Add returns the sum of f and other
+func (f Facts) Add(other Facts) Facts {
+	sum := Facts{
+		Calories:       f.Calories.Add(other.Calories),
+		Protein:        f.Protein.Add(other.Protein),
+		Carbohydrate:   f.Carbohydrate.Add(other.Carbohydrate),
+		Fat:            f.Fat.Add(other.Fat),
+		Fiber:          f.Fiber.Add(other.Fiber),
+		Sugar:          f.Sugar.Add(other.Sugar),
+		Sodium:         f.Sodium.Add(other.Sodium),
+		Micronutrients: make(map[string]decimal.Decimal, len(f.Micronutrients)),
+	}
+	for key, amount := range f.Micronutrients {
+		sum.Micronutrients[key] = amount
+	}
+	for key, amount := range other.Micronutrients {
+		sum.Micronutrients[key] = sum.Micronutrients[key].Add(amount)
+	}
+	return sum
+}
+
+// Scale returns f multiplied by factor
+func (f Facts) Scale(factor decimal.Decimal) Facts {
+	scaled := Facts{
+		Calories:       f.Calories.Mul(factor),
+		Protein:        f.Protein.Mul(factor),
+		Carbohydrate:   f.Carbohydrate.Mul(factor),
+		Fat:            f.Fat.Mul(factor),
+		Fiber:          f.Fiber.Mul(factor),
+		Sugar:          f.Sugar.Mul(factor),
+		Sodium:         f.Sodium.Mul(factor),
+		Micronutrients: make(map[string]decimal.Decimal, len(f.Micronutrients)),
+	}
+	for key, amount := range f.Micronutrients {
+		scaled.Micronutrients[key] = amount.Mul(factor)
+	}
+	return scaled
+}
+
+// Zero returns facts with every amount zero
+func Zero() Facts {
+	return Facts{Micronutrients: map[string]decimal.Decimal{}}
+}
+
+// ValidateBasis checks basis and, for nutrients per 100 g, the weight of one
+// serving
+func ValidateBasis(basis string, servingGrams *decimal.Decimal) error {
+	switch basis {
+	case BasisServing:
+		return nil
+	case Basis100g:
+		if servingGrams == nil || !servingGrams.IsPositive() {
+			return ErrMissingServingGrams
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownBasis, basis)
+	}
+}
+
+// Factor is what nutrients given per basis are multiplied by for servings
+// servings of a food weighing servingGrams each
+func Factor(basis string, servingGrams, servings decimal.Decimal) decimal.Decimal {
+	if basis == Basis100g {
+		return servings.Mul(servingGrams).Div(hundred)
+	}
+	return servings
+}
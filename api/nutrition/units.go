@@ -0,0 +1,136 @@
+package nutrition
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// UnitServing measures food in servings of its nutrient basis. It is the
+// default unit.
+const UnitServing = "serving"
+
+// Kinds of units
+const (
+	KindMass   = "mass"
+	KindVolume = "volume"
+)
+
+var (
+	ErrUnknownUnit           = errors.New("unknown unit")
+	ErrUnitNeedsDensity      = errors.New("volume units need the food's gramsPerMl")
+	ErrUnitNeedsServingGrams = errors.New("weighed amounts need the food's servingGrams")
+	ErrInvalidQuantity       = errors.New("quantity must be positive")
+)
+
+// Unit is a metric or imperial unit. Base is grams for mass and milliliters
+// for volume.
+type Unit struct {
+	Key    string          `json:"key"`
+	Name   string          `json:"name"`
+	Kind   string          `json:"kind"`
+	System string          `json:"system"`
+	Base   decimal.Decimal `json:"base"`
+}
+
+// Units are the units quantities can be given in, besides servings and a
+// food's named portions. Volumes are US customary.
+var Units = []Unit{
+	{Key: "g", Name: "gram", Kind: KindMass, System: "metric", Base: decimal.NewFromInt(1)},
+	{Key: "kg", Name: "kilogram", Kind: KindMass, System: "metric", Base: decimal.NewFromInt(1000)},
+	{Key: "oz", Name: "ounce", Kind: KindMass, System: "imperial", Base: decimal.RequireFromString("28.349523125")},
+	{Key: "lb", Name: "pound", Kind: KindMass, System: "imperial", Base: decimal.RequireFromString("453.59237")},
+	{Key: "ml", Name: "milliliter", Kind: KindVolume, System: "metric", Base: decimal.NewFromInt(1)},
+	{Key: "l", Name: "liter", Kind: KindVolume, System: "metric", Base: decimal.NewFromInt(1000)},
+	{Key: "tsp", Name: "teaspoon", Kind: KindVolume, System: "imperial", Base: decimal.RequireFromString("4.92892159375")},
+	{Key: "tbsp", Name: "tablespoon", Kind: KindVolume, System: "imperial", Base: decimal.RequireFromString("14.78676478125")},
+	{Key: "floz", Name: "fluid ounce", Kind: KindVolume, System: "imperial", Base: decimal.RequireFromString("29.5735295625")},
+	{Key: "cup", Name: "cup", Kind: KindVolume, System: "imperial", Base: decimal.RequireFromString("236.5882365")},
+}
+
+// FindUnit returns the unit with key
+func FindUnit(key string) (Unit, bool) {
+	for _, u := range Units {
+		if u.Key == key {
+			return u, true
+		}
+	}
+	return Unit{}, false
+}
+
+// Convert converts quantity between two units of the same kind
+func Convert(quantity decimal.Decimal, from, to string) (decimal.Decimal, error) {
+	fromUnit, ok := FindUnit(from)
+	if !ok {
+		return decimal.Decimal{}, fmt.Errorf("%w: %s", ErrUnknownUnit, from)
+	}
+	toUnit, ok := FindUnit(to)
+	if !ok {
+		return decimal.Decimal{}, fmt.Errorf("%w: %s", ErrUnknownUnit, to)
+	}
+	if fromUnit.Kind != toUnit.Kind {
+		return decimal.Decimal{}, fmt.Errorf("cannot convert %s to %s", fromUnit.Name, toUnit.Name)
+	}
+	return quantity.Mul(fromUnit.Base).Div(toUnit.Base), nil
+}
+
+// Measures is what a food knows about its own size: its nutrient basis, the
+// weight of one serving, its density and its named portions in grams
+type Measures struct {
+	Basis        string
+	ServingGrams *decimal.Decimal
+	GramsPerMl   *decimal.Decimal
+	Portions     map[string]decimal.Decimal
+}
+
+// Grams is the weight of quantity in unit, or false for servings, which are
+// not weighed
+func (m Measures) Grams(quantity decimal.Decimal, unit string) (decimal.Decimal, bool, error) {
+	if !quantity.IsPositive() {
+		return decimal.Decimal{}, false, ErrInvalidQuantity
+	}
+	if unit == "" || unit == UnitServing {
+		return decimal.Decimal{}, false, nil
+	}
+	if grams, ok := m.Portions[unit]; ok {
+		return quantity.Mul(grams), true, nil
+	}
+
+	u, ok := FindUnit(unit)
+	if !ok {
+		return decimal.Decimal{}, false, fmt.Errorf("%w: %s", ErrUnknownUnit, unit)
+	}
+	if u.Kind == KindVolume {
+		if m.GramsPerMl == nil {
+			return decimal.Decimal{}, false, ErrUnitNeedsDensity
+		}
+		return quantity.Mul(u.Base).Mul(*m.GramsPerMl), true, nil
+	}
+	return quantity.Mul(u.Base), true, nil
+}
+
+// FactorForGrams is what nutrients given per basis are multiplied by for
+// grams of the food
+func (m Measures) FactorForGrams(grams decimal.Decimal) (decimal.Decimal, error) {
+	if m.Basis == Basis100g {
+		return grams.Div(hundred), nil
+	}
+	if m.ServingGrams == nil || !m.ServingGrams.IsPositive() {
+		return decimal.Decimal{}, ErrUnitNeedsServingGrams
+	}
+	return grams.Div(*m.ServingGrams), nil
+}
+
+// Check validates quantity in unit for the food and returns its weight,
+// nil for servings
+func (m Measures) Check(quantity decimal.Decimal, unit string) (*decimal.Decimal, error) {
+	grams, weighed, err := m.Grams(quantity, unit)
+	if err != nil || !weighed {
+		return nil, err
+	}
+	if _, err := m.FactorForGrams(grams); err != nil {
+		return nil, err
+	}
+	return &grams, nil
+}
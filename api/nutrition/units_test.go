@@ -0,0 +1,165 @@
+package nutrition
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func decPtr(s string) *decimal.Decimal {
+	d := dec(s)
+	return &d
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		quantity string
+		from, to string
+		want     string
+	}{
+		{"1", "oz", "g", "28.3495"},
+		{"100", "g", "oz", "3.5274"},
+		{"1", "lb", "oz", "16"},
+		{"1", "kg", "lb", "2.2046"},
+		{"2.5", "kg", "g", "2500"},
+		{"1", "cup", "tbsp", "16"},
+		{"1", "tbsp", "tsp", "3"},
+		{"1", "cup", "ml", "236.5882"},
+		{"1", "l", "floz", "33.814"},
+	}
+	for _, test := range tests {
+		got, err := Convert(dec(test.quantity), test.from, test.to)
+		if err != nil {
+			t.Errorf("Convert(%s %s, %s): %v", test.quantity, test.from, test.to, err)
+			continue
+		}
+		if !got.Round(4).Equal(dec(test.want)) {
+			t.Errorf("Convert(%s %s, %s) = %s, want %s", test.quantity, test.from, test.to, got, test.want)
+		}
+	}
+
+	if _, err := Convert(dec("1"), "cup", "g"); err == nil {
+		t.Error("Convert(cup, g) succeeded, want an error across kinds")
+	}
+	if _, err := Convert(dec("1"), "stone", "g"); !errors.Is(err, ErrUnknownUnit) {
+		t.Errorf("Convert(stone, g) = %v, want ErrUnknownUnit", err)
+	}
+}
+
+func TestMeasuresGrams(t *testing.T) {
+	milk := Measures{
+		Basis:        Basis100g,
+		ServingGrams: decPtr("250"),
+		GramsPerMl:   decPtr("1.03"),
+		Portions:     map[string]decimal.Decimal{"glass": dec("200")},
+	}
+	tests := []struct {
+		name     string
+		measures Measures
+		quantity string
+		unit     string
+		want     string
+		weighed  bool
+		err      error
+	}{
+		{"serving", milk, "2", UnitServing, "", false, nil},
+		{"default unit", milk, "2", "", "", false, nil},
+		{"metric mass", milk, "1.5", "kg", "1500", true, nil},
+		{"imperial mass", milk, "2", "oz", "56.69904625", true, nil},
+		{"volume with density", milk, "100", "ml", "103", true, nil},
+		{"imperial volume", milk, "1", "cup", "243.685883595", true, nil},
+		{"volume without density", Measures{Basis: Basis100g}, "100", "ml", "", false, ErrUnitNeedsDensity},
+		{"named portion", milk, "1.5", "glass", "300", true, nil},
+		{"unknown unit", milk, "1", "bowl", "", false, ErrUnknownUnit},
+		{"zero quantity", milk, "0", "g", "", false, ErrInvalidQuantity},
+		{"negative quantity", milk, "-1", UnitServing, "", false, ErrInvalidQuantity},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			grams, weighed, err := test.measures.Grams(dec(test.quantity), test.unit)
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("Grams() error = %v, want %v", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Grams(): %v", err)
+			}
+			if weighed != test.weighed {
+				t.Fatalf("Grams() weighed = %v, want %v", weighed, test.weighed)
+			}
+			if weighed && !grams.Equal(dec(test.want)) {
+				t.Errorf("Grams() = %s, want %s", grams, test.want)
+			}
+		})
+	}
+}
+
+func TestFactors(t *testing.T) {
+	per100g := Measures{Basis: Basis100g, ServingGrams: decPtr("40")}
+	perServing := Measures{Basis: BasisServing, ServingGrams: decPtr("40")}
+
+	tests := []struct {
+		name     string
+		measures Measures
+		grams    string
+		want     string
+		err      error
+	}{
+		{"per 100 g", per100g, "250", "2.5", nil},
+		{"per serving", perServing, "60", "1.5", nil},
+		{"per serving without weight", Measures{Basis: BasisServing}, "60", "", ErrUnitNeedsServingGrams},
+		{"per serving with zero weight", Measures{Basis: BasisServing, ServingGrams: decPtr("0")}, "60", "", ErrUnitNeedsServingGrams},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			factor, err := test.measures.FactorForGrams(dec(test.grams))
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("FactorForGrams() error = %v, want %v", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FactorForGrams(): %v", err)
+			}
+			if !factor.Equal(dec(test.want)) {
+				t.Errorf("FactorForGrams(%s) = %s, want %s", test.grams, factor, test.want)
+			}
+		})
+	}
+
+	// Servings scale nutrients per serving directly and nutrients per 100 g
+	// by the serving's weight
+	if got := Factor(BasisServing, dec("40"), dec("3")); !got.Equal(dec("3")) {
+		t.Errorf("Factor(serving) = %s, want 3", got)
+	}
+	if got := Factor(Basis100g, dec("40"), dec("3")); !got.Equal(dec("1.2")) {
+		t.Errorf("Factor(100g) = %s, want 1.2", got)
+	}
+
+	// Three servings weighed out scale like three servings
+	factor, err := per100g.FactorForGrams(dec("120"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := Factor(Basis100g, dec("40"), dec("3")); !factor.Equal(want) {
+		t.Errorf("FactorForGrams(120) = %s, want %s", factor, want)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	perServing := Measures{Basis: BasisServing}
+	if grams, err := perServing.Check(dec("2"), UnitServing); err != nil || grams != nil {
+		t.Errorf("Check(2 servings) = %v, %v, want nil, nil", grams, err)
+	}
+	if _, err := perServing.Check(dec("100"), "g"); !errors.Is(err, ErrUnitNeedsServingGrams) {
+		t.Errorf("Check(100 g) without servingGrams = %v, want ErrUnitNeedsServingGrams", err)
+	}
+}
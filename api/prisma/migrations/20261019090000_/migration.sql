@@ -0,0 +1,74 @@
+-- CreateTable
+CREATE TABLE "MoodType" (
+    "id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    "label" TEXT NOT NULL,
+    "emoji" TEXT NOT NULL,
+    "color" TEXT NOT NULL,
+    "valence" REAL NOT NULL,
+    "position" INTEGER NOT NULL DEFAULT 0,
+    "archivedAt" DATETIME,
+    "userId" INTEGER NOT NULL,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" DATETIME NOT NULL,
+    CONSTRAINT "MoodType_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- RedefineTables
+PRAGMA defer_foreign_keys=ON;
+PRAGMA foreign_keys=OFF;
+CREATE TABLE "new_Mood" (
+    "id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    "title" TEXT NOT NULL,
+    "description" TEXT NOT NULL,
+    "emoji" TEXT NOT NULL,
+    "valence" REAL,
+    "moodTypeId" INTEGER,
+    "userId" INTEGER NOT NULL,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" DATETIME NOT NULL,
+    CONSTRAINT "Mood_moodTypeId_fkey" FOREIGN KEY ("moodTypeId") REFERENCES "MoodType" ("id") ON DELETE SET NULL ON UPDATE CASCADE,
+    CONSTRAINT "Mood_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+INSERT INTO "new_Mood" ("createdAt", "description", "emoji", "id", "title", "updatedAt", "userId") SELECT "createdAt", "description", "emoji", "id", "title", "updatedAt", "userId" FROM "Mood";
+DROP TABLE "Mood";
+ALTER TABLE "new_Mood" RENAME TO "Mood";
+PRAGMA foreign_keys=ON;
+PRAGMA defer_foreign_keys=OFF;
+
+-- CreateIndex
+CREATE UNIQUE INDEX "MoodType_label_userId_key" ON "MoodType"("label", "userId");
+
+-- Backfill valence for entries logged with built-in moods
+UPDATE "Mood" SET "valence" = CASE "emoji"
+    WHEN '🤩' THEN 1.0
+    WHEN '😊' THEN 0.8
+    WHEN '🥳' THEN 0.8
+    WHEN '😄' THEN 0.8
+    WHEN '🥰' THEN 0.9
+    WHEN '🙏' THEN 0.7
+    WHEN '😌' THEN 0.6
+    WHEN '⚡️' THEN 0.6
+    WHEN '😐' THEN 0.2
+    WHEN '🤔' THEN 0.0
+    WHEN '😴' THEN -0.2
+    WHEN '💭' THEN -0.1
+    WHEN '😕' THEN -0.3
+    WHEN '😮‍💨' THEN -0.4
+    WHEN '😢' THEN -0.8
+    WHEN '😰' THEN -0.7
+    WHEN '😤' THEN -0.7
+    WHEN '😟' THEN -0.7
+    WHEN '😞' THEN -0.7
+    WHEN '😠' THEN -0.9
+    WHEN '💔' THEN -0.8
+    WHEN '🤒' THEN -0.6
+    WHEN '💪' THEN 0.7
+    WHEN '🥱' THEN -0.4
+    WHEN '✨' THEN 0.5
+    WHEN '🤕' THEN -0.6
+    WHEN '💫' THEN 0.7
+    WHEN '🎨' THEN 0.6
+    WHEN '🎯' THEN 0.5
+    WHEN '🌟' THEN 0.4
+    ELSE NULL
+END;
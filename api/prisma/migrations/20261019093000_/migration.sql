@@ -0,0 +1,16 @@
+-- CreateTable
+CREATE TABLE "MoodComponent" (
+    "id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    "moodId" INTEGER NOT NULL,
+    "emoji" TEXT NOT NULL,
+    "moodTypeId" INTEGER,
+    "weight" REAL NOT NULL DEFAULT 1,
+    "valence" REAL,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT "MoodComponent_moodId_fkey" FOREIGN KEY ("moodId") REFERENCES "Mood" ("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT "MoodComponent_moodTypeId_fkey" FOREIGN KEY ("moodTypeId") REFERENCES "MoodType" ("id") ON DELETE SET NULL ON UPDATE CASCADE
+);
+
+-- Every existing entry becomes a single full-weight component
+INSERT INTO "MoodComponent" ("moodId", "emoji", "moodTypeId", "weight", "valence", "createdAt")
+SELECT "id", "emoji", "moodTypeId", 1, "valence", "createdAt" FROM "Mood";
@@ -0,0 +1,44 @@
+-- AlterTable
+ALTER TABLE "User" ADD COLUMN "locationPrecision" TEXT NOT NULL DEFAULT 'exact';
+
+-- CreateTable
+CREATE TABLE "Place" (
+    "id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    "name" TEXT NOT NULL,
+    "latitude" REAL NOT NULL,
+    "longitude" REAL NOT NULL,
+    "radius" REAL NOT NULL DEFAULT 150,
+    "userId" INTEGER NOT NULL,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" DATETIME NOT NULL,
+    CONSTRAINT "Place_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- RedefineTables
+PRAGMA defer_foreign_keys=ON;
+PRAGMA foreign_keys=OFF;
+CREATE TABLE "new_Mood" (
+    "id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    "title" TEXT NOT NULL,
+    "description" TEXT NOT NULL,
+    "emoji" TEXT NOT NULL,
+    "valence" REAL,
+    "moodTypeId" INTEGER,
+    "latitude" REAL,
+    "longitude" REAL,
+    "placeId" INTEGER,
+    "userId" INTEGER NOT NULL,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" DATETIME NOT NULL,
+    CONSTRAINT "Mood_moodTypeId_fkey" FOREIGN KEY ("moodTypeId") REFERENCES "MoodType" ("id") ON DELETE SET NULL ON UPDATE CASCADE,
+    CONSTRAINT "Mood_placeId_fkey" FOREIGN KEY ("placeId") REFERENCES "Place" ("id") ON DELETE SET NULL ON UPDATE CASCADE,
+    CONSTRAINT "Mood_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+INSERT INTO "new_Mood" ("createdAt", "description", "emoji", "id", "moodTypeId", "title", "updatedAt", "userId", "valence") SELECT "createdAt", "description", "emoji", "id", "moodTypeId", "title", "updatedAt", "userId", "valence" FROM "Mood";
+DROP TABLE "Mood";
+ALTER TABLE "new_Mood" RENAME TO "Mood";
+PRAGMA foreign_keys=ON;
+PRAGMA defer_foreign_keys=OFF;
+
+-- CreateIndex
+CREATE UNIQUE INDEX "Place_name_userId_key" ON "Place"("name", "userId");
@@ -0,0 +1,5 @@
+-- AlterTable
+ALTER TABLE "User" ADD COLUMN "timezone" TEXT NOT NULL DEFAULT 'UTC';
+
+-- CreateIndex
+CREATE INDEX "Mood_userId_createdAt_idx" ON "Mood"("userId", "createdAt");
@@ -0,0 +1,16 @@
+-- CreateTable
+CREATE TABLE "Report" (
+    "id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    "userId" INTEGER NOT NULL,
+    "period" TEXT NOT NULL,
+    "periodStart" TEXT NOT NULL,
+    "storageKey" TEXT NOT NULL,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT "Report_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "Report_storageKey_key" ON "Report"("storageKey");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "Report_userId_period_periodStart_key" ON "Report"("userId", "period", "periodStart");
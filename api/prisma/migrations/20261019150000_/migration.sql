@@ -0,0 +1,2 @@
+-- AlterTable
+ALTER TABLE "Mood" ADD COLUMN "sentiment" REAL;
@@ -0,0 +1,58 @@
+-- CreateTable
+CREATE TABLE "WellbeingSettings" (
+    "id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    "userId" INTEGER NOT NULL,
+    "enabled" BOOLEAN NOT NULL DEFAULT true,
+    "windowDays" INTEGER NOT NULL DEFAULT 7,
+    "threshold" REAL NOT NULL DEFAULT -0.3,
+    "minLowDays" INTEGER NOT NULL DEFAULT 5,
+    "cooldownDays" INTEGER NOT NULL DEFAULT 7,
+    "country" TEXT NOT NULL DEFAULT 'TR',
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" DATETIME NOT NULL,
+    CONSTRAINT "WellbeingSettings_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- CreateTable
+CREATE TABLE "CheckIn" (
+    "id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    "userId" INTEGER NOT NULL,
+    "rule" TEXT NOT NULL,
+    "message" TEXT NOT NULL,
+    "windowStart" TEXT NOT NULL,
+    "windowEnd" TEXT NOT NULL,
+    "averageValence" REAL NOT NULL,
+    "dismissedAt" DATETIME,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT "CheckIn_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- CreateTable
+CREATE TABLE "SupportResource" (
+    "id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    "country" TEXT NOT NULL,
+    "kind" TEXT NOT NULL DEFAULT 'helpline',
+    "name" TEXT NOT NULL,
+    "phone" TEXT,
+    "url" TEXT,
+    "description" TEXT,
+    "position" INTEGER NOT NULL DEFAULT 0,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" DATETIME NOT NULL
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "WellbeingSettings_userId_key" ON "WellbeingSettings"("userId");
+
+-- CreateIndex
+CREATE INDEX "CheckIn_userId_createdAt_idx" ON "CheckIn"("userId", "createdAt");
+
+-- Default resources, "INTL" is shown for countries without their own list
+INSERT INTO "SupportResource" ("country", "kind", "name", "phone", "url", "description", "position", "createdAt", "updatedAt") VALUES
+    ('TR', 'emergency', 'Acil Çağrı Merkezi', '112', NULL, 'Acil durumlarda 7/24 ücretsiz', 0, CAST(strftime('%s', 'now') AS INTEGER) * 1000, CAST(strftime('%s', 'now') AS INTEGER) * 1000),
+    ('TR', 'helpline', 'Find A Helpline', NULL, 'https://findahelpline.com/countries/tr', 'Türkiye''deki ücretsiz ve gizli destek hatları', 1, CAST(strftime('%s', 'now') AS INTEGER) * 1000, CAST(strftime('%s', 'now') AS INTEGER) * 1000),
+    ('US', 'emergency', 'Emergency Services', '911', NULL, 'For immediate danger', 0, CAST(strftime('%s', 'now') AS INTEGER) * 1000, CAST(strftime('%s', 'now') AS INTEGER) * 1000),
+    ('US', 'helpline', '988 Suicide & Crisis Lifeline', '988', 'https://988lifeline.org', 'Call or text 988, free and confidential, 24/7', 1, CAST(strftime('%s', 'now') AS INTEGER) * 1000, CAST(strftime('%s', 'now') AS INTEGER) * 1000),
+    ('GB', 'emergency', 'Emergency Services', '999', NULL, 'For immediate danger', 0, CAST(strftime('%s', 'now') AS INTEGER) * 1000, CAST(strftime('%s', 'now') AS INTEGER) * 1000),
+    ('GB', 'helpline', 'Samaritans', '116 123', 'https://www.samaritans.org', 'Free to call, day or night', 1, CAST(strftime('%s', 'now') AS INTEGER) * 1000, CAST(strftime('%s', 'now') AS INTEGER) * 1000),
+    ('INTL', 'helpline', 'Find A Helpline', NULL, 'https://findahelpline.com', 'Free, confidential helplines in your country', 0, CAST(strftime('%s', 'now') AS INTEGER) * 1000, CAST(strftime('%s', 'now') AS INTEGER) * 1000);
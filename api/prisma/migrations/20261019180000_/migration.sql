@@ -0,0 +1,14 @@
+-- CreateTable
+CREATE TABLE "Assessment" (
+    "id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    "userId" INTEGER NOT NULL,
+    "questionnaire" TEXT NOT NULL,
+    "answers" TEXT NOT NULL,
+    "score" INTEGER NOT NULL,
+    "severity" TEXT NOT NULL,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT "Assessment_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE INDEX "Assessment_userId_questionnaire_createdAt_idx" ON "Assessment"("userId", "questionnaire", "createdAt");
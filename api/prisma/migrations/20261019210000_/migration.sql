@@ -0,0 +1,29 @@
+-- RedefineTables
+PRAGMA defer_foreign_keys=ON;
+PRAGMA foreign_keys=OFF;
+CREATE TABLE "new_Tag" (
+    "id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    "name" TEXT NOT NULL,
+    "userId" INTEGER NOT NULL,
+    "isPublic" BOOLEAN NOT NULL DEFAULT false,
+    "archivedAt" DATETIME,
+    "color" TEXT,
+    "icon" TEXT,
+    "position" INTEGER NOT NULL DEFAULT 0,
+    "groupId" INTEGER,
+    "parentId" INTEGER,
+    "hiddenAt" DATETIME,
+    "adoptedFromId" INTEGER,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" DATETIME NOT NULL,
+    CONSTRAINT "Tag_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT "Tag_groupId_fkey" FOREIGN KEY ("groupId") REFERENCES "TagGroup" ("id") ON DELETE SET NULL ON UPDATE CASCADE,
+    CONSTRAINT "Tag_parentId_fkey" FOREIGN KEY ("parentId") REFERENCES "Tag" ("id") ON DELETE SET NULL ON UPDATE CASCADE,
+    CONSTRAINT "Tag_adoptedFromId_fkey" FOREIGN KEY ("adoptedFromId") REFERENCES "Tag" ("id") ON DELETE SET NULL ON UPDATE CASCADE
+);
+INSERT INTO "new_Tag" ("archivedAt", "color", "createdAt", "groupId", "icon", "id", "isPublic", "name", "parentId", "position", "updatedAt", "userId") SELECT "archivedAt", "color", "createdAt", "groupId", "icon", "id", "isPublic", "name", "parentId", "position", "updatedAt", "userId" FROM "Tag";
+DROP TABLE "Tag";
+ALTER TABLE "new_Tag" RENAME TO "Tag";
+CREATE UNIQUE INDEX "Tag_name_userId_key" ON "Tag"("name", "userId");
+PRAGMA foreign_keys=ON;
+PRAGMA defer_foreign_keys=OFF;
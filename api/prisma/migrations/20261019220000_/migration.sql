@@ -0,0 +1,13 @@
+-- CreateTable
+CREATE TABLE "_TagToUserFood" (
+    "A" INTEGER NOT NULL,
+    "B" INTEGER NOT NULL,
+    CONSTRAINT "_TagToUserFood_A_fkey" FOREIGN KEY ("A") REFERENCES "Tag" ("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT "_TagToUserFood_B_fkey" FOREIGN KEY ("B") REFERENCES "UserFood" ("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "_TagToUserFood_AB_unique" ON "_TagToUserFood"("A", "B");
+
+-- CreateIndex
+CREATE INDEX "_TagToUserFood_B_index" ON "_TagToUserFood"("B");
@@ -0,0 +1,21 @@
+-- AlterTable
+ALTER TABLE "Food" ADD COLUMN "protein" DECIMAL NOT NULL DEFAULT 0;
+ALTER TABLE "Food" ADD COLUMN "carbohydrate" DECIMAL NOT NULL DEFAULT 0;
+ALTER TABLE "Food" ADD COLUMN "fat" DECIMAL NOT NULL DEFAULT 0;
+ALTER TABLE "Food" ADD COLUMN "fiber" DECIMAL NOT NULL DEFAULT 0;
+ALTER TABLE "Food" ADD COLUMN "sugar" DECIMAL NOT NULL DEFAULT 0;
+ALTER TABLE "Food" ADD COLUMN "sodium" DECIMAL NOT NULL DEFAULT 0;
+ALTER TABLE "Food" ADD COLUMN "nutrientBasis" TEXT NOT NULL DEFAULT 'serving';
+ALTER TABLE "Food" ADD COLUMN "servingGrams" DECIMAL;
+
+-- CreateTable
+CREATE TABLE "FoodMicronutrient" (
+    "id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    "foodId" INTEGER NOT NULL,
+    "nutrient" TEXT NOT NULL,
+    "amount" DECIMAL NOT NULL,
+    CONSTRAINT "FoodMicronutrient_foodId_fkey" FOREIGN KEY ("foodId") REFERENCES "Food" ("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- CreateIndex
+CREATE UNIQUE INDEX "FoodMicronutrient_foodId_nutrient_key" ON "FoodMicronutrient"("foodId", "nutrient");
@@ -0,0 +1,36 @@
+-- AlterTable
+ALTER TABLE "Food" ADD COLUMN "gramsPerMl" DECIMAL;
+
+-- CreateTable
+CREATE TABLE "FoodServing" (
+    "id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    "foodId" INTEGER NOT NULL,
+    "name" TEXT NOT NULL,
+    "grams" DECIMAL NOT NULL,
+    CONSTRAINT "FoodServing_foodId_fkey" FOREIGN KEY ("foodId") REFERENCES "Food" ("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- RedefineTables
+PRAGMA defer_foreign_keys=ON;
+PRAGMA foreign_keys=OFF;
+CREATE TABLE "new_UserFood" (
+    "id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    "userId" INTEGER NOT NULL,
+    "foodId" INTEGER NOT NULL,
+    "quantity" DECIMAL NOT NULL DEFAULT 1,
+    "unit" TEXT NOT NULL DEFAULT 'serving',
+    "grams" DECIMAL,
+    "eatenAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" DATETIME NOT NULL,
+    CONSTRAINT "UserFood_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT "UserFood_foodId_fkey" FOREIGN KEY ("foodId") REFERENCES "Food" ("id") ON DELETE RESTRICT ON UPDATE CASCADE
+);
+INSERT INTO "new_UserFood" ("createdAt", "eatenAt", "foodId", "id", "quantity", "updatedAt", "userId") SELECT "createdAt", "eatenAt", "foodId", "id", "quantity", "updatedAt", "userId" FROM "UserFood";
+DROP TABLE "UserFood";
+ALTER TABLE "new_UserFood" RENAME TO "UserFood";
+PRAGMA foreign_keys=ON;
+PRAGMA defer_foreign_keys=OFF;
+
+-- CreateIndex
+CREATE UNIQUE INDEX "FoodServing_foodId_name_key" ON "FoodServing"("foodId", "name");
@@ -0,0 +1,88 @@
+-- CreateTable
+CREATE TABLE "MealSlot" (
+    "id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    "name" TEXT NOT NULL,
+    "time" TEXT NOT NULL,
+    "position" INTEGER NOT NULL DEFAULT 0,
+    "userId" INTEGER NOT NULL,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" DATETIME NOT NULL,
+    CONSTRAINT "MealSlot_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- CreateTable
+CREATE TABLE "Meal" (
+    "id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    "userId" INTEGER NOT NULL,
+    "slotId" INTEGER NOT NULL,
+    "date" TEXT NOT NULL,
+    "eatenAt" DATETIME NOT NULL,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" DATETIME NOT NULL,
+    CONSTRAINT "Meal_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT "Meal_slotId_fkey" FOREIGN KEY ("slotId") REFERENCES "MealSlot" ("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- RedefineTables
+PRAGMA defer_foreign_keys=ON;
+PRAGMA foreign_keys=OFF;
+CREATE TABLE "new_UserFood" (
+    "id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    "userId" INTEGER NOT NULL,
+    "foodId" INTEGER NOT NULL,
+    "quantity" DECIMAL NOT NULL DEFAULT 1,
+    "unit" TEXT NOT NULL DEFAULT 'serving',
+    "grams" DECIMAL,
+    "eatenAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "mealId" INTEGER,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" DATETIME NOT NULL,
+    CONSTRAINT "UserFood_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT "UserFood_foodId_fkey" FOREIGN KEY ("foodId") REFERENCES "Food" ("id") ON DELETE RESTRICT ON UPDATE CASCADE,
+    CONSTRAINT "UserFood_mealId_fkey" FOREIGN KEY ("mealId") REFERENCES "Meal" ("id") ON DELETE SET NULL ON UPDATE CASCADE
+);
+INSERT INTO "new_UserFood" ("createdAt", "eatenAt", "foodId", "grams", "id", "quantity", "unit", "updatedAt", "userId") SELECT "createdAt", "eatenAt", "foodId", "grams", "id", "quantity", "unit", "updatedAt", "userId" FROM "UserFood";
+DROP TABLE "UserFood";
+ALTER TABLE "new_UserFood" RENAME TO "UserFood";
+CREATE TABLE "new_Mood" (
+    "id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    "title" TEXT NOT NULL,
+    "description" TEXT NOT NULL,
+    "emoji" TEXT NOT NULL,
+    "valence" REAL,
+    "sentiment" REAL,
+    "moodTypeId" INTEGER,
+    "latitude" REAL,
+    "longitude" REAL,
+    "placeId" INTEGER,
+    "mealId" INTEGER,
+    "userId" INTEGER NOT NULL,
+    "createdAt" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" DATETIME NOT NULL,
+    CONSTRAINT "Mood_moodTypeId_fkey" FOREIGN KEY ("moodTypeId") REFERENCES "MoodType" ("id") ON DELETE SET NULL ON UPDATE CASCADE,
+    CONSTRAINT "Mood_placeId_fkey" FOREIGN KEY ("placeId") REFERENCES "Place" ("id") ON DELETE SET NULL ON UPDATE CASCADE,
+    CONSTRAINT "Mood_mealId_fkey" FOREIGN KEY ("mealId") REFERENCES "Meal" ("id") ON DELETE SET NULL ON UPDATE CASCADE,
+    CONSTRAINT "Mood_userId_fkey" FOREIGN KEY ("userId") REFERENCES "User" ("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+INSERT INTO "new_Mood" ("createdAt", "description", "emoji", "id", "latitude", "longitude", "moodTypeId", "placeId", "sentiment", "title", "updatedAt", "userId", "valence") SELECT "createdAt", "description", "emoji", "id", "latitude", "longitude", "moodTypeId", "placeId", "sentiment", "title", "updatedAt", "userId", "valence" FROM "Mood";
+DROP TABLE "Mood";
+ALTER TABLE "new_Mood" RENAME TO "Mood";
+CREATE INDEX "Mood_userId_createdAt_idx" ON "Mood"("userId", "createdAt");
+PRAGMA foreign_keys=ON;
+PRAGMA defer_foreign_keys=OFF;
+
+-- CreateIndex
+CREATE UNIQUE INDEX "MealSlot_name_userId_key" ON "MealSlot"("name", "userId");
+
+-- CreateIndex
+CREATE UNIQUE INDEX "Meal_slotId_date_key" ON "Meal"("slotId", "date");
+
+-- Give existing users the default meal slots new users get at signup
+INSERT INTO "MealSlot" ("name", "time", "position", "userId", "createdAt", "updatedAt")
+SELECT slot."name", slot."time", slot."position", "User"."id", CAST(strftime('%s', 'now') AS INTEGER) * 1000, CAST(strftime('%s', 'now') AS INTEGER) * 1000
+FROM "User", (
+    SELECT 'Breakfast' AS "name", '08:00' AS "time", 0 AS "position"
+    UNION ALL SELECT 'Lunch', '12:30', 1
+    UNION ALL SELECT 'Snacks', '16:00', 2
+    UNION ALL SELECT 'Dinner', '19:30', 3
+) AS slot;
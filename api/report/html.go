@@ -0,0 +1,162 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+)
+
+const (
+	chartWidth  = 640.0
+	chartHeight = 200.0
+	chartMargin = 24.0
+)
+
+type chartPoint struct {
+	X, Y float64
+}
+
+type chartData struct {
+	Width, Height float64
+	ZeroY         float64
+	Line          string
+	Points        []chartPoint
+	Labels        []chartLabel
+}
+
+type chartLabel struct {
+	X    float64
+	Text string
+}
+
+// valenceY maps a valence in [-1, 1] onto the chart's vertical axis
+func valenceY(valence, height float64) float64 {
+	return chartMargin + (1-valence)/2*(height-2*chartMargin)
+}
+
+// buildChart lays out one point per day with entries. The x positions are
+// shared by the HTML and the PDF renderer.
+func buildChart(days []DayValence, width, height float64) chartData {
+	chart := chartData{Width: width, Height: height, ZeroY: valenceY(0, height)}
+	if len(days) == 0 {
+		return chart
+	}
+
+	step := (width - 2*chartMargin) / float64(max(len(days)-1, 1))
+	labelEvery := max(len(days)/7, 1)
+	for i, day := range days {
+		x := chartMargin + float64(i)*step
+		if i%labelEvery == 0 {
+			chart.Labels = append(chart.Labels, chartLabel{X: x, Text: day.Date[5:]})
+		}
+		if day.AverageValence == nil {
+			continue
+		}
+		point := chartPoint{X: x, Y: valenceY(*day.AverageValence, height)}
+		chart.Points = append(chart.Points, point)
+		chart.Line += fmt.Sprintf("%.1f,%.1f ", point.X, point.Y)
+	}
+	return chart
+}
+
+var funcs = template.FuncMap{
+	"valence": formatValence,
+	"calories": func(v float64) string {
+		return fmt.Sprintf("%.0f", v)
+	},
+}
+
+// pageStyle is shared by every HTML page so reports and the year in review
+// look alike
+const pageStyle = `<style>
+body { font-family: -apple-system, "Segoe UI", Roboto, sans-serif; max-width: 720px; margin: 2rem auto; padding: 0 1rem; color: #222; }
+h1 { font-size: 1.5rem; }
+h2 { font-size: 1.1rem; margin-top: 2rem; border-bottom: 1px solid #ddd; padding-bottom: .25rem; }
+table { width: 100%; border-collapse: collapse; }
+td, th { text-align: left; padding: .25rem .5rem; border-bottom: 1px solid #eee; }
+.muted { color: #777; font-size: .9rem; }
+.summary span { display: inline-block; margin-right: 1.5rem; }
+</style>
+`
+
+var htmlTemplate = template.Must(template.New("report").Funcs(funcs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Data.Title}}</title>
+` + pageStyle + `</head>
+<body>
+<h1>{{.Data.Title}}</h1>
+<p class="muted">{{.Data.Username}} · {{.Data.Timezone}} · generated {{.Data.GeneratedAt.Format "2006-01-02 15:04"}}</p>
+
+<p class="summary">
+<span><strong>{{.Data.MoodCount}}</strong> moods</span>
+<span>average valence <strong>{{valence .Data.AverageValence}}</strong></span>
+<span><strong>{{.Data.TotalCalories}}</strong> kcal logged</span>
+</p>
+
+<h2>Mood</h2>
+{{if .Chart.Points}}
+<svg viewBox="0 0 {{.Chart.Width}} {{.Chart.Height}}" width="100%" role="img" aria-label="Average valence per day">
+<line x1="0" y1="{{.Chart.ZeroY}}" x2="{{.Chart.Width}}" y2="{{.Chart.ZeroY}}" stroke="#ccc" stroke-dasharray="4 4"/>
+<polyline points="{{.Chart.Line}}" fill="none" stroke="#4A90E2" stroke-width="2"/>
+{{range .Chart.Points}}<circle cx="{{.X}}" cy="{{.Y}}" r="3" fill="#4A90E2"/>{{end}}
+{{range .Chart.Labels}}<text x="{{.X}}" y="{{$.Chart.Height}}" font-size="10" text-anchor="middle" fill="#777">{{.Text}}</text>{{end}}
+</svg>
+{{else}}
+<p class="muted">No moods were logged in this period.</p>
+{{end}}
+
+<h2>Top tags</h2>
+{{if .Data.TopTags}}
+<table>
+{{range .Data.TopTags}}<tr><td>{{.Name}}</td><td>{{.Count}}</td></tr>
+{{end}}</table>
+{{else}}
+<p class="muted">No tags used.</p>
+{{end}}
+
+<h2>Notable entries</h2>
+{{if .Data.Notable}}
+<table>
+{{range .Data.Notable}}<tr><td>{{.Date}}</td><td>{{.Emoji}} <strong>{{.Title}}</strong><br><span class="muted">{{.Description}}</span></td><td>{{valence .Valence}}</td></tr>
+{{end}}</table>
+{{else}}
+<p class="muted">Nothing stood out.</p>
+{{end}}
+
+<h2>Assessments</h2>
+{{if .Data.Assessments}}
+<table>
+{{range .Data.Assessments}}<tr><td>{{.Date}}</td><td>{{.Name}}</td><td>{{.Score}} / {{.MaxScore}}</td><td>{{.Severity}}</td></tr>
+{{end}}</table>
+{{else}}
+<p class="muted">No questionnaires answered.</p>
+{{end}}
+
+<h2>Food</h2>
+{{if .Data.Foods}}
+<p>{{.Data.TotalCalories}} kcal in total, {{calories .Data.AverageDailyCalories}} kcal per logged day.</p>
+<table>
+<tr><th>Food</th><th>Times</th><th>kcal</th></tr>
+{{range .Data.Foods}}<tr><td>{{.Name}}</td><td>{{.Count}}</td><td>{{.Calories}}</td></tr>
+{{end}}</table>
+{{else}}
+<p class="muted">No food logged.</p>
+{{end}}
+</body>
+</html>
+`))
+
+// HTML renders the report as a standalone page without external assets
+func HTML(data Data) ([]byte, error) {
+	var buf bytes.Buffer
+	err := htmlTemplate.Execute(&buf, struct {
+		Data  Data
+		Chart chartData
+	}{data, buildChart(data.Days, chartWidth, chartHeight)})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
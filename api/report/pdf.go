@@ -0,0 +1,262 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// A4 in points
+const (
+	pageWidth  = 595.0
+	pageHeight = 842.0
+	pageMargin = 50.0
+)
+
+// winAnsiExtras maps characters outside Latin-1 that the standard PDF fonts
+// can still show. Turkish letters missing from WinAnsi are transliterated,
+// anything else (emoji in particular) is dropped.
+var winAnsiExtras = map[rune]string{
+	'—': "\x97",
+	'–': "\x96",
+	'’': "\x92",
+	'‘': "\x91",
+	'“': "\x93",
+	'”': "\x94",
+	'…': "\x85",
+	'ğ': "g",
+	'Ğ': "G",
+	'ş': "s",
+	'Ş': "S",
+	'ı': "i",
+	'İ': "I",
+}
+
+// pdfString encodes s as an escaped WinAnsi PDF string literal
+func pdfString(s string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n' || r == '\t':
+			b.WriteByte(' ')
+		case r >= 0x20 && r < 0x7F, r >= 0xA0 && r <= 0xFF:
+			b.WriteByte(byte(r))
+		default:
+			b.WriteString(winAnsiExtras[r])
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// wrap breaks s into lines of roughly maxWidth points. Helvetica averages
+// about half the font size per character, which is close enough here.
+func wrap(s string, size, maxWidth float64) []string {
+	maxChars := int(maxWidth / (size * 0.5))
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(s) {
+		if line != "" && len([]rune(line))+1+len([]rune(word)) > maxChars {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// pdfWriter lays out text top to bottom, starting new pages as needed
+type pdfWriter struct {
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+	y     float64
+}
+
+func (w *pdfWriter) newPage() {
+	w.page = &bytes.Buffer{}
+	w.pages = append(w.pages, w.page)
+	w.y = pageHeight - pageMargin
+}
+
+// ensure starts a new page unless height points are left on this one
+func (w *pdfWriter) ensure(height float64) {
+	if w.page == nil || w.y-height < pageMargin {
+		w.newPage()
+	}
+}
+
+func (w *pdfWriter) text(x float64, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(w.page, "BT /%s %.1f Tf %.2f %.2f Td %s Tj ET\n", font, size, x, w.y-size, pdfString(s))
+}
+
+// paragraph writes wrapped text and moves below it
+func (w *pdfWriter) paragraph(size float64, bold bool, s string) {
+	for _, line := range wrap(s, size, pageWidth-2*pageMargin) {
+		w.ensure(size * 1.4)
+		w.text(pageMargin, size, bold, line)
+		w.y -= size * 1.4
+	}
+}
+
+func (w *pdfWriter) heading(s string) {
+	w.ensure(40)
+	w.y -= 12
+	w.paragraph(13, true, s)
+	fmt.Fprintf(w.page, "0.8 G %.2f %.2f m %.2f %.2f l S 0 G\n", pageMargin, w.y, pageWidth-pageMargin, w.y)
+	w.y -= 6
+}
+
+// row writes cells at the given x offsets on one line
+func (w *pdfWriter) row(size float64, offsets []float64, cells ...string) {
+	w.ensure(size * 1.4)
+	for i, cell := range cells {
+		w.text(pageMargin+offsets[i], size, false, cell)
+	}
+	w.y -= size * 1.4
+}
+
+func (w *pdfWriter) chart(days []DayValence) {
+	chart := buildChart(days, pageWidth-2*pageMargin, 160)
+	w.ensure(chart.Height + 10)
+	top := w.y
+
+	fmt.Fprintf(w.page, "0.8 G [3 3] 0 d %.2f %.2f m %.2f %.2f l S [] 0 d\n",
+		pageMargin, top-chart.ZeroY, pageMargin+chart.Width, top-chart.ZeroY)
+	fmt.Fprintf(w.page, "0.29 0.56 0.89 RG 0.29 0.56 0.89 rg 1.5 w\n")
+	for i, point := range chart.Points {
+		op := "l"
+		if i == 0 {
+			op = "m"
+		}
+		fmt.Fprintf(w.page, "%.2f %.2f %s\n", pageMargin+point.X, top-point.Y, op)
+	}
+	if len(chart.Points) > 0 {
+		w.page.WriteString("S\n")
+	}
+	for _, point := range chart.Points {
+		fmt.Fprintf(w.page, "%.2f %.2f 4 4 re f\n", pageMargin+point.X-2, top-point.Y-2)
+	}
+	w.page.WriteString("0 G 0 g 1 w\n")
+
+	w.y = top - chart.Height + 8
+	for _, label := range chart.Labels {
+		w.text(pageMargin+label.X-12, 8, false, label.Text)
+	}
+	w.y -= 14
+}
+
+// bytes assembles the pages into a PDF file with the two standard Helvetica
+// fonts, so no font data has to be embedded
+func (w *pdfWriter) bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-4 are fixed, then a page and its content stream per page
+	kids := make([]string, len(w.pages))
+	for i := range w.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(w.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range w.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+// PDF renders the report as an A4 document
+func PDF(data Data) ([]byte, error) {
+	w := &pdfWriter{}
+	w.newPage()
+
+	w.paragraph(18, true, data.Title())
+	w.paragraph(9, false, fmt.Sprintf("%s · %s · generated %s", data.Username, data.Timezone, data.GeneratedAt.Format("2006-01-02 15:04")))
+	w.y -= 6
+	w.paragraph(11, false, fmt.Sprintf("%d moods, average valence %s, %d kcal logged", data.MoodCount, formatValence(data.AverageValence), data.TotalCalories))
+
+	w.heading("Mood")
+	if len(data.Days) > 0 && data.MoodCount > 0 {
+		w.chart(data.Days)
+	} else {
+		w.paragraph(10, false, "No moods were logged in this period.")
+	}
+
+	w.heading("Top tags")
+	if len(data.TopTags) == 0 {
+		w.paragraph(10, false, "No tags used.")
+	}
+	for _, tag := range data.TopTags {
+		w.row(10, []float64{0, 300}, tag.Name, fmt.Sprint(tag.Count))
+	}
+
+	w.heading("Notable entries")
+	if len(data.Notable) == 0 {
+		w.paragraph(10, false, "Nothing stood out.")
+	}
+	for _, entry := range data.Notable {
+		w.row(10, []float64{0, 80, 440}, entry.Date, entry.Title, formatValence(entry.Valence))
+		if entry.Description != "" {
+			w.paragraph(9, false, entry.Description)
+		}
+		w.y -= 4
+	}
+
+	w.heading("Assessments")
+	if len(data.Assessments) == 0 {
+		w.paragraph(10, false, "No questionnaires answered.")
+	}
+	for _, score := range data.Assessments {
+		w.row(10, []float64{0, 80, 160, 240}, score.Date, score.Name, fmt.Sprintf("%d / %d", score.Score, score.MaxScore), score.Severity)
+	}
+
+	w.heading("Food")
+	if len(data.Foods) == 0 {
+		w.paragraph(10, false, "No food logged.")
+	} else {
+		w.paragraph(10, false, fmt.Sprintf("%d kcal in total, %.0f kcal per logged day.", data.TotalCalories, data.AverageDailyCalories))
+		w.row(10, []float64{0, 300, 380}, "Food", "Times", "kcal")
+		for _, food := range data.Foods {
+			w.row(10, []float64{0, 300, 380}, food.Name, fmt.Sprint(food.Count), fmt.Sprint(food.Calories))
+		}
+	}
+
+	return w.bytes(), nil
+}
+
+func formatValence(v *float64) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%+.2f", *v)
+}
@@ -0,0 +1,93 @@
+package report
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// ErrUnknownPeriod is returned for periods other than week and month
+var ErrUnknownPeriod = errors.New("period must be week or month")
+
+type DayValence struct {
+	Date           string   `json:"date"`
+	Count          int      `json:"count"`
+	AverageValence *float64 `json:"averageValence"`
+}
+
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type Entry struct {
+	Date        string   `json:"date"`
+	Emoji       string   `json:"emoji"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Valence     *float64 `json:"valence"`
+}
+
+type AssessmentScore struct {
+	Date     string `json:"date"`
+	Name     string `json:"name"`
+	Score    int    `json:"score"`
+	MaxScore int    `json:"maxScore"`
+	Severity string `json:"severity"`
+}
+
+type FoodCount struct {
+	Name     string `json:"name"`
+	Count    int    `json:"count"`
+	Calories int    `json:"calories"`
+}
+
+// Data is everything a report shows for one user and period. Dates are local
+// YYYY-MM-DD strings, To is inclusive.
+type Data struct {
+	Username             string            `json:"username"`
+	Period               string            `json:"period"`
+	From                 string            `json:"from"`
+	To                   string            `json:"to"`
+	Timezone             string            `json:"timezone"`
+	MoodCount            int               `json:"moodCount"`
+	AverageValence       *float64          `json:"averageValence"`
+	Days                 []DayValence      `json:"days"`
+	TopTags              []TagCount        `json:"topTags"`
+	Notable              []Entry           `json:"notable"`
+	Assessments          []AssessmentScore `json:"assessments"`
+	Foods                []FoodCount       `json:"foods"`
+	TotalCalories        int               `json:"totalCalories"`
+	AverageDailyCalories float64           `json:"averageDailyCalories"`
+	GeneratedAt          time.Time         `json:"generatedAt"`
+}
+
+// Bounds returns local midnight of the first day of the period containing
+// date and of the first day after it. Weeks start on Monday.
+func Bounds(period string, date time.Time) (time.Time, time.Time, error) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	switch period {
+	case PeriodWeek:
+		start := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		return start, start.AddDate(0, 0, 7), nil
+	case PeriodMonth:
+		start := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+		return start, start.AddDate(0, 1, 0), nil
+	default:
+		return time.Time{}, time.Time{}, ErrUnknownPeriod
+	}
+}
+
+// Title is the heading shown on the report
+func (d Data) Title() string {
+	if d.Period == PeriodMonth {
+		if from, err := time.Parse("2006-01-02", d.From); err == nil {
+			return "Monthly report — " + from.Format("January 2006")
+		}
+	}
+	return "Weekly report — " + d.From + " to " + d.To
+}
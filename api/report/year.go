@@ -0,0 +1,137 @@
+package report
+
+import (
+	"bytes"
+	"html/template"
+	"time"
+)
+
+type MonthSummary struct {
+	Month          string   `json:"month"`
+	Count          int      `json:"count"`
+	AverageValence *float64 `json:"averageValence"`
+}
+
+type MoodTypeCount struct {
+	Emoji string  `json:"emoji"`
+	Label string  `json:"label,omitempty"`
+	Share float64 `json:"share"`
+}
+
+type Streak struct {
+	Days int    `json:"days"`
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+}
+
+// YearReview is the yearly recap, ordered the way the client tells it as a
+// story
+type YearReview struct {
+	Username       string          `json:"username"`
+	Year           int             `json:"year"`
+	Timezone       string          `json:"timezone"`
+	MoodCount      int             `json:"moodCount"`
+	DaysLogged     int             `json:"daysLogged"`
+	AverageValence *float64        `json:"averageValence"`
+	Months         []MonthSummary  `json:"months"`
+	BestMonth      *MonthSummary   `json:"bestMonth"`
+	WorstMonth     *MonthSummary   `json:"worstMonth"`
+	TopMoodTypes   []MoodTypeCount `json:"topMoodTypes"`
+	TopTags        []TagCount      `json:"topTags"`
+	LongestStreak  Streak          `json:"longestStreak"`
+	TopFoods       []FoodCount     `json:"topFoods"`
+	Notable        []Entry         `json:"notable"`
+	GeneratedAt    time.Time       `json:"generatedAt"`
+}
+
+var yearFuncs = template.FuncMap{
+	"valence": formatValence,
+	"percent": func(share float64) int {
+		return int(share*100 + 0.5)
+	},
+	// barWidth turns a valence into a 0-100% bar width
+	"barWidth": func(v *float64) int {
+		if v == nil {
+			return 0
+		}
+		return int((*v+1)/2*100 + 0.5)
+	},
+}
+
+var yearTemplate = template.Must(template.New("year").Funcs(yearFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Year}} in review</title>
+` + pageStyle + `<style>
+.bar { background: #eee; height: .6rem; border-radius: .3rem; }
+.bar div { background: #4A90E2; height: 100%; border-radius: .3rem; }
+.big { font-size: 2rem; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>{{.Year}} in review</h1>
+<p class="muted">{{.Username}} · {{.Timezone}}</p>
+
+<p class="summary">
+<span><span class="big">{{.MoodCount}}</span> moods</span>
+<span><span class="big">{{.DaysLogged}}</span> days logged</span>
+<span><span class="big">{{.LongestStreak.Days}}</span> day longest streak</span>
+</p>
+{{if .LongestStreak.Days}}<p class="muted">Longest streak: {{.LongestStreak.From}} to {{.LongestStreak.To}}</p>{{end}}
+
+<h2>Months</h2>
+<table>
+{{range .Months}}<tr><td>{{.Month}}</td><td style="width:50%"><div class="bar"><div style="width:{{barWidth .AverageValence}}%"></div></div></td><td>{{valence .AverageValence}}</td><td class="muted">{{.Count}}</td></tr>
+{{end}}</table>
+{{with .BestMonth}}<p>Best month: <strong>{{.Month}}</strong> ({{valence .AverageValence}})</p>{{end}}
+{{with .WorstMonth}}<p>Hardest month: <strong>{{.Month}}</strong> ({{valence .AverageValence}})</p>{{end}}
+
+<h2>Most felt</h2>
+{{if .TopMoodTypes}}
+<table>
+{{range .TopMoodTypes}}<tr><td>{{.Emoji}} {{.Label}}</td><td>{{percent .Share}}%</td></tr>
+{{end}}</table>
+{{else}}
+<p class="muted">No moods logged.</p>
+{{end}}
+
+<h2>Top tags</h2>
+{{if .TopTags}}
+<table>
+{{range .TopTags}}<tr><td>{{.Name}}</td><td>{{.Count}}</td></tr>
+{{end}}</table>
+{{else}}
+<p class="muted">No tags used.</p>
+{{end}}
+
+<h2>Most logged foods</h2>
+{{if .TopFoods}}
+<table>
+{{range .TopFoods}}<tr><td>{{.Name}}</td><td>{{.Count}}</td></tr>
+{{end}}</table>
+{{else}}
+<p class="muted">No food logged.</p>
+{{end}}
+
+<h2>Moments</h2>
+{{if .Notable}}
+<table>
+{{range .Notable}}<tr><td>{{.Date}}</td><td>{{.Emoji}} <strong>{{.Title}}</strong>{{with .Description}}<br><span class="muted">{{.}}</span>{{end}}</td><td>{{valence .Valence}}</td></tr>
+{{end}}</table>
+{{else}}
+<p class="muted">Nothing stood out.</p>
+{{end}}
+</body>
+</html>
+`))
+
+// YearReviewHTML renders the recap as a page without images or external
+// assets, so it can be shared as a single file
+func YearReviewHTML(review YearReview) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := yearTemplate.Execute(&buf, review); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
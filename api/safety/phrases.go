@@ -0,0 +1,111 @@
+package safety
+
+// Phrases are written one per line. Words are separated by spaces and must
+// appear consecutively; a word ending in * matches any word starting with it,
+// which is how Turkish suffixes ("öldüreceğim", "öldürmek") are covered, and
+// "..." allows up to maxGap words in between.
+//
+// Text and phrases are compared after Turkish letters are folded to ASCII
+// ("ölmek" also matches "olmek"), so a phrase must stay distinctive once
+// folded: "keşke öl*" would also match "keşke olsaydı".
+
+var englishPhrases = map[string][]string{
+	SeverityHigh: {
+		"kill myself",
+		"kill my self",
+		"killing myself",
+		"end my life",
+		"ending my life",
+		"end it all",
+		"take my own life",
+		"take my life",
+		"suicid*",
+		"want* to ... die",
+		"going to ... die tonight",
+		"hang myself",
+		"slit my wrist*",
+		"overdose on purpose",
+	},
+	SeverityConcern: {
+		"self harm*",
+		"selfharm*",
+		"hurt* myself",
+		"cut* myself",
+		"better off dead",
+		"better off without me",
+		"no reason to live",
+		"nothing to live for",
+		"not worth living",
+		"not want to live",
+		"not want to be alive",
+		"not want to wake up",
+		"wish i was dead",
+		"wish i were dead",
+		"wish i could die",
+		"wish i had never been born",
+		"can not go on anymore",
+		"can not go on like this",
+		"can not go on living",
+		"disappear forever",
+	},
+}
+
+// englishExclusions are idioms and contexts that contain a phrase but are not
+// about the writer's safety. A match overlapping an exclusion is dropped.
+var englishExclusions = []string{
+	"kill* myself laugh*",
+	"cut* myself some slack",
+	"cut* myself a",
+	"cut* myself shaving",
+	"hurt* myself ... gym",
+	"not suicidal",
+	"no longer suicidal",
+	"suicide squad",
+	"suicide prevention",
+	"suicide doors",
+	"want* to ... die laugh*",
+	"die of embarrass*",
+	"die of boredom",
+}
+
+var turkishPhrases = map[string][]string{
+	SeverityHigh: {
+		"intihar*",
+		"kendimi öldür*",
+		"canıma kıy*",
+		"hayatıma son ver*",
+		"yaşamıma son ver*",
+		"kendimi asaca*",
+		"kendimi asmak*",
+		"ölmek isti*",
+		"ölmek istedi*",
+		"bileklerimi kes*",
+		"bileğimi kes*",
+	},
+	SeverityConcern: {
+		"kendime zarar ver*",
+		"kendimi kestim",
+		"kendimi kesiyor*",
+		"kendimi keseceğ*",
+		"yaşamak istemiyor*",
+		"yaşamak istemedi*",
+		"yaşamanın anlamı yok*",
+		"yaşamanın ... anlamı kalma*",
+		"yaşamak için ... sebep* yok*",
+		"keşke ölsem*",
+		"keşke ölseydi*",
+		"keşke hiç doğmasaydı*",
+		"her şeye son ver*",
+		"bensiz daha iyi*",
+		"ben olmasam daha iyi*",
+		"ortadan kaybolmak isti*",
+	},
+}
+
+var turkishExclusions = []string{
+	"gülmekten öl*",
+	"intihar saldır*",
+	"intihar bombacı*",
+	"intihar eylem*",
+	"intihar önle*",
+}
@@ -0,0 +1,206 @@
+// Package safety looks for self-harm and crisis language in free text, so the
+// app can show safety resources right away. Matching is done offline with
+// the phrase lists in phrases.go.
+package safety
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	LanguageEnglish = "en"
+	LanguageTurkish = "tr"
+
+	// SeverityHigh is explicit intent; SeverityConcern is self-harm or a wish
+	// not to be alive
+	SeverityHigh    = "high"
+	SeverityConcern = "concern"
+
+	// maxGap is how many words "..." in a phrase may skip
+	maxGap = 3
+)
+
+// Match is one phrase found in a text
+type Match struct {
+	Language string `json:"language"`
+	Severity string `json:"severity"`
+	Phrase   string `json:"phrase"`
+}
+
+// Result sums up the matches of a text. Severity is the highest severity
+// matched and Language the language of the first match.
+type Result struct {
+	Severity string  `json:"severity"`
+	Language string  `json:"language"`
+	Matches  []Match `json:"matches"`
+}
+
+type word struct {
+	text   string
+	prefix bool
+	gap    bool
+}
+
+type pattern struct {
+	source string
+	words  []word
+}
+
+type locale struct {
+	language   string
+	phrases    map[string][]pattern
+	exclusions []pattern
+}
+
+var locales = []locale{
+	compileLocale(LanguageTurkish, turkishPhrases, turkishExclusions),
+	compileLocale(LanguageEnglish, englishPhrases, englishExclusions),
+}
+
+// Check returns the crisis phrases found in text, false when there are none.
+// Every locale is checked, since entries often mix languages.
+func Check(text string) (Result, bool) {
+	tokens := tokenize(text)
+	var result Result
+
+	for _, loc := range locales {
+		for _, severity := range []string{SeverityHigh, SeverityConcern} {
+			for _, p := range loc.phrases[severity] {
+				start, end, ok := p.find(tokens, 0)
+				for ok && loc.excluded(tokens, start, end) {
+					start, end, ok = p.find(tokens, start+1)
+				}
+				if !ok {
+					continue
+				}
+
+				result.Matches = append(result.Matches, Match{
+					Language: loc.language,
+					Severity: severity,
+					Phrase:   p.source,
+				})
+				if result.Language == "" {
+					result.Language = loc.language
+				}
+				if result.Severity != SeverityHigh {
+					result.Severity = severity
+				}
+			}
+		}
+	}
+
+	return result, len(result.Matches) > 0
+}
+
+// fold maps Turkish letters to their ASCII look-alikes, since many users type
+// without them
+var fold = strings.NewReplacer(
+	"ı", "i", "ş", "s", "ğ", "g", "ü", "u", "ö", "o", "ç", "c",
+	"â", "a", "î", "i", "û", "u",
+)
+
+// tokenize lower-cases and folds text and splits it into words. English
+// contractions are expanded so "can't" and "cannot" both read "can not".
+func tokenize(text string) []string {
+	text = strings.ToLowerSpecial(unicode.TurkishCase, text)
+	text = fold.Replace(text)
+	text = strings.NewReplacer(
+		"’", "'",
+		"cannot", "can not",
+		"can't", "can not",
+		"won't", "will not",
+		"n't", " not",
+		"'", "",
+	).Replace(text)
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+}
+
+func compile(source string) pattern {
+	p := pattern{source: source}
+	for _, field := range strings.Fields(source) {
+		if field == "..." {
+			p.words = append(p.words, word{gap: true})
+			continue
+		}
+		prefix := strings.HasSuffix(field, "*")
+		for _, token := range tokenize(strings.TrimSuffix(field, "*")) {
+			p.words = append(p.words, word{text: token})
+		}
+		p.words[len(p.words)-1].prefix = prefix
+	}
+	return p
+}
+
+func compileLocale(language string, phrases map[string][]string, exclusions []string) locale {
+	loc := locale{language: language, phrases: make(map[string][]pattern)}
+	for severity, sources := range phrases {
+		for _, source := range sources {
+			loc.phrases[severity] = append(loc.phrases[severity], compile(source))
+		}
+	}
+	for _, source := range exclusions {
+		loc.exclusions = append(loc.exclusions, compile(source))
+	}
+	return loc
+}
+
+// find returns the first match starting at or after from as the token range
+// [start, end)
+func (p pattern) find(tokens []string, from int) (int, int, bool) {
+	for start := from; start < len(tokens); start++ {
+		if end, ok := p.matchAt(tokens, start, 0); ok {
+			return start, end, true
+		}
+	}
+	return 0, 0, false
+}
+
+// matchAt matches words[w:] against tokens[i:], returning where the match ends
+func (p pattern) matchAt(tokens []string, i, w int) (int, bool) {
+	if w == len(p.words) {
+		return i, true
+	}
+
+	if p.words[w].gap {
+		for skip := 0; skip <= maxGap && i+skip <= len(tokens); skip++ {
+			if end, ok := p.matchAt(tokens, i+skip, w+1); ok {
+				return end, true
+			}
+		}
+		return 0, false
+	}
+
+	if i >= len(tokens) {
+		return 0, false
+	}
+	expected := p.words[w]
+	if tokens[i] != expected.text && !(expected.prefix && strings.HasPrefix(tokens[i], expected.text)) {
+		return 0, false
+	}
+	return p.matchAt(tokens, i+1, w+1)
+}
+
+// excluded reports whether an exclusion overlaps tokens[start:end]
+func (loc locale) excluded(tokens []string, start, end int) bool {
+	for _, exclusion := range loc.exclusions {
+		// An exclusion may begin a few words before the match
+		from := start - len(exclusion.words) - maxGap
+		if from < 0 {
+			from = 0
+		}
+		for {
+			exStart, exEnd, ok := exclusion.find(tokens, from)
+			if !ok || exStart >= end {
+				break
+			}
+			if exEnd > start {
+				return true
+			}
+			from = exStart + 1
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package safety
+
+import "testing"
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		severity string
+		language string
+	}{
+		{"english intent", "I want to kill myself", SeverityHigh, LanguageEnglish},
+		{"english gap", "I just want to finally die", SeverityHigh, LanguageEnglish},
+		{"english prefix", "Having suicidal thoughts again", SeverityHigh, LanguageEnglish},
+		{"english contraction", "I don't want to live like this", SeverityConcern, LanguageEnglish},
+		{"english concern", "Sometimes I wish I was dead", SeverityConcern, LanguageEnglish},
+		{"highest severity wins", "I keep hurting myself and I want to end my life", SeverityHigh, LanguageEnglish},
+		{"turkish intent", "İntihar etmeyi düşünüyorum", SeverityHigh, LanguageTurkish},
+		{"turkish future", "Kendimi öldüreceğim", SeverityHigh, LanguageTurkish},
+		{"turkish present", "Artık ölmek istiyorum", SeverityHigh, LanguageTurkish},
+		{"turkish past", "Dün ölmek istedim", SeverityHigh, LanguageTurkish},
+		{"turkish concern", "Yaşamak istemiyorum", SeverityConcern, LanguageTurkish},
+		{"turkish self harm", "Yine kendime zarar verdim", SeverityConcern, LanguageTurkish},
+		{"turkish gap", "Yaşamanın artık hiçbir anlamı kalmadı", SeverityConcern, LanguageTurkish},
+		{"ascii folded", "olmek istiyorum", SeverityHigh, LanguageTurkish},
+		{"ascii folded suffix", "kendimi oldurecegim", SeverityHigh, LanguageTurkish},
+		{"ascii folded concern", "yasamak istemiyorum", SeverityConcern, LanguageTurkish},
+
+		{"english idiom", "I was killing myself laughing at that show", "", ""},
+		{"english slack", "I cut myself some slack today", "", ""},
+		{"english title", "Watched Suicide Squad tonight", "", ""},
+		{"english negated", "I am not suicidal, just tired", "", ""},
+		{"turkish news", "Haberlerde intihar saldırısı vardı", "", ""},
+		{"turkish news folded", "haberlerde intihar saldirisi vardi", "", ""},
+		{"turkish idiom", "Gülmekten öldüm", "", ""},
+		{"plain day", "Had a great day at the park", "", ""},
+		{"turkish plain day", "Keşke olsaydı dedim", "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, ok := Check(test.text)
+			if test.severity == "" {
+				if ok {
+					t.Fatalf("Check(%q) matched %v, want no match", test.text, result.Matches)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("Check(%q) found nothing, want %s", test.text, test.severity)
+			}
+			if result.Severity != test.severity {
+				t.Errorf("Check(%q) severity = %q, want %q", test.text, result.Severity, test.severity)
+			}
+			if result.Language != test.language {
+				t.Errorf("Check(%q) language = %q, want %q", test.text, result.Language, test.language)
+			}
+		})
+	}
+}
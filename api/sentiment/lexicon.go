@@ -0,0 +1,107 @@
+package sentiment
+
+// Word polarities run from -4 (very negative) to 4 (very positive).
+//
+// English entries match whole words. Turkish entries are stems matched
+// against the start of a word, since suffixes carry person, tense and case
+// ("mutluyum", "mutluydum", "mutluluk"); verbStem marks stems whose negative
+// form is built with the -ma/-me suffix ("sevmiyorum").
+
+var englishLexicon = map[string]float64{
+	"happy": 3, "glad": 2.5, "joy": 3, "joyful": 3, "great": 3, "good": 2, "fine": 1,
+	"calm": 2, "relaxed": 2, "peaceful": 2.5, "excited": 2.5, "grateful": 3, "thankful": 3,
+	"love": 3, "loved": 3, "lovely": 3, "wonderful": 3.5, "amazing": 3.5, "awesome": 3.5,
+	"fantastic": 3.5, "excellent": 3.5, "proud": 2.5, "hopeful": 2, "energetic": 2,
+	"motivated": 2, "productive": 2, "fun": 2, "enjoyed": 2.5, "enjoy": 2, "better": 1.5,
+	"best": 3, "nice": 2, "content": 1.5, "satisfied": 2, "rested": 1.5, "laughed": 2.5,
+	"smile": 2, "safe": 1.5, "confident": 2, "inspired": 2.5, "okay": 0.5, "ok": 0.5,
+	"sad": -2.5, "unhappy": -2.5, "depressed": -3.5, "down": -1.5, "lonely": -2.5,
+	"angry": -3, "mad": -2.5, "furious": -3.5, "annoyed": -2, "irritated": -2,
+	"frustrated": -2.5, "anxious": -2.5, "worried": -2, "nervous": -2, "stressed": -2.5,
+	"stress": -2, "scared": -2.5, "afraid": -2.5, "tired": -1.5, "exhausted": -2.5,
+	"bored": -1.5, "awful": -3, "terrible": -3.5, "horrible": -3.5, "bad": -2.5,
+	"worse": -2.5, "worst": -3.5, "hate": -3, "hated": -3, "cry": -2.5, "cried": -2.5,
+	"crying": -2.5, "hurt": -2.5, "pain": -2.5, "sick": -2, "ill": -2, "miserable": -3.5,
+	"hopeless": -3.5, "worthless": -3.5, "overwhelmed": -2.5, "disappointed": -2.5,
+	"upset": -2.5, "guilty": -2, "ashamed": -2.5, "empty": -2, "lost": -1.5, "fail": -2,
+	"failed": -2, "panic": -3, "awkward": -1, "meh": -0.5,
+}
+
+var englishIntensifiers = map[string]float64{
+	"very": 1.5, "really": 1.4, "so": 1.3, "extremely": 1.8, "incredibly": 1.7,
+	"super": 1.5, "too": 1.3, "totally": 1.4, "absolutely": 1.6, "quite": 1.2,
+	"slightly": 0.6, "somewhat": 0.7, "bit": 0.7, "little": 0.7, "kinda": 0.7,
+}
+
+var englishNegations = map[string]bool{
+	"not": true, "no": true, "never": true, "without": true, "nothing": true,
+	"neither": true, "nor": true, "hardly": true, "barely": true,
+}
+
+type stem struct {
+	polarity float64
+	verbStem bool
+}
+
+var turkishLexicon = map[string]stem{
+	"mutlu": {3, false}, "sevin": {3, true}, "neşe": {3, false}, "huzur": {2.5, false},
+	"rahat": {2, false}, "sakin": {2, false}, "güzel": {2.5, false}, "harika": {3.5, false},
+	"mükemmel": {3.5, false}, "muhteşem": {3.5, false}, "süper": {3, false}, "iyi": {2, false},
+	"keyif": {2.5, false}, "eğlen": {2.5, true}, "gül": {2, true}, "sev": {2.5, true},
+	"şükür": {2.5, false}, "minnet": {3, false}, "umut": {2, false}, "heyecan": {2, false},
+	"gurur": {2.5, false}, "başar": {2.5, true}, "dinlen": {1.5, true}, "enerji": {1.5, false},
+	"verimli": {2, false}, "motive": {2, false}, "tatmin": {2, false}, "şanslı": {2, false},
+	"hoş": {2, false}, "ferah": {2, false}, "coş": {2.5, true}, "rahatla": {2, true},
+	"üzgün": {-2.5, false}, "üzül": {-2.5, true}, "mutsuz": {-2.5, false}, "kötü": {-2.5, false},
+	"berbat": {-3.5, false}, "rezil": {-3, false}, "korkunç": {-3.5, false}, "kızgın": {-3, false},
+	"sinir": {-2.5, false}, "öfke": {-3, false}, "kızd": {-2, false}, "endişe": {-2.5, false},
+	"kaygı": {-2.5, false}, "gergin": {-2, false}, "stres": {-2.5, false}, "kork": {-2.5, true},
+	"yorgun": {-1.5, false}, "yoruldu": {-1.5, false}, "bitkin": {-2.5, false}, "sıkıl": {-1.5, true},
+	"sıkıntı": {-2, false}, "yalnız": {-2, false}, "ağla": {-2.5, true}, "huzursuz": {-2.5, false},
+	"hasta": {-2, false}, "depresif": {-3.5, false}, "çaresiz": {-3.5, false}, "umutsuz": {-3.5, false},
+	"değersiz": {-3.5, false}, "boşluk": {-2, false}, "rahatsız": {-2, false}, "keyifsiz": {-2, false},
+	"pişman": {-2, false}, "utan": {-2, true}, "suçlu": {-2, false}, "panik": {-3, false},
+	"nefret": {-3, false}, "bunal": {-2.5, true}, "enerjisiz": {-1.5, false},
+}
+
+// turkishExclusions start like a lexicon stem but are unrelated words
+// ("seviye" is a level, not "sev-" to love; "hastane" is a hospital)
+var turkishExclusions = []string{"seviye", "sevk", "hastane", "süpermarket"}
+
+// turkishPhrases are matched stem by stem on consecutive words
+var turkishPhrases = []struct {
+	stems    []string
+	polarity float64
+}{
+	{[]string{"moral", "bozuk"}, -2.5},
+	{[]string{"moral", "yüksek"}, 2.5},
+	{[]string{"hayal", "kırıklı"}, -2.5},
+	{[]string{"can", "sıkkın"}, -2},
+	{[]string{"can", "sıkıl"}, -2},
+	{[]string{"acı", "çek"}, -2.5},
+	{[]string{"kendimi", "iyi"}, 2},
+}
+
+var turkishIntensifiers = map[string]float64{
+	"çok": 1.5, "aşırı": 1.8, "fazla": 1.3, "gerçekten": 1.4, "cidden": 1.4,
+	"epey": 1.3, "oldukça": 1.2, "derece": 1.6, "inanılmaz": 1.7,
+	"müthiş": 1.6, "baya": 1.3, "bayağı": 1.3, "biraz": 0.7, "azıcık": 0.6, "hafif": 0.7,
+}
+
+// turkishNegations follow the word they negate ("mutlu değilim",
+// "enerjim yok")
+var turkishNegations = []string{"değil", "yok"}
+
+// turkishNegativeSuffixes follow a verb stem in its negative form
+var turkishNegativeSuffixes = []string{"ma", "me", "mı", "mi", "mu", "mü"}
+
+// Common function words used to guess the language of a text
+var englishMarkers = map[string]bool{
+	"the": true, "and": true, "i": true, "is": true, "was": true, "am": true, "it": true,
+	"to": true, "my": true, "of": true, "today": true, "feel": true, "feeling": true,
+}
+
+var turkishMarkers = map[string]bool{
+	"ve": true, "bir": true, "bu": true, "çok": true, "ama": true, "değil": true, "bugün": true,
+	"ben": true, "için": true, "gibi": true, "daha": true, "hiç": true, "çünkü": true,
+}
@@ -0,0 +1,258 @@
+// Package sentiment scores free text with a small built-in lexicon for
+// Turkish and English. It runs fully offline, so mood descriptions never
+// leave the server.
+package sentiment
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	LanguageEnglish = "en"
+	LanguageTurkish = "tr"
+
+	// negationScalar flips and dampens a negated word, so "not happy" reads
+	// as mildly negative rather than as the opposite of happy
+	negationScalar = -0.74
+	// normalizationAlpha controls how quickly the summed polarity approaches
+	// -1 or 1
+	normalizationAlpha = 15
+	// Words after "but"/"ama" outweigh the words before them
+	contrastBefore = 0.5
+	contrastAfter  = 1.5
+)
+
+// Result is the sentiment of a text, Score running from -1 to 1 like mood
+// valence
+type Result struct {
+	Score    float64 `json:"score"`
+	Language string  `json:"language"`
+	Matches  int     `json:"matches"`
+}
+
+// Analyze scores text, returning false when no sentiment-bearing words were
+// found
+func Analyze(text string) (Result, bool) {
+	var result Result
+	switch detectLanguage(text) {
+	case LanguageEnglish:
+		result = scoreEnglish(text)
+	case LanguageTurkish:
+		result = scoreTurkish(text)
+	default:
+		result = scoreEnglish(text)
+		if turkish := scoreTurkish(text); turkish.Matches > result.Matches {
+			result = turkish
+		}
+	}
+	return result, result.Matches > 0
+}
+
+// tokenize lower-cases text and splits it into words. English contractions
+// like "don't" become "do not" so the negation is seen.
+func tokenize(text string, lower func(string) string) []string {
+	text = lower(text)
+	text = strings.NewReplacer("n't", " not", "’", "'").Replace(text)
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+}
+
+func detectLanguage(text string) string {
+	var english, turkish int
+	for _, r := range text {
+		if strings.ContainsRune("çğıöşüÇĞİÖŞÜ", r) {
+			turkish++
+		}
+	}
+	for _, token := range tokenize(text, strings.ToLower) {
+		if englishMarkers[token] {
+			english++
+		}
+		if turkishMarkers[token] {
+			turkish++
+		}
+	}
+
+	switch {
+	case english > turkish:
+		return LanguageEnglish
+	case turkish > english:
+		return LanguageTurkish
+	default:
+		return ""
+	}
+}
+
+// normalize maps a summed polarity onto -1..1
+func normalize(sum float64) float64 {
+	if sum == 0 {
+		return 0
+	}
+	score := sum / math.Sqrt(sum*sum+normalizationAlpha)
+	return math.Max(-1, math.Min(1, score))
+}
+
+// applyContrast weights the polarities around the last "but"
+func applyContrast(polarities []float64, contrastAt int) {
+	if contrastAt < 0 {
+		return
+	}
+	for i := range polarities {
+		if i < contrastAt {
+			polarities[i] *= contrastBefore
+		} else if i > contrastAt {
+			polarities[i] *= contrastAfter
+		}
+	}
+}
+
+func scoreEnglish(text string) Result {
+	tokens := tokenize(text, strings.ToLower)
+	polarities := make([]float64, len(tokens))
+	result := Result{Language: LanguageEnglish}
+	contrastAt := -1
+
+	for i, token := range tokens {
+		if token == "but" {
+			contrastAt = i
+			continue
+		}
+		polarity, ok := englishLexicon[token]
+		if !ok {
+			continue
+		}
+		result.Matches++
+
+		for back := 1; back <= 3 && i-back >= 0; back++ {
+			previous := tokens[i-back]
+			if factor, ok := englishIntensifiers[previous]; ok && back <= 2 {
+				polarity *= factor
+			}
+			if englishNegations[previous] {
+				polarity *= negationScalar
+				break
+			}
+		}
+		polarities[i] = polarity
+	}
+
+	applyContrast(polarities, contrastAt)
+	var sum float64
+	for _, polarity := range polarities {
+		sum += polarity
+	}
+	result.Score = normalize(sum)
+	return result
+}
+
+// turkishStem returns the longest lexicon stem word starts with
+func turkishStem(word string) (string, stem, bool) {
+	for _, excluded := range turkishExclusions {
+		if strings.HasPrefix(word, excluded) {
+			return "", stem{}, false
+		}
+	}
+
+	var best string
+	var found stem
+	for candidate, entry := range turkishLexicon {
+		if len(candidate) > len(best) && strings.HasPrefix(word, candidate) {
+			best, found = candidate, entry
+		}
+	}
+	return best, found, best != ""
+}
+
+// negatedVerb reports whether the suffix after a verb stem is the negative
+// -ma/-me, leaving out the infinitive (-mak/-mek) and the reported past
+// (-mış/-miş/...) which start the same way
+func negatedVerb(suffix string) bool {
+	for _, affirmative := range []string{"mak", "mek", "mış", "miş", "muş", "müş"} {
+		if strings.HasPrefix(suffix, affirmative) {
+			return false
+		}
+	}
+	for _, negative := range turkishNegativeSuffixes {
+		if strings.HasPrefix(suffix, negative) {
+			return true
+		}
+	}
+	return false
+}
+
+func scoreTurkish(text string) Result {
+	tokens := tokenize(text, func(s string) string {
+		return strings.ToLowerSpecial(unicode.TurkishCase, s)
+	})
+	polarities := make([]float64, len(tokens))
+	result := Result{Language: LanguageTurkish}
+	contrastAt := -1
+
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		if token == "ama" || token == "fakat" || token == "ancak" {
+			contrastAt = i
+			continue
+		}
+
+		var polarity float64
+		last := i
+		for _, phrase := range turkishPhrases {
+			if i+len(phrase.stems) > len(tokens) {
+				continue
+			}
+			matched := true
+			for j, stem := range phrase.stems {
+				if !strings.HasPrefix(tokens[i+j], stem) {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				polarity, last = phrase.polarity, i+len(phrase.stems)-1
+				break
+			}
+		}
+
+		if polarity == 0 {
+			matchedStem, entry, ok := turkishStem(token)
+			if !ok {
+				continue
+			}
+			polarity = entry.polarity
+			if entry.verbStem && negatedVerb(token[len(matchedStem):]) {
+				polarity *= negationScalar
+			}
+		}
+		result.Matches++
+
+		if i > 0 {
+			if factor, ok := turkishIntensifiers[tokens[i-1]]; ok {
+				polarity *= factor
+			}
+		}
+		// "değil" and "yok" negate the word before them
+		if last+1 < len(tokens) {
+			for _, negation := range turkishNegations {
+				if strings.HasPrefix(tokens[last+1], negation) {
+					polarity *= negationScalar
+					break
+				}
+			}
+		}
+
+		polarities[i] = polarity
+		i = last
+	}
+
+	applyContrast(polarities, contrastAt)
+	var sum float64
+	for _, polarity := range polarities {
+		sum += polarity
+	}
+	result.Score = normalize(sum)
+	return result
+}
@@ -0,0 +1,68 @@
+package sentiment
+
+import "testing"
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		text     string
+		matched  bool
+		positive bool
+		language string
+	}{
+		{"I am happy today", true, true, LanguageEnglish},
+		{"I am not happy today", true, false, LanguageEnglish},
+		{"It was a terrible day", true, false, LanguageEnglish},
+		{"Bugün çok mutluyum", true, true, LanguageTurkish},
+		{"Bugün mutlu değilim", true, false, LanguageTurkish},
+		{"Onu çok seviyorum", true, true, LanguageTurkish},
+		{"Bu işi hiç sevmiyorum", true, false, LanguageTurkish},
+		{"Sevgilimle güzel bir gün", true, true, LanguageTurkish},
+		{"Moralim bozuk", true, false, LanguageTurkish},
+		{"Seviye sınavına girdim", false, false, LanguageTurkish},
+		{"Bugün hastaneye gittim", false, false, LanguageTurkish},
+		{"Kargo sevkiyatı bugün çıktı", false, false, LanguageTurkish},
+	}
+	for _, test := range tests {
+		result, ok := Analyze(test.text)
+		if ok != test.matched {
+			t.Errorf("Analyze(%q) matched = %v, want %v", test.text, ok, test.matched)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if result.Language != test.language {
+			t.Errorf("Analyze(%q) language = %q, want %q", test.text, result.Language, test.language)
+		}
+		if (result.Score > 0) != test.positive {
+			t.Errorf("Analyze(%q) score = %.2f, want positive = %v", test.text, result.Score, test.positive)
+		}
+	}
+}
+
+func TestIntensifiersStrengthen(t *testing.T) {
+	plain, _ := Analyze("I am happy")
+	intense, _ := Analyze("I am very happy")
+	if intense.Score <= plain.Score {
+		t.Errorf("very happy scored %.2f, happy %.2f", intense.Score, plain.Score)
+	}
+}
+
+func TestTurkishStem(t *testing.T) {
+	tests := []struct {
+		word string
+		stem string
+	}{
+		{"seviyorum", "sev"},
+		{"sevindim", "sevin"},
+		{"rahatladım", "rahatla"},
+		{"seviye", ""},
+		{"seviyesi", ""},
+		{"hastane", ""},
+	}
+	for _, test := range tests {
+		if got, _, _ := turkishStem(test.word); got != test.stem {
+			t.Errorf("turkishStem(%q) = %q, want %q", test.word, got, test.stem)
+		}
+	}
+}
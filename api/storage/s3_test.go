@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeS3 is a MinIO-style stand-in keeping objects in memory. It checks the
+// parts of a Signature Version 4 request a real server checks first.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	hash := sha256.Sum256(body)
+
+	auth := r.Header.Get("Authorization")
+	switch {
+	case !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=access/"):
+		http.Error(w, "bad credential", http.StatusForbidden)
+		return
+	case !strings.Contains(auth, "/us-east-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature="):
+		http.Error(w, "bad scope", http.StatusForbidden)
+		return
+	case r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(hash[:]):
+		http.Error(w, "payload hash mismatch", http.StatusBadRequest)
+		return
+	case r.Header.Get("X-Amz-Date") == "":
+		http.Error(w, "missing date", http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := r.URL.EscapedPath()
+	switch r.Method {
+	case http.MethodPut:
+		f.objects[key] = body
+		f.types[key] = r.Header.Get("Content-Type")
+	case http.MethodGet:
+		data, ok := f.objects[key]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "unsupported", http.StatusMethodNotAllowed)
+	}
+}
+
+func newTestS3(t *testing.T) (*S3Store, *fakeS3) {
+	t.Helper()
+	fake := &fakeS3{objects: map[string][]byte{}, types: map[string]string{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	store, err := NewS3Store(S3Config{
+		Endpoint:  server.URL + "/",
+		Bucket:    "moods",
+		AccessKey: "access",
+		SecretKey: "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store, fake
+}
+
+func TestS3StoreRoundTrip(t *testing.T) {
+	store, fake := newTestS3(t)
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "attachments/1/photo one.jpg", []byte("pixels"), "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.types["/moods/attachments/1/photo%20one.jpg"]; got != "image/jpeg" {
+		t.Errorf("stored content type %q, want image/jpeg", got)
+	}
+
+	reader, err := store.Open(ctx, "attachments/1/photo one.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil || string(data) != "pixels" {
+		t.Fatalf("got %q, %v, want pixels", data, err)
+	}
+
+	if err := store.Delete(ctx, "attachments/1/photo one.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Open(ctx, "attachments/1/photo one.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("open after delete: got %v, want ErrNotFound", err)
+	}
+	// Deleting a missing blob is not an error
+	if err := store.Delete(ctx, "attachments/1/photo one.jpg"); err != nil {
+		t.Errorf("second delete: %v", err)
+	}
+}
+
+func TestS3StoreReportsServerErrors(t *testing.T) {
+	store, _ := newTestS3(t)
+	store.config.AccessKey = "someone-else"
+
+	err := store.Put(context.Background(), "key", []byte("data"), "text/plain")
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("got %v, want a 403 error", err)
+	}
+}
+
+func TestNewS3StoreRequiresConfig(t *testing.T) {
+	if _, err := NewS3Store(S3Config{Endpoint: "http://localhost:9000"}); err == nil {
+		t.Error("expected an error for missing bucket and keys")
+	}
+}
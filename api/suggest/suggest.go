@@ -0,0 +1,301 @@
+// Package suggest ranks tags for a new mood entry with small naive Bayes
+// models trained on one user's own tagged entries. An entry can have several
+// tags, so every tag gets its own one-vs-rest model deciding whether it
+// applies. The models look at the words of the entry, the time of day, the
+// day of the week and which tags the user tends to use together.
+package suggest
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	ReasonText       = "text"
+	ReasonTimeOfDay  = "time_of_day"
+	ReasonWeekday    = "weekday"
+	ReasonCoOccurred = "co_occurrence"
+
+	// minTokenLength drops short words, which are mostly suffixes and
+	// function words
+	minTokenLength = 3
+	// smoothing is the Laplace smoothing added to every count
+	smoothing = 1.0
+	// reasonLift is how much more likely a feature must be with a tag than
+	// overall to be given as a reason
+	reasonLift = 1.5
+	// minScore leaves out tags whose probability of applying is low
+	minScore = 0.2
+)
+
+// Example is a past entry and the tags it was given
+type Example struct {
+	Text string
+	Time time.Time
+	Tags []int
+}
+
+// Draft is the entry being written. Tags are the ones already chosen and
+// TagNames names them in reasons.
+type Draft struct {
+	Text     string
+	Time     time.Time
+	Tags     []int
+	TagNames map[int]string
+}
+
+type Reason struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+type Suggestion struct {
+	TagID   int      `json:"tagId"`
+	Score   float64  `json:"score"`
+	Reasons []Reason `json:"reasons"`
+}
+
+type tagStats struct {
+	entries  int
+	tokens   map[string]int
+	tokenSum int
+	dayParts [4]int
+	weekdays [7]int
+	coTagged map[int]int
+}
+
+// Model holds the per-tag counts learned from a user's entries
+type Model struct {
+	entries  int
+	tags     map[int]*tagStats
+	tokens   map[string]int
+	tokenSum int
+	dayParts [4]int
+	weekdays [7]int
+	vocab    int
+}
+
+var dayPartNames = [4]string{"at night", "in the morning", "in the afternoon", "in the evening"}
+
+// dayPart splits the day into night (0-6), morning (6-12), afternoon (12-18)
+// and evening (18-24)
+func dayPart(t time.Time) int {
+	return t.Hour() / 6
+}
+
+var stopwords = map[string]bool{
+	"the": true, "and": true, "was": true, "for": true, "with": true, "that": true,
+	"this": true, "but": true, "have": true, "had": true, "not": true, "are": true,
+	"you": true, "all": true, "just": true, "very": true, "today": true, "feel": true,
+	"bir": true, "ve": true, "bu": true, "çok": true, "ama": true, "için": true,
+	"gibi": true, "daha": true, "ben": true, "beni": true, "bana": true, "bugün": true,
+	"değil": true, "sonra": true, "kadar": true, "çünkü": true, "şey": true,
+}
+
+// tokenize returns the distinct words of text worth learning from
+func tokenize(text string) []string {
+	text = strings.ToLowerSpecial(unicode.TurkishCase, text)
+	seen := make(map[string]bool)
+	var tokens []string
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		if len([]rune(word)) < minTokenLength || stopwords[word] || seen[word] {
+			continue
+		}
+		seen[word] = true
+		tokens = append(tokens, word)
+	}
+	return tokens
+}
+
+// Train builds a model from examples. Examples without tags still count
+// towards how often a word or time is seen overall.
+func Train(examples []Example) *Model {
+	m := &Model{tags: make(map[int]*tagStats), tokens: make(map[string]int)}
+	for _, example := range examples {
+		tokens := tokenize(example.Text)
+		part, weekday := dayPart(example.Time), int(example.Time.Weekday())
+
+		m.entries++
+		m.dayParts[part]++
+		m.weekdays[weekday]++
+		for _, token := range tokens {
+			m.tokens[token]++
+		}
+		m.tokenSum += len(tokens)
+
+		for _, tagID := range example.Tags {
+			stats := m.tags[tagID]
+			if stats == nil {
+				stats = &tagStats{tokens: make(map[string]int), coTagged: make(map[int]int)}
+				m.tags[tagID] = stats
+			}
+			stats.entries++
+			stats.dayParts[part]++
+			stats.weekdays[weekday]++
+			for _, token := range tokens {
+				stats.tokens[token]++
+				stats.tokenSum++
+			}
+			for _, other := range example.Tags {
+				if other != tagID {
+					stats.coTagged[other]++
+				}
+			}
+		}
+	}
+	m.vocab = len(m.tokens)
+	return m
+}
+
+// Suggest ranks the tags not yet on the draft, best first, at most limit
+func (m *Model) Suggest(draft Draft, limit int) []Suggestion {
+	if m.entries == 0 || len(m.tags) == 0 {
+		return nil
+	}
+
+	chosen := make(map[int]bool, len(draft.Tags))
+	for _, tagID := range draft.Tags {
+		chosen[tagID] = true
+	}
+	tokens := tokenize(draft.Text)
+	part, weekday := dayPart(draft.Time), int(draft.Time.Weekday())
+
+	type scored struct {
+		tagID int
+		// logOdds is log P(tag | draft) - log P(no tag | draft)
+		logOdds float64
+		reasons []Reason
+	}
+	var candidates []scored
+	for tagID, stats := range m.tags {
+		if chosen[tagID] {
+			continue
+		}
+		candidate := scored{tagID: tagID}
+
+		// Every feature adds log P(feature | tag) - log P(feature | no tag),
+		// the entries without the tag being the overall counts minus the
+		// tag's
+		rest := m.entries - stats.entries
+		candidate.logOdds = math.Log((float64(stats.entries) + smoothing) / (float64(rest) + smoothing))
+
+		var words []string
+		for _, token := range tokens {
+			// Words never seen say nothing about any tag
+			if m.tokens[token] == 0 {
+				continue
+			}
+			given := (float64(stats.tokens[token]) + smoothing) / (float64(stats.tokenSum) + smoothing*float64(m.vocab+1))
+			without := (float64(m.tokens[token]-stats.tokens[token]) + smoothing) / (float64(m.tokenSum-stats.tokenSum) + smoothing*float64(m.vocab+1))
+			candidate.logOdds += math.Log(given / without)
+			// The reason compares how many of the tag's entries use the word
+			// with how many of all entries do
+			withTag := float64(stats.tokens[token]) / float64(stats.entries)
+			overall := float64(m.tokens[token]) / float64(m.entries)
+			if stats.tokens[token] >= 2 && withTag/overall >= reasonLift {
+				words = append(words, token)
+			}
+		}
+		if len(words) > 0 {
+			if len(words) > 3 {
+				words = words[:3]
+			}
+			candidate.reasons = append(candidate.reasons, Reason{
+				Kind:    ReasonText,
+				Message: fmt.Sprintf("You often use it when writing about %s", quoteList(words)),
+			})
+		}
+
+		partGiven := (float64(stats.dayParts[part]) + smoothing) / (float64(stats.entries) + 4*smoothing)
+		partWithout := (float64(m.dayParts[part]-stats.dayParts[part]) + smoothing) / (float64(rest) + 4*smoothing)
+		// Like unseen words, a time nobody has written at tells nothing
+		if m.dayParts[part] > 0 {
+			candidate.logOdds += math.Log(partGiven / partWithout)
+		}
+		partOverall := (float64(m.dayParts[part]) + smoothing) / (float64(m.entries) + 4*smoothing)
+		if stats.dayParts[part] >= 2 && partGiven/partOverall >= reasonLift {
+			candidate.reasons = append(candidate.reasons, Reason{
+				Kind:    ReasonTimeOfDay,
+				Message: "You often use it " + dayPartNames[part],
+			})
+		}
+
+		weekdayGiven := (float64(stats.weekdays[weekday]) + smoothing) / (float64(stats.entries) + 7*smoothing)
+		weekdayWithout := (float64(m.weekdays[weekday]-stats.weekdays[weekday]) + smoothing) / (float64(rest) + 7*smoothing)
+		if m.weekdays[weekday] > 0 {
+			candidate.logOdds += math.Log(weekdayGiven / weekdayWithout)
+		}
+		weekdayOverall := (float64(m.weekdays[weekday]) + smoothing) / (float64(m.entries) + 7*smoothing)
+		if stats.weekdays[weekday] >= 2 && weekdayGiven/weekdayOverall >= reasonLift {
+			candidate.reasons = append(candidate.reasons, Reason{
+				Kind:    ReasonWeekday,
+				Message: "You often use it on " + time.Weekday(weekday).String() + "s",
+			})
+		}
+
+		for _, other := range draft.Tags {
+			otherStats := m.tags[other]
+			if otherStats == nil {
+				continue
+			}
+			// P(other | tag) against P(other | no tag)
+			otherGiven := (float64(stats.coTagged[other]) + smoothing) / (float64(stats.entries) + 2*smoothing)
+			otherWithout := (float64(otherStats.entries-stats.coTagged[other]) + smoothing) / (float64(rest) + 2*smoothing)
+			candidate.logOdds += math.Log(otherGiven / otherWithout)
+
+			together := (float64(stats.coTagged[other]) + smoothing) / (float64(otherStats.entries) + 2*smoothing)
+			if stats.coTagged[other] >= 2 && together/(float64(stats.entries)/float64(m.entries)) >= reasonLift {
+				candidate.reasons = append(candidate.reasons, Reason{
+					Kind:    ReasonCoOccurred,
+					Message: fmt.Sprintf("You used it together with %q %d times", draft.TagNames[other], stats.coTagged[other]),
+				})
+			}
+		}
+
+		candidates = append(candidates, candidate)
+	}
+
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	// Each score is the probability that its tag applies on its own, so
+	// tags that are usually used together can all score high
+	var suggestions []Suggestion
+	for _, candidate := range candidates {
+		score := 1 / (1 + math.Exp(-candidate.logOdds))
+		if score < minScore {
+			continue
+		}
+		reasons := candidate.reasons
+		if reasons == nil {
+			reasons = []Reason{}
+		}
+		suggestions = append(suggestions, Suggestion{TagID: candidate.tagID, Score: score, Reasons: reasons})
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].TagID < suggestions[j].TagID
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+func quoteList(words []string) string {
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = `"` + word + `"`
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,78 @@
+package suggest
+
+import (
+	"testing"
+	"time"
+)
+
+func trainingExamples() []Example {
+	morning := time.Date(2026, 10, 5, 8, 0, 0, 0, time.UTC)
+	evening := time.Date(2026, 10, 7, 20, 0, 0, 0, time.UTC)
+
+	var examples []Example
+	for i := 0; i < 20; i++ {
+		examples = append(examples,
+			Example{Text: "Went running in the park", Time: morning.AddDate(0, 0, 7*i), Tags: []int{1, 2}},
+			Example{Text: "Long meeting about the deadline at work", Time: evening.AddDate(0, 0, 7*i), Tags: []int{3}},
+			Example{Text: "Quiet evening reading", Time: evening.AddDate(0, 0, 7*i+1)},
+		)
+	}
+	return examples
+}
+
+func TestSuggestScoresTagsIndependently(t *testing.T) {
+	model := Train(trainingExamples())
+	suggestions := model.Suggest(Draft{
+		Text: "Running in the park again",
+		Time: time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC),
+	}, 5)
+
+	scores := make(map[int]float64)
+	for _, suggestion := range suggestions {
+		scores[suggestion.TagID] = suggestion.Score
+	}
+
+	// Tags 1 and 2 always go together; each must be likely on its own
+	// rather than sharing one probability between them
+	for _, tagID := range []int{1, 2} {
+		if scores[tagID] < 0.9 {
+			t.Errorf("tag %d scored %.3f, want at least 0.9", tagID, scores[tagID])
+		}
+	}
+	if _, ok := scores[3]; ok {
+		t.Errorf("tag 3 suggested with score %.3f", scores[3])
+	}
+}
+
+func TestSuggestSkipsChosenTags(t *testing.T) {
+	model := Train(trainingExamples())
+	suggestions := model.Suggest(Draft{
+		Text:     "Running in the park again",
+		Time:     time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC),
+		Tags:     []int{1},
+		TagNames: map[int]string{1: "exercise"},
+	}, 5)
+
+	if len(suggestions) == 0 || suggestions[0].TagID != 2 {
+		t.Fatalf("got %+v, want tag 2 first", suggestions)
+	}
+	for _, suggestion := range suggestions {
+		if suggestion.TagID == 1 {
+			t.Error("a chosen tag was suggested again")
+		}
+	}
+}
+
+func TestSuggestWithoutEvidence(t *testing.T) {
+	model := Train(trainingExamples())
+	suggestions := model.Suggest(Draft{
+		Text: "Something entirely different",
+		Time: time.Date(2026, 10, 18, 3, 0, 0, 0, time.UTC),
+	}, 5)
+
+	for _, suggestion := range suggestions {
+		if suggestion.Score >= 0.5 {
+			t.Errorf("tag %d scored %.3f without evidence", suggestion.TagID, suggestion.Score)
+		}
+	}
+}
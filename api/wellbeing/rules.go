@@ -0,0 +1,190 @@
+// Package wellbeing evaluates rolling windows of daily mood valence and
+// flags patterns worth a gentle check-in
+package wellbeing
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	RuleSustainedLow   = "sustained_low"
+	RuleConsecutiveLow = "consecutive_low"
+	RuleSharpDrop      = "sharp_drop"
+)
+
+// Settings are a user's thresholds. Valences run from -1 to 1.
+type Settings struct {
+	WindowDays int
+	Threshold  float64
+	MinLowDays int
+}
+
+// Day is the average valence of the entries of one local day. Days without
+// entries are left out.
+type Day struct {
+	Date    time.Time
+	Valence float64
+}
+
+// Finding is a rule that matched, with the window it matched on
+type Finding struct {
+	Rule           string
+	WindowStart    time.Time
+	WindowEnd      time.Time
+	LowDays        int
+	AverageValence float64
+	Message        string
+}
+
+// Rule inspects the days up to and including today
+type Rule interface {
+	Name() string
+	Evaluate(days []Day, today time.Time, settings Settings) (Finding, bool)
+}
+
+// Rules are evaluated in order; each can produce at most one finding
+var Rules = []Rule{
+	sustainedLowRule{},
+	consecutiveLowRule{},
+	sharpDropRule{},
+}
+
+// Evaluate runs every rule against days, which must be sorted by date
+func Evaluate(days []Day, today time.Time, settings Settings) []Finding {
+	var findings []Finding
+	for _, rule := range Rules {
+		if finding, ok := rule.Evaluate(days, today, settings); ok {
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// window returns the days of the windowDays-long window ending today, or
+// offset windows earlier, with the window's first and last date
+func window(days []Day, today time.Time, windowDays, offset int) ([]Day, time.Time, time.Time) {
+	end := today.AddDate(0, 0, 1-offset*windowDays)
+	start := end.AddDate(0, 0, -windowDays)
+	var inWindow []Day
+	for _, day := range days {
+		if !day.Date.Before(start) && day.Date.Before(end) {
+			inWindow = append(inWindow, day)
+		}
+	}
+	return inWindow, start, end.AddDate(0, 0, -1)
+}
+
+func average(days []Day) float64 {
+	var sum float64
+	for _, day := range days {
+		sum += day.Valence
+	}
+	return sum / float64(len(days))
+}
+
+// sustainedLowRule matches when most logged days of the window were low and
+// the window as a whole averaged low
+type sustainedLowRule struct{}
+
+func (sustainedLowRule) Name() string { return RuleSustainedLow }
+
+func (r sustainedLowRule) Evaluate(days []Day, today time.Time, settings Settings) (Finding, bool) {
+	inWindow, start, end := window(days, today, settings.WindowDays, 0)
+	if len(inWindow) < settings.MinLowDays {
+		return Finding{}, false
+	}
+
+	lowDays := 0
+	for _, day := range inWindow {
+		if day.Valence <= settings.Threshold {
+			lowDays++
+		}
+	}
+	avg := average(inWindow)
+	if lowDays < settings.MinLowDays || avg > settings.Threshold {
+		return Finding{}, false
+	}
+
+	return Finding{
+		Rule:           r.Name(),
+		WindowStart:    start,
+		WindowEnd:      end,
+		LowDays:        lowDays,
+		AverageValence: avg,
+		Message:        fmt.Sprintf("You've logged low moods on %d of the last %d days. How are you doing? It might help to talk to someone you trust.", lowDays, settings.WindowDays),
+	}, true
+}
+
+// consecutiveLowRule matches a run of MinLowDays calendar days in a row,
+// ending today or yesterday, that were all logged and all low
+type consecutiveLowRule struct{}
+
+func (consecutiveLowRule) Name() string { return RuleConsecutiveLow }
+
+func (r consecutiveLowRule) Evaluate(days []Day, today time.Time, settings Settings) (Finding, bool) {
+	byDate := make(map[string]float64, len(days))
+	for _, day := range days {
+		byDate[day.Date.Format("2006-01-02")] = day.Valence
+	}
+
+	// Today may not be logged yet, so a run ending yesterday still counts
+	end := today
+	if _, ok := byDate[end.Format("2006-01-02")]; !ok {
+		end = end.AddDate(0, 0, -1)
+	}
+
+	var run []Day
+	for day := end; ; day = day.AddDate(0, 0, -1) {
+		valence, ok := byDate[day.Format("2006-01-02")]
+		if !ok || valence > settings.Threshold {
+			break
+		}
+		run = append(run, Day{Date: day, Valence: valence})
+	}
+	if len(run) < settings.MinLowDays {
+		return Finding{}, false
+	}
+
+	return Finding{
+		Rule:           r.Name(),
+		WindowStart:    run[len(run)-1].Date,
+		WindowEnd:      end,
+		LowDays:        len(run),
+		AverageValence: average(run),
+		Message:        fmt.Sprintf("The last %d days in a row have felt heavy. You don't have to carry it alone; reaching out can make a difference.", len(run)),
+	}, true
+}
+
+// sharpDropRule matches when the window's average fell well below the
+// previous window's, even if it is not low yet
+type sharpDropRule struct{}
+
+// sharpDropDelta is how far, on the -1..1 scale, the average has to fall
+const sharpDropDelta = 0.5
+
+func (sharpDropRule) Name() string { return RuleSharpDrop }
+
+func (r sharpDropRule) Evaluate(days []Day, today time.Time, settings Settings) (Finding, bool) {
+	current, start, end := window(days, today, settings.WindowDays, 0)
+	previous, _, _ := window(days, today, settings.WindowDays, 1)
+
+	// Both windows need enough entries to compare them fairly
+	minDays := (settings.MinLowDays + 1) / 2
+	if len(current) < minDays || len(previous) < minDays {
+		return Finding{}, false
+	}
+
+	currentAverage, previousAverage := average(current), average(previous)
+	if previousAverage-currentAverage < sharpDropDelta {
+		return Finding{}, false
+	}
+
+	return Finding{
+		Rule:           r.Name(),
+		WindowStart:    start,
+		WindowEnd:      end,
+		AverageValence: currentAverage,
+		Message:        "Your mood has dipped compared to the days before. Want to take a moment to check in with yourself?",
+	}, true
+}
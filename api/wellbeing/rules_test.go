@@ -0,0 +1,98 @@
+package wellbeing
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	today    = time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	settings = Settings{WindowDays: 7, Threshold: -0.3, MinLowDays: 4}
+)
+
+// daysAgo builds sorted days from valences keyed by how many days before
+// today they were logged
+func daysAgo(valences map[int]float64) []Day {
+	var days []Day
+	for offset := 30; offset >= 0; offset-- {
+		if valence, ok := valences[offset]; ok {
+			days = append(days, Day{Date: today.AddDate(0, 0, -offset), Valence: valence})
+		}
+	}
+	return days
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  Rule
+		days  map[int]float64
+		match bool
+		// start and end of the finding's window, in days before today
+		start, end int
+		lowDays    int
+	}{
+		{"sustained exactly min low days", sustainedLowRule{}, map[int]float64{0: -0.5, 2: -0.5, 4: -0.5, 6: -0.5}, true, 6, 0, 4},
+		{"sustained one low day short", sustainedLowRule{}, map[int]float64{0: -0.5, 2: -0.5, 4: -0.5}, false, 0, 0, 0},
+		{"sustained average at threshold", sustainedLowRule{}, map[int]float64{0: -0.5, 1: -0.5, 2: -0.5, 3: -0.5, 4: 0.5}, true, 6, 0, 4},
+		{"sustained average above threshold", sustainedLowRule{}, map[int]float64{0: -0.5, 1: -0.5, 2: -0.5, 3: -0.5, 4: 0.8, 5: 0.8, 6: 0.8}, false, 0, 0, 0},
+		{"sustained low day outside window", sustainedLowRule{}, map[int]float64{4: -0.5, 5: -0.5, 6: -0.5, 7: -0.5}, false, 0, 0, 0},
+
+		{"consecutive exactly min low days", consecutiveLowRule{}, map[int]float64{0: -0.5, 1: -0.5, 2: -0.5, 3: -0.5}, true, 3, 0, 4},
+		{"consecutive one day short", consecutiveLowRule{}, map[int]float64{0: -0.5, 1: -0.5, 2: -0.5}, false, 0, 0, 0},
+		{"consecutive run ends yesterday", consecutiveLowRule{}, map[int]float64{1: -0.5, 2: -0.5, 3: -0.5, 4: -0.5}, true, 4, 1, 4},
+		{"consecutive run ends yesterday, today fine", consecutiveLowRule{}, map[int]float64{0: 0.5, 1: -0.5, 2: -0.5, 3: -0.5, 4: -0.5}, false, 0, 0, 0},
+		{"consecutive run ends two days ago", consecutiveLowRule{}, map[int]float64{2: -0.5, 3: -0.5, 4: -0.5, 5: -0.5}, false, 0, 0, 0},
+		{"consecutive gap in run", consecutiveLowRule{}, map[int]float64{0: -0.5, 1: -0.5, 3: -0.5, 4: -0.5}, false, 0, 0, 0},
+		{"consecutive at threshold", consecutiveLowRule{}, map[int]float64{0: -0.3, 1: -0.3, 2: -0.3, 3: -0.3}, true, 3, 0, 4},
+
+		{"sharp drop exactly delta", sharpDropRule{}, map[int]float64{0: -0.25, 3: -0.25, 8: 0.25, 12: 0.25}, true, 6, 0, 0},
+		{"sharp drop below delta", sharpDropRule{}, map[int]float64{0: -0.2, 3: -0.2, 8: 0.25, 12: 0.25}, false, 0, 0, 0},
+		{"sharp drop too few current days", sharpDropRule{}, map[int]float64{0: -0.75, 8: 0.25, 12: 0.25}, false, 0, 0, 0},
+		{"sharp drop too few previous days", sharpDropRule{}, map[int]float64{0: -0.75, 3: -0.75, 8: 0.25}, false, 0, 0, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			finding, ok := test.rule.Evaluate(daysAgo(test.days), today, settings)
+			if ok != test.match {
+				t.Fatalf("Evaluate() matched = %v, want %v", ok, test.match)
+			}
+			if !ok {
+				return
+			}
+			if finding.Rule != test.rule.Name() {
+				t.Errorf("Rule = %s, want %s", finding.Rule, test.rule.Name())
+			}
+			if want := today.AddDate(0, 0, -test.start); !finding.WindowStart.Equal(want) {
+				t.Errorf("WindowStart = %s, want %s", finding.WindowStart, want)
+			}
+			if want := today.AddDate(0, 0, -test.end); !finding.WindowEnd.Equal(want) {
+				t.Errorf("WindowEnd = %s, want %s", finding.WindowEnd, want)
+			}
+			if finding.LowDays != test.lowDays {
+				t.Errorf("LowDays = %d, want %d", finding.LowDays, test.lowDays)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	// A good previous week followed by four low days in a row matches every
+	// rule, in rule order
+	days := daysAgo(map[int]float64{0: -0.6, 1: -0.6, 2: -0.6, 3: -0.6, 8: 0.4, 10: 0.4, 12: 0.4})
+	findings := Evaluate(days, today, settings)
+
+	want := []string{RuleSustainedLow, RuleConsecutiveLow, RuleSharpDrop}
+	if len(findings) != len(want) {
+		t.Fatalf("Evaluate() found %d rules, want %d", len(findings), len(want))
+	}
+	for i, finding := range findings {
+		if finding.Rule != want[i] {
+			t.Errorf("finding %d = %s, want %s", i, finding.Rule, want[i])
+		}
+	}
+
+	if findings := Evaluate(nil, today, settings); len(findings) != 0 {
+		t.Errorf("Evaluate(no days) found %d rules, want none", len(findings))
+	}
+}
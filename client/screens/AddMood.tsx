@@ -118,7 +118,7 @@ const AddMood: React.FC = () => {
   }, []);
 
   const handleQuantityChange = useCallback((foodId: number, quantity: string) => {
-    const numQuantity = parseInt(quantity) || 0;
+    const numQuantity = parseFloat(quantity) || 0;
     setSelectedFoods(prev => 
       prev.map(item => 
         item.id === foodId 
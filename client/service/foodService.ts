@@ -1,4 +1,5 @@
 import api from "../api";
+import { BulkUserFoodResponse, Decimal, Diary, DiaryDay, Unit, UserFood } from "../types/Food";
 
 /**
  * Represents a food item.
@@ -50,6 +51,20 @@ export const getFoods = async (): Promise<Food[]> => {
   }
 };
 
+/**
+ * Retrieves the units food quantities can be given in, besides servings.
+ * @returns A Promise containing an array of Unit objects.
+ * @throws Throws an error if the API call fails.
+ */
+export const getUnits = async (): Promise<Unit[]> => {
+  try {
+    const response = await api.get<Unit[]>('/foods/units');
+    return response.data;
+  } catch (error) {
+    throw handleApiError(error, 'Failed to fetch units');
+  }
+};
+
 /**
  * Creates a new food item in the API.
  * @param food - The food data to be created.
@@ -110,30 +125,89 @@ export const addMultipleUserFoods = async (foods: FoodInput[]): Promise<Food[]>
 };
 
 /**
- * Retrieves food items by date from the API.
- * @param date - The date to filter food items.
- * @returns A Promise containing an array of Food objects.
+ * Retrieves the user's food entries for a day from the diary.
+ * @param date - The day in YYYY-MM-DD, in the user's timezone.
+ * @returns A Promise containing the entries of that day.
  * @throws Throws an error if the API call fails.
  */
-export const getFoodsByDate = async (date: string): Promise<Food[]> => {
+export const getFoodsByDate = async (date: string): Promise<UserFood[]> => {
   try {
-    const response = await api.get<Food[]>(`/foods/date/${date}`);
-    return response.data;
+    const response = await api.get<DiaryDay>(`/diary/${date}`);
+    return response.data.entries;
   } catch (error) {
     throw handleApiError(error, 'Failed to fetch foods by date');
   }
 };
 
+/**
+ * Retrieves the food diary with daily totals.
+ * @param from - The first day in YYYY-MM-DD, today if omitted.
+ * @param to - The last day in YYYY-MM-DD, inclusive.
+ * @returns A Promise containing the diary.
+ * @throws Throws an error if the API call fails.
+ */
+export const getDiary = async (from?: string, to?: string): Promise<Diary> => {
+  try {
+    const response = await api.get<Diary>('/diary', { params: { from, to } });
+    return response.data;
+  } catch (error) {
+    throw handleApiError(error, 'Failed to fetch diary');
+  }
+};
+
+/**
+ * Updates the amount, time, meal or tags of a diary entry. A mealId of 0
+ * takes the entry out of its meal.
+ * @param id - The ID of the entry to update.
+ * @param entry - The fields to change.
+ * @returns A Promise containing the updated entry.
+ * @throws Throws an error if the API call fails.
+ */
+export const updateUserFood = async (
+  id: number,
+  entry: { quantity?: Decimal | number; unit?: string; eatenAt?: string; mealId?: number; tags?: number[] },
+): Promise<UserFood> => {
+  try {
+    const response = await api.put<UserFood>(`/diary/${id}`, entry);
+    return response.data;
+  } catch (error) {
+    throw handleApiError(error, 'Failed to update diary entry');
+  }
+};
+
+/**
+ * Deletes a diary entry.
+ * @param id - The ID of the entry to delete.
+ * @throws Throws an error if the API call fails.
+ */
+export const deleteUserFood = async (id: number): Promise<void> => {
+  try {
+    await api.delete(`/diary/${id}`);
+  } catch (error) {
+    throw handleApiError(error, 'Failed to delete diary entry');
+  }
+};
+
 export interface UserFoodInput {
   foodId: number;
-  quantity: number;
+  quantity: Decimal | number;
+  unit?: string;
   eatenAt: string;
+  mealId?: number;
+  tags?: number[];
 }
 
-export const addUserFoods = async (userFoods: UserFoodInput[]): Promise<Food[]> => {
+/**
+ * Logs several food entries at once. Nothing is logged unless every entry is
+ * valid.
+ * @param userFoods - The entries to log.
+ * @returns A Promise containing the created entries.
+ * @throws Throws an error if the API call fails or any entry is invalid.
+ */
+export const addUserFoods = async (userFoods: UserFoodInput[]): Promise<UserFood[]> => {
   try {
-    const response = await api.post<Food[]>('/foods/multiple', userFoods);
-    return response.data;
+    const response = await api.post<BulkUserFoodResponse>('/foods/multiple', userFoods);
+    return response.data.results.flatMap((result) => (result.entry ? [result.entry] : []));
   } catch (error) {
     throw handleApiError(error, 'Failed to add user foods');
   }
@@ -0,0 +1,186 @@
+import api from "../api";
+import { Meal, MealSlot } from "../types/Food";
+
+/**
+ * Represents the input data required to create or update a meal slot.
+ */
+export interface MealSlotInput {
+  name: string; // Name of the slot, like Breakfast
+  time: string; // Usual local time of the meal, HH:MM
+}
+
+/**
+ * Handles API errors by returning a formatted error message.
+ * @param error - The error object caught during the API call.
+ * @param message - A custom message to include in the error.
+ * @returns A new Error object with a formatted message.
+ */
+const handleApiError = (error: unknown, message: string): Error => {
+  return new Error(`Error: ${error instanceof Error ? error.message : 'Unknown error'} - ${message}`);
+};
+
+/**
+ * Retrieves the user's meal slots in order. New users start with breakfast,
+ * lunch, snacks and dinner; the list is empty if every slot was deleted.
+ * @returns A Promise containing an array of MealSlot objects.
+ * @throws Throws an error if the API call fails.
+ */
+export const getMealSlots = async (): Promise<MealSlot[]> => {
+  try {
+    const response = await api.get<MealSlot[]>('/meal-slots');
+    return response.data;
+  } catch (error) {
+    throw handleApiError(error, 'Failed to fetch meal slots');
+  }
+};
+
+/**
+ * Gives a user without meal slots breakfast, lunch, snacks and dinner again.
+ * @returns A Promise containing the created MealSlot objects.
+ * @throws Throws an error if the API call fails, or if the user has slots.
+ */
+export const addDefaultMealSlots = async (): Promise<MealSlot[]> => {
+  try {
+    const response = await api.post<MealSlot[]>('/meal-slots/defaults');
+    return response.data;
+  } catch (error) {
+    throw handleApiError(error, 'Failed to add default meal slots');
+  }
+};
+
+/**
+ * Adds a meal slot after the user's other slots.
+ * @param slot - The slot to add.
+ * @returns A Promise containing the created MealSlot.
+ * @throws Throws an error if the API call fails.
+ */
+export const createMealSlot = async (slot: MealSlotInput): Promise<MealSlot> => {
+  try {
+    const response = await api.post<MealSlot>('/meal-slots', slot);
+    return response.data;
+  } catch (error) {
+    throw handleApiError(error, 'Failed to create meal slot');
+  }
+};
+
+/**
+ * Renames a meal slot or changes its time.
+ * @param id - The ID of the slot to update.
+ * @param slot - The new name and time.
+ * @returns A Promise containing the updated MealSlot.
+ * @throws Throws an error if the API call fails.
+ */
+export const updateMealSlot = async (id: number, slot: MealSlotInput): Promise<MealSlot> => {
+  try {
+    const response = await api.put<MealSlot>(`/meal-slots/${id}`, slot);
+    return response.data;
+  } catch (error) {
+    throw handleApiError(error, 'Failed to update meal slot');
+  }
+};
+
+/**
+ * Deletes a meal slot and its meals. Their entries and moods are kept.
+ * @param id - The ID of the slot to delete.
+ * @throws Throws an error if the API call fails.
+ */
+export const deleteMealSlot = async (id: number): Promise<void> => {
+  try {
+    await api.delete(`/meal-slots/${id}`);
+  } catch (error) {
+    throw handleApiError(error, 'Failed to delete meal slot');
+  }
+};
+
+/**
+ * Stores a new order for the meal slots.
+ * @param ids - The ID of every slot, in the new order.
+ * @throws Throws an error if the API call fails.
+ */
+export const reorderMealSlots = async (ids: number[]): Promise<void> => {
+  try {
+    await api.put('/meal-slots/order', { ids });
+  } catch (error) {
+    throw handleApiError(error, 'Failed to reorder meal slots');
+  }
+};
+
+/**
+ * Retrieves the meals between two local dates, both inclusive, with their
+ * entries and nutrition totals.
+ * @param from - The first date, YYYY-MM-DD.
+ * @param to - The last date, YYYY-MM-DD.
+ * @returns A Promise containing an array of Meal objects.
+ * @throws Throws an error if the API call fails.
+ */
+export const getMeals = async (from: string, to: string): Promise<Meal[]> => {
+  try {
+    const response = await api.get<Meal[]>('/meals', { params: { from, to } });
+    return response.data;
+  } catch (error) {
+    throw handleApiError(error, 'Failed to fetch meals');
+  }
+};
+
+/**
+ * Starts the meal of a slot on a date, or returns the one already there.
+ * @param slotId - The ID of the meal slot.
+ * @param date - The local date, YYYY-MM-DD.
+ * @returns A Promise containing the Meal.
+ * @throws Throws an error if the API call fails.
+ */
+export const createMeal = async (slotId: number, date: string): Promise<Meal> => {
+  try {
+    const response = await api.post<Meal>('/meals', { slotId, date });
+    return response.data;
+  } catch (error) {
+    throw handleApiError(error, 'Failed to create meal');
+  }
+};
+
+/**
+ * Changes the time of a meal within its day.
+ * @param id - The ID of the meal.
+ * @param eatenAt - The new time.
+ * @returns A Promise containing the updated Meal.
+ * @throws Throws an error if the API call fails.
+ */
+export const updateMeal = async (id: number, eatenAt: string): Promise<Meal> => {
+  try {
+    const response = await api.put<Meal>(`/meals/${id}`, { eatenAt });
+    return response.data;
+  } catch (error) {
+    throw handleApiError(error, 'Failed to update meal');
+  }
+};
+
+/**
+ * Deletes a meal. Its entries and moods are kept.
+ * @param id - The ID of the meal to delete.
+ * @throws Throws an error if the API call fails.
+ */
+export const deleteMeal = async (id: number): Promise<void> => {
+  try {
+    await api.delete(`/meals/${id}`);
+  } catch (error) {
+    throw handleApiError(error, 'Failed to delete meal');
+  }
+};
+
+/**
+ * Logs the entries of a meal again on another date, like having the same
+ * breakfast as yesterday.
+ * @param id - The ID of the meal to copy.
+ * @param date - The local date to copy it to, YYYY-MM-DD.
+ * @param slotId - The slot to copy it into, the meal's own by default.
+ * @returns A Promise containing the meal the entries were copied to.
+ * @throws Throws an error if the API call fails.
+ */
+export const copyMeal = async (id: number, date: string, slotId?: number): Promise<Meal> => {
+  try {
+    const response = await api.post<Meal>(`/meals/${id}/copy`, { date, slotId });
+    return response.data;
+  } catch (error) {
+    throw handleApiError(error, 'Failed to copy meal');
+  }
+};
@@ -12,6 +12,8 @@ interface MoodInput {
   description: string;
   emoji: string;
   tags: number[];
+  // Links the mood to a meal, for how it felt after eating
+  mealId?: number;
 }
 
 /**
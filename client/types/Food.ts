@@ -1,10 +1,67 @@
+import { Mood } from './Mood';
+import { Tag } from './Tag';
+
+// Decimal amounts are sent as strings to keep their precision
+export type Decimal = string;
+
+export type NutrientBasis = 'serving' | '100g';
+
+export interface FoodMicronutrient {
+  id: number;
+  foodId: number;
+  nutrient: string;
+  amount: Decimal;
+}
+
+export interface FoodServing {
+  id: number;
+  foodId: number;
+  name: string;
+  grams: Decimal;
+}
+
+export interface Unit {
+  key: string;
+  name: string;
+  kind: 'mass' | 'volume';
+  system: 'metric' | 'imperial';
+  base: Decimal;
+}
+
+export interface Micronutrient {
+  key: string;
+  name: string;
+  unit: string;
+}
+
+export interface NutritionFacts {
+  calories: Decimal;
+  protein: Decimal;
+  carbohydrate: Decimal;
+  fat: Decimal;
+  fiber: Decimal;
+  sugar: Decimal;
+  sodium: Decimal;
+  micronutrients: Record<string, Decimal>;
+}
+
 export interface Food {
   id: number;
-  tags:any
   name: string;
   calories: number;
+  protein: Decimal;
+  carbohydrate: Decimal;
+  fat: Decimal;
+  fiber: Decimal;
+  sugar: Decimal;
+  sodium: Decimal;
+  micronutrients?: FoodMicronutrient[];
+  nutrientBasis: NutrientBasis;
+  servingGrams?: Decimal | null;
+  gramsPerMl?: Decimal | null;
+  servings?: FoodServing[];
   categoryId: number;
-  tag:any;
+  tags?: Tag[];
   category: {
     id: number;
     name: string;
@@ -17,20 +74,92 @@ export interface UserFood {
   userId: number;
   foodId: number;
   food: Food;
-  quantity: number;
+  quantity: Decimal;
+  // "serving", a unit key or the name of one of the food's servings
+  unit: string;
+  grams?: Decimal | null;
+  eatenAt: string;
+  mealId?: number | null;
   createdAt: string;
-  tag:any;
+  tags: Tag[];
+  nutrients?: NutritionFacts;
 }
 
 export interface FoodInput {
   name: string;
   calories: number;
   categoryId: number;
-  tag:any;
+  protein?: Decimal | number;
+  carbohydrate?: Decimal | number;
+  fat?: Decimal | number;
+  fiber?: Decimal | number;
+  sugar?: Decimal | number;
+  sodium?: Decimal | number;
+  micronutrients?: Record<string, Decimal | number>;
+  nutrientBasis?: NutrientBasis;
+  servingGrams?: Decimal | number;
+  gramsPerMl?: Decimal | number;
+  servings?: { name: string; grams: Decimal | number }[];
 }
 
 export interface UserFoodInput {
   foodId: number;
-  quantity: number;
-  tag:any;
-}
\ No newline at end of file
+  quantity: Decimal | number;
+  unit?: string;
+  eatenAt: string;
+  mealId?: number;
+  tags?: number[];
+}
+
+export interface DiaryTotals extends NutritionFacts {
+  entries: number;
+}
+
+export interface DiaryDay {
+  date: string;
+  entries: UserFood[];
+  totals: DiaryTotals;
+}
+
+export interface Diary {
+  from: string;
+  to: string;
+  timezone: string;
+  days: DiaryDay[];
+  totals: DiaryTotals;
+}
+
+export interface BulkUserFoodResult {
+  index: number;
+  status: 'created' | 'failed' | 'skipped';
+  error?: string;
+  entry?: UserFood;
+}
+
+export interface BulkUserFoodResponse {
+  created: number;
+  failed: number;
+  results: BulkUserFoodResult[];
+}
+
+export interface MealSlot {
+  id: number;
+  name: string;
+  // Usual local time of the meal, HH:MM
+  time: string;
+  position: number;
+  userId: number;
+}
+
+export interface Meal {
+  id: number;
+  userId: number;
+  slotId: number;
+  slot?: MealSlot;
+  // Local day of the meal, YYYY-MM-DD
+  date: string;
+  eatenAt: string;
+  entries: UserFood[];
+  moods?: Mood[];
+  totals: DiaryTotals;
+}
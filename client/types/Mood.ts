@@ -3,12 +3,56 @@ export interface Mood {
   title: string;
   description: string;
   emoji: string;
+  valence?: number | null;
+  sentiment?: number | null;
+  safety?: SafetyPayload | null;
+  checkIns?: CheckIn[];
+  moodTypeId?: number | null;
+  mealId?: number | null;
+  components?: MoodComponent[];
   tags: Tag[];
   createdAt: string;
   updatedAt: string;
   userId: string;
 }
 
+export interface MoodComponent {
+  id: number;
+  moodId: number;
+  emoji: string;
+  moodTypeId?: number | null;
+  weight: number;
+  valence?: number | null;
+}
+
+export interface SupportResource {
+  id: number;
+  country: string;
+  kind: string;
+  name: string;
+  phone?: string | null;
+  url?: string | null;
+  description?: string | null;
+  position: number;
+}
+
+export interface SafetyPayload {
+  severity: 'high' | 'concern';
+  message: string;
+  resources: SupportResource[];
+}
+
+export interface CheckIn {
+  id: number;
+  rule: string;
+  message: string;
+  windowStart: string;
+  windowEnd: string;
+  averageValence: number;
+  dismissedAt?: string | null;
+  createdAt: string;
+}
+
 export interface Tag {
   id: string;
   name: string;
@@ -2,6 +2,10 @@ export interface Tag {
   id: string;
   name: string;
   color?: string;
+  icon?: string | null;
+  groupId?: number | null;
+  parentId?: number | null;
+  position?: number;
   createdAt: Date;
   updatedAt: Date;
 }
@@ -30,7 +34,40 @@ export interface TagResponse {
   updatedAt: string;
 }
 
+export interface TagNode {
+  id: number;
+  name: string;
+  color: string | null;
+  icon: string | null;
+  position: number;
+  archived: boolean;
+  children: TagNode[];
+}
 
+export interface TagGroup {
+  id: number;
+  name: string;
+  color: string | null;
+  icon: string | null;
+  position: number;
+  tags: TagNode[];
+}
 
+export interface TagTree {
+  groups: TagGroup[];
+  ungrouped: TagNode[];
+}
 
+export interface TagSuggestionReason {
+  kind: 'text' | 'time_of_day' | 'weekday' | 'co_occurrence';
+  message: string;
+}
 
+export interface TagSuggestion {
+  id: number;
+  name: string;
+  color: string | null;
+  icon: string | null;
+  score: number;
+  reasons: TagSuggestionReason[];
+}